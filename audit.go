@@ -0,0 +1,130 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one outbound call. Bodies are recorded only as
+// SHA-256 hashes, never in the clear. Headers are recorded with
+// credentials redacted and the request's Scrubber applied.
+type AuditRecord struct {
+	Time           time.Time     `json:"time"`
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	Caller         string        `json:"caller,omitempty"`
+	Meta           Meta          `json:"meta,omitempty"`
+	StatusCode     int           `json:"status_code"`
+	Latency        time.Duration `json:"latency"`
+	RequestSHA256  string        `json:"request_sha256"`
+	ResponseSHA256 string        `json:"response_sha256"`
+	RequestHeader  http.Header   `json:"request_header,omitempty"`
+	ResponseHeader http.Header   `json:"response_header,omitempty"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// AuditSink receives an AuditRecord for every request sent over the wire.
+type AuditSink interface {
+	Record(record AuditRecord)
+}
+
+// AuditSinkFunc adapts a func to an AuditSink.
+type AuditSinkFunc func(record AuditRecord)
+
+func (f AuditSinkFunc) Record(record AuditRecord) { f(record) }
+
+// JSONAuditSink writes records to w as JSON lines.
+type JSONAuditSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONAuditSink returns a JSONAuditSink writing to w.
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{enc: json.NewEncoder(w)}
+}
+
+func (s *JSONAuditSink) Record(record AuditRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(record)
+}
+
+type auditRecorder struct {
+	sink    AuditSink
+	options *RequestOptions
+	record  AuditRecord
+	start   time.Time
+}
+
+// startAudit hashes the request body when auditing is enabled, returning a
+// reader replaying the hashed bytes. A nil recorder is returned otherwise.
+func startAudit(options *RequestOptions, body io.Reader) (*auditRecorder, io.Reader, error) {
+	if options.AuditSink == nil {
+		return nil, body, nil
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = io.ReadAll(body); err != nil {
+			return nil, nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	target := options.URL
+	if u, err := url.Parse(options.URL); err == nil {
+		target = u.Redacted()
+	}
+	start := time.Now()
+	return &auditRecorder{
+		sink:    options.AuditSink,
+		options: options,
+		start:   start,
+		record: AuditRecord{
+			Time:          start,
+			Method:        options.Method,
+			URL:           scrubText(options, target),
+			Caller:        options.CallerIdentity,
+			Meta:          options.Meta,
+			RequestSHA256: sha256Hex(data),
+			RequestHeader: scrubHeader(options, requestHeaders(options)),
+		},
+	}, body, nil
+}
+
+func (a *auditRecorder) finish(statusCode int, header http.Header, body []byte, err error) {
+	if a == nil {
+		return
+	}
+	a.record.StatusCode = statusCode
+	a.record.Latency = time.Since(a.start)
+	a.record.ResponseSHA256 = sha256Hex(body)
+	a.record.ResponseHeader = scrubHeader(a.options, header)
+	if err != nil {
+		a.record.Error = scrubError(a.options, err).Error()
+	}
+	a.sink.Record(a.record)
+}
+
+// requestHeaders returns the headers set on options as an http.Header.
+func requestHeaders(options *RequestOptions) http.Header {
+	header := make(http.Header, len(options.Headers))
+	for name, value := range options.Headers {
+		header.Set(name, value)
+	}
+	return header
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
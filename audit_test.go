@@ -0,0 +1,50 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog_RecordsHashesNotBodies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("card accepted"))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(strings.Replace(ts.URL, "http://", "http://user:secret@", 1)),
+		httpclientutils.WithBody("4111111111111111"),
+		httpclientutils.WithAuditLog(httpclientutils.NewJSONAuditSink(&buf)),
+		httpclientutils.WithCallerIdentity("billing-service"),
+	)
+	assert.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "4111111111111111")
+	assert.NotContains(t, buf.String(), "card accepted")
+	assert.NotContains(t, buf.String(), "secret")
+
+	var record httpclientutils.AuditRecord
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, http.MethodPost, record.Method)
+	assert.Equal(t, "billing-service", record.Caller)
+	assert.Equal(t, http.StatusCreated, record.StatusCode)
+	assert.Equal(t, hash("4111111111111111"), record.RequestSHA256)
+	assert.Equal(t, hash("card accepted"), record.ResponseSHA256)
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
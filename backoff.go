@@ -0,0 +1,97 @@
+package httpclientutils
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffPolicy describes an exponential retry schedule. It matches the
+// backoff used by webhooks, long polling, the outbox and chunked uploads
+// when Multiplier and Jitter are left zero.
+type BackoffPolicy struct {
+	Initial     time.Duration // wait after the first failure; defaults to a second
+	Max         time.Duration // cap on any wait; defaults to a minute
+	Multiplier  float64       // growth per failure; defaults to 2
+	Jitter      float64       // fraction of each wait randomized away, from 0 to 1
+	JitterSeed  uint64        // seeds the jitter so schedules are reproducible
+	MaxAttempts int           // attempts before giving up, for SimulateRetries; defaults to 5
+}
+
+// NextDelay returns the wait after the given number of failed attempts,
+// starting at one. It is a pure function of its arguments: with jitter the
+// same seed and attempt always give the same delay.
+func NextDelay(attempt int, policy BackoffPolicy) time.Duration {
+	initial := policy.Initial
+	if initial <= 0 {
+		initial = time.Second
+	}
+	limit := policy.Max
+	if limit <= 0 {
+		limit = time.Minute
+	}
+	multiplier := policy.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	wait := float64(initial)
+	for i := 1; i < attempt && wait < float64(limit); i++ {
+		wait *= multiplier
+	}
+	wait = min(wait, float64(limit))
+	if policy.Jitter > 0 {
+		r := rand.New(rand.NewPCG(policy.JitterSeed, uint64(attempt))).Float64()
+		wait -= wait * min(policy.Jitter, 1) * r
+	}
+	return time.Duration(wait)
+}
+
+// SimulatedAttempt is the scripted outcome of one attempt for
+// SimulateRetries.
+type SimulatedAttempt struct {
+	StatusCode int           // zero for a transport error
+	Latency    time.Duration // how long the attempt takes
+	RetryAfter time.Duration // a Retry-After the response carries, if any
+}
+
+// SimulatedRetry reports when an attempt fires in a simulated schedule.
+type SimulatedRetry struct {
+	Attempt    int
+	Start      time.Duration // offset from the first attempt
+	Delay      time.Duration // wait before this attempt
+	StatusCode int
+	Final      bool // no further attempt follows
+}
+
+// SimulateRetries replays outcomes against policy, retrying transport
+// errors, 408, 429 and 5xx like SendWebhook and honoring Retry-After, and
+// reports when each attempt would fire. It stops at the first outcome
+// that is not retried, at MaxAttempts, or when outcomes run out.
+func SimulateRetries(policy BackoffPolicy, outcomes []SimulatedAttempt) []SimulatedRetry {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	var (
+		schedule []SimulatedRetry
+		clock    time.Duration
+		delay    time.Duration
+	)
+	for i, outcome := range outcomes {
+		attempt := i + 1
+		clock += delay
+		retry := SimulatedRetry{Attempt: attempt, Start: clock, Delay: delay, StatusCode: outcome.StatusCode}
+		clock += outcome.Latency
+
+		retryable := retryableStatus(outcome.StatusCode)
+		retry.Final = !retryable || attempt >= maxAttempts || attempt == len(outcomes)
+		schedule = append(schedule, retry)
+		if retry.Final {
+			break
+		}
+		delay = NextDelay(attempt, policy)
+		if outcome.RetryAfter > 0 {
+			delay = outcome.RetryAfter
+		}
+	}
+	return schedule
+}
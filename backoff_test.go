@@ -0,0 +1,49 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDelay(t *testing.T) {
+	policy := httpclientutils.BackoffPolicy{Initial: 100 * time.Millisecond, Max: time.Second}
+	var delays []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		delays = append(delays, httpclientutils.NextDelay(attempt, policy))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second,
+	}, delays)
+
+	jittered := httpclientutils.BackoffPolicy{Initial: time.Second, Jitter: 0.5, JitterSeed: 42}
+	first := httpclientutils.NextDelay(3, jittered)
+	assert.Equal(t, first, httpclientutils.NextDelay(3, jittered))
+	assert.GreaterOrEqual(t, first, 2*time.Second)
+	assert.LessOrEqual(t, first, 4*time.Second)
+}
+
+func TestSimulateRetries(t *testing.T) {
+	policy := httpclientutils.BackoffPolicy{Initial: time.Second, MaxAttempts: 4}
+	schedule := httpclientutils.SimulateRetries(policy, []httpclientutils.SimulatedAttempt{
+		{StatusCode: 0, Latency: 100 * time.Millisecond},
+		{StatusCode: http.StatusServiceUnavailable, Latency: 100 * time.Millisecond, RetryAfter: 10 * time.Second},
+		{StatusCode: http.StatusTooManyRequests, Latency: 100 * time.Millisecond},
+		{StatusCode: http.StatusBadGateway},
+		{StatusCode: http.StatusOK},
+	})
+
+	assert.Equal(t, []httpclientutils.SimulatedRetry{
+		{Attempt: 1, Start: 0, Delay: 0, StatusCode: 0},
+		{Attempt: 2, Start: 1100 * time.Millisecond, Delay: time.Second, StatusCode: 503},
+		{Attempt: 3, Start: 11200 * time.Millisecond, Delay: 10 * time.Second, StatusCode: 429},
+		{Attempt: 4, Start: 15300 * time.Millisecond, Delay: 4 * time.Second, StatusCode: 502, Final: true},
+	}, schedule)
+
+	schedule = httpclientutils.SimulateRetries(policy, []httpclientutils.SimulatedAttempt{{StatusCode: 500}, {StatusCode: 404}})
+	assert.Len(t, schedule, 2)
+	assert.True(t, schedule[1].Final)
+}
@@ -0,0 +1,193 @@
+package httpclientutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BatchCombiner merges the bodies of individual requests into one bulk body.
+type BatchCombiner func(bodies [][]byte) ([]byte, error)
+
+// BatchSplitter splits a bulk response body into n per-request bodies, in
+// the order the requests were combined.
+type BatchSplitter func(body []byte, n int) ([][]byte, error)
+
+// BatchConfig declares a batchable endpoint.
+type BatchConfig struct {
+	Endpoint string        // POSTs to this URL are batched
+	BulkURL  string        // URL the merged request is sent to; defaults to Endpoint
+	Window   time.Duration // how long to collect requests before sending
+	MaxSize  int           // sends early once this many requests are pending; zero means no limit
+	Combine  BatchCombiner
+	Split    BatchSplitter
+}
+
+// Batcher merges POSTs to a batchable endpoint that arrive within a window
+// into a single bulk request. Headers, auth and transport settings of the
+// first request in a batch are used for the bulk request. The bulk request
+// is cancelled only once every caller in the batch has given up, and runs
+// under the longest of their timeouts.
+type Batcher struct {
+	config BatchConfig
+
+	mu      sync.Mutex
+	pending *batch
+}
+
+type batch struct {
+	options *RequestOptions
+	bodies  [][]byte
+	results []chan batchResult
+	timer   *time.Timer
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiting int           // callers whose context is still live
+	timeout time.Duration // longest caller timeout; zero when any has none
+}
+
+type batchResult struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	err        error
+}
+
+// NewBatcher returns a Batcher for config.
+func NewBatcher(config BatchConfig) *Batcher {
+	if config.BulkURL == "" {
+		config.BulkURL = config.Endpoint
+	}
+	return &Batcher{config: config}
+}
+
+func (b *Batcher) accepts(options *RequestOptions) bool {
+	return options.Method == http.MethodPost && options.URL == b.config.Endpoint
+}
+
+func (b *Batcher) do(options *RequestOptions) (int, http.Header, []byte, error) {
+	reader, err := prepareBody(options.Body, options.DisableEscapeHTML)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to prepare request body: %w", err)
+	}
+	var body []byte
+	if reader != nil {
+		if body, err = io.ReadAll(reader); err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to prepare request body: %w", err)
+		}
+	}
+
+	result := make(chan batchResult, 1)
+	b.mu.Lock()
+	if b.pending == nil {
+		// The bulk request serves every caller, so it keeps the first
+		// one's context values but not its cancellation.
+		ctx, cancel := context.WithCancel(context.WithoutCancel(options.Context))
+		pending := &batch{options: options, ctx: ctx, cancel: cancel, timeout: options.Timeout}
+		pending.timer = time.AfterFunc(b.config.Window, func() { b.flush(pending) })
+		b.pending = pending
+	}
+	pending := b.pending
+	pending.bodies = append(pending.bodies, body)
+	pending.results = append(pending.results, result)
+	pending.waiting++
+	if options.Timeout == 0 || pending.timeout == 0 {
+		pending.timeout = 0
+	} else {
+		pending.timeout = max(pending.timeout, options.Timeout)
+	}
+	full := b.config.MaxSize > 0 && len(pending.bodies) >= b.config.MaxSize
+	b.mu.Unlock()
+
+	stop := context.AfterFunc(options.Context, func() {
+		b.mu.Lock()
+		pending.waiting--
+		abandoned := pending.waiting == 0
+		b.mu.Unlock()
+		if abandoned {
+			pending.cancel()
+		}
+	})
+	defer stop()
+
+	if full {
+		b.flush(pending)
+	}
+	select {
+	case r := <-result:
+		return r.statusCode, r.header, r.body, r.err
+	case <-options.Context.Done():
+		return 0, nil, nil, options.Context.Err()
+	}
+}
+
+// flush sends pending unless it has already been sent.
+func (b *Batcher) flush(pending *batch) {
+	b.mu.Lock()
+	if b.pending != pending {
+		b.mu.Unlock()
+		return
+	}
+	b.pending = nil
+	pending.timer.Stop()
+	b.mu.Unlock()
+
+	// The batch may be sent from a timer goroutine, where a panic in
+	// Combine or Split would otherwise take the process down.
+	var err error
+	results := func() []batchResult {
+		defer recoverPanic(pending.options.OnPanic, &err)
+		return b.send(pending)
+	}()
+	if err != nil {
+		results = make([]batchResult, len(pending.bodies))
+		for i := range results {
+			results[i].err = err
+		}
+	}
+	for i, result := range results {
+		pending.results[i] <- result
+	}
+	pending.cancel()
+}
+
+func (b *Batcher) send(pending *batch) []batchResult {
+	results := make([]batchResult, len(pending.bodies))
+	fail := func(err error) []batchResult {
+		for i := range results {
+			results[i].err = err
+		}
+		return results
+	}
+
+	combined, err := b.config.Combine(pending.bodies)
+	if err != nil {
+		return fail(fmt.Errorf("failed to combine batch: %w", err))
+	}
+
+	bulk := detachedOptions(pending.options)
+	bulk.Context = pending.ctx
+	bulk.Timeout = pending.timeout
+	bulk.URL = b.config.BulkURL
+	bulk.Body = combined
+	statusCode, header, body, err := send(bulk)
+	if err != nil {
+		return fail(err)
+	}
+
+	parts, err := b.config.Split(body, len(pending.bodies))
+	if err == nil && len(parts) != len(pending.bodies) {
+		err = fmt.Errorf("got %d parts for %d requests", len(parts), len(pending.bodies))
+	}
+	if err != nil {
+		return fail(fmt.Errorf("failed to split batch response: %w", err))
+	}
+	for i := range results {
+		results[i] = batchResult{statusCode: statusCode, header: header, body: parts[i]}
+	}
+	return results
+}
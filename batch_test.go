@@ -0,0 +1,101 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher_MergesRequestsWithinWindow(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		assert.Equal(t, "/bulk", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		w.Write(bytes.ToUpper(body))
+	}))
+	defer ts.Close()
+
+	batcher := httpclientutils.NewBatcher(httpclientutils.BatchConfig{
+		Endpoint: ts.URL + "/items",
+		BulkURL:  ts.URL + "/bulk",
+		Window:   50 * time.Millisecond,
+		Combine:  func(bodies [][]byte) ([]byte, error) { return bytes.Join(bodies, []byte("\n")), nil },
+		Split:    func(body []byte, n int) ([][]byte, error) { return bytes.Split(body, []byte("\n")), nil },
+	})
+
+	inputs := []string{"a", "b", "c"}
+	outputs := make([]string, len(inputs))
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _, body, err := httpclientutils.MakeHTTPRequest(
+				httpclientutils.WithMethod(http.MethodPost),
+				httpclientutils.WithURL(ts.URL+"/items"),
+				httpclientutils.WithBody(input),
+				httpclientutils.WithBatcher(batcher),
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, status)
+			outputs[i] = string(body)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	for i, input := range inputs {
+		assert.Equal(t, string(bytes.ToUpper([]byte(input))), outputs[i])
+	}
+}
+
+func TestBatcher_OutlivesCancelledCaller(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(bytes.ToUpper(body))
+	}))
+	defer ts.Close()
+
+	batcher := httpclientutils.NewBatcher(httpclientutils.BatchConfig{
+		Endpoint: ts.URL,
+		Window:   100 * time.Millisecond,
+		Combine:  func(bodies [][]byte) ([]byte, error) { return bytes.Join(bodies, []byte("\n")), nil },
+		Split:    func(body []byte, n int) ([][]byte, error) { return bytes.Split(body, []byte("\n")), nil },
+	})
+	post := func(ctx context.Context, body string, resp *httpclientutils.Response) error {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithContext(ctx),
+			httpclientutils.WithMethod(http.MethodPost),
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithBody(body),
+			httpclientutils.WithBatcher(batcher),
+			httpclientutils.WithResponse(resp),
+		)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var first, second httpclientutils.Response
+	firstErr := make(chan error, 1)
+	go func() { firstErr <- post(ctx, "a", &first) }()
+	time.Sleep(20 * time.Millisecond)
+	secondErr := make(chan error, 1)
+	go func() { secondErr <- post(context.Background(), "b", &second) }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	assert.NoError(t, <-secondErr)
+	assert.Equal(t, "B", string(second.Body))
+	assert.Nil(t, first.Body)
+}
@@ -0,0 +1,197 @@
+package httpclientutils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BulkResult is the outcome of one item of a 207 Multi-Status or bulk
+// operation response. Err is nil for items with a 2xx status and a
+// *BulkItemError otherwise.
+type BulkResult struct {
+	Index      int
+	ID         string // the href of a WebDAV response, or the item's id
+	StatusCode int
+	Err        error
+	Body       []byte // the item as sent
+}
+
+// BulkItemError is the error of a failed bulk item.
+type BulkItemError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *BulkItemError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("item failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("item failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// ParseBulkResponse splits a multi-status or bulk response into per-item
+// results. XML bodies are read as a WebDAV DAV:multistatus; JSON bodies
+// may be an array of items, an object holding one under "items",
+// "results" or "responses", or an Elasticsearch-style bulk response whose
+// items wrap their result in the action name. Items carry their status as
+// "status", "statusCode" or "status_code", defaulting to the response
+// status, and their message as "message" or "error".
+func ParseBulkResponse(statusCode int, contentType string, body []byte) ([]BulkResult, error) {
+	contentType = strings.Split(contentType, ";")[0]
+	if strings.HasSuffix(contentType, "/xml") || strings.HasSuffix(contentType, "+xml") {
+		return parseMultiStatus(body)
+	}
+	items, err := bulkItems(body)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]BulkResult, len(items))
+	for i, item := range items {
+		results[i] = newBulkResult(i, item, statusCode)
+	}
+	return results, nil
+}
+
+func bulkItems(body []byte) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if json.Unmarshal(body, &items) == nil {
+		return items, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse bulk response: %w", err)
+	}
+	for _, name := range []string{"items", "results", "responses"} {
+		if raw, ok := object[name]; ok {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("failed to parse bulk response: %w", err)
+			}
+			return items, nil
+		}
+	}
+	return nil, errors.New("failed to parse bulk response: no item list")
+}
+
+func newBulkResult(index int, raw json.RawMessage, defaultStatus int) BulkResult {
+	result := BulkResult{Index: index, StatusCode: defaultStatus, Body: raw}
+	var item map[string]json.RawMessage
+	if json.Unmarshal(raw, &item) != nil {
+		return result
+	}
+	if len(item) == 1 {
+		// {"index": {"_id": ..., "status": ...}}
+		for _, inner := range item {
+			var wrapped map[string]json.RawMessage
+			if json.Unmarshal(inner, &wrapped) == nil && wrapped != nil {
+				if _, ok := firstMember(wrapped, "status", "statusCode", "status_code"); ok {
+					item = wrapped
+				}
+			}
+		}
+	}
+	if raw, ok := firstMember(item, "id", "_id"); ok {
+		result.ID = jsonScalar(raw)
+	}
+	if raw, ok := firstMember(item, "status", "statusCode", "status_code"); ok {
+		if status, err := strconv.Atoi(jsonScalar(raw)); err == nil {
+			result.StatusCode = status
+		}
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		itemErr := &BulkItemError{StatusCode: result.StatusCode}
+		if raw, ok := firstMember(item, "message", "error"); ok {
+			itemErr.Message = bulkMessage(raw)
+		}
+		result.Err = itemErr
+	}
+	return result
+}
+
+func firstMember(object map[string]json.RawMessage, names ...string) (json.RawMessage, bool) {
+	for _, name := range names {
+		if raw, ok := object[name]; ok && !isJSONNull(raw) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+// jsonScalar returns a JSON string's value or any other value's literal.
+func jsonScalar(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	return string(raw)
+}
+
+// bulkMessage reads an error member that is a string or an object with a
+// "message" or "reason" member.
+func bulkMessage(raw json.RawMessage) string {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(raw, &object) == nil {
+		if message, ok := firstMember(object, "message", "reason"); ok {
+			return jsonScalar(message)
+		}
+	}
+	return jsonScalar(raw)
+}
+
+type multiStatus struct {
+	Responses []struct {
+		Inner    string   `xml:",innerxml"`
+		Hrefs    []string `xml:"DAV: href"`
+		Status   string   `xml:"DAV: status"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+		Description string `xml:"DAV: responsedescription"`
+	} `xml:"DAV: response"`
+}
+
+// parseMultiStatus reads a WebDAV multistatus body (RFC 4918). A response
+// without a status of its own takes the first failed propstat status.
+func parseMultiStatus(body []byte) ([]BulkResult, error) {
+	var status multiStatus
+	if err := xml.Unmarshal(body, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse multistatus response: %w", err)
+	}
+	results := make([]BulkResult, len(status.Responses))
+	for i, response := range status.Responses {
+		result := BulkResult{Index: i, StatusCode: http.StatusOK, Body: []byte(response.Inner)}
+		if len(response.Hrefs) > 0 {
+			result.ID = strings.TrimSpace(response.Hrefs[0])
+		}
+		if response.Status != "" {
+			result.StatusCode = statusLineCode(response.Status)
+		} else {
+			for _, propstat := range response.Propstat {
+				if code := statusLineCode(propstat.Status); code < 200 || code >= 300 {
+					result.StatusCode = code
+					break
+				}
+			}
+		}
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			result.Err = &BulkItemError{StatusCode: result.StatusCode, Message: strings.TrimSpace(response.Description)}
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// statusLineCode returns the code of a status line such as
+// "HTTP/1.1 404 Not Found", or 0 when it has none.
+func statusLineCode(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return code
+}
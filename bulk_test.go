@@ -0,0 +1,69 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBulkResponse_MultiStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:">
+  <d:response><d:href>/files/a.txt</d:href><d:status>HTTP/1.1 204 No Content</d:status></d:response>
+  <d:response>
+    <d:href>/files/b.txt</d:href>
+    <d:propstat><d:prop><d:getetag/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+    <d:propstat><d:prop><d:owner/></d:prop><d:status>HTTP/1.1 403 Forbidden</d:status></d:propstat>
+    <d:responsedescription>owner is read-only</d:responsedescription>
+  </d:response>
+</d:multistatus>`))
+	}))
+	defer ts.Close()
+
+	statusCode, header, body, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithMethod("PROPPATCH"))
+	assert.NoError(t, err)
+	results, err := httpclientutils.ParseBulkResponse(statusCode, header.Get("Content-Type"), body)
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, "/files/a.txt", results[0].ID)
+	assert.Equal(t, http.StatusNoContent, results[0].StatusCode)
+	assert.NoError(t, results[0].Err)
+
+	var itemErr *httpclientutils.BulkItemError
+	if assert.ErrorAs(t, results[1].Err, &itemErr) {
+		assert.Equal(t, http.StatusForbidden, itemErr.StatusCode)
+		assert.Equal(t, "owner is read-only", itemErr.Message)
+	}
+}
+
+func TestParseBulkResponse_JSON(t *testing.T) {
+	elastic := []byte(`{"took": 3, "errors": true, "items": [
+		{"index": {"_id": "1", "status": 201}},
+		{"index": {"_id": "2", "status": 409, "error": {"type": "version_conflict", "reason": "document exists"}}}
+	]}`)
+	results, err := httpclientutils.ParseBulkResponse(http.StatusOK, "application/json", elastic)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "1", results[0].ID)
+		assert.NoError(t, results[0].Err)
+		assert.EqualError(t, results[1].Err, "item failed with status 409: document exists")
+	}
+
+	upserts := []byte(`[{"id": 7, "statusCode": 200}, {"id": 8, "statusCode": 422, "message": "name is required"}, {"id": 9}]`)
+	results, err = httpclientutils.ParseBulkResponse(http.StatusMultiStatus, "application/json", upserts)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, "7", results[0].ID)
+		assert.EqualError(t, results[1].Err, "item failed with status 422: name is required")
+		assert.Equal(t, http.StatusMultiStatus, results[2].StatusCode)
+		assert.NoError(t, results[2].Err)
+	}
+}
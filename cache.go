@@ -0,0 +1,340 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStore persists serialized cache entries.
+type CacheStore interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// ErrCacheMiss is returned by requests using WithCacheOnly when no cached
+// response is available.
+var ErrCacheMiss = errors.New("cache miss")
+
+// CacheKeyFunc derives the cache key for a request.
+type CacheKeyFunc func(options *RequestOptions) string
+
+// DefaultCacheKey keys entries by method and URL.
+func DefaultCacheKey(options *RequestOptions) string {
+	return options.Method + " " + options.URL
+}
+
+// HeaderCacheKey keys entries by method, URL and the values of the named
+// request headers, for APIs whose responses depend on e.g. Accept-Language
+// or a tenant header without declaring it in Vary.
+func HeaderCacheKey(headers ...string) CacheKeyFunc {
+	return func(options *RequestOptions) string {
+		key := DefaultCacheKey(options)
+		for _, name := range headers {
+			key += "\n" + http.CanonicalHeaderKey(name) + ": " + requestHeader(options, name)
+		}
+		return key
+	}
+}
+
+// Cache serves GET and HEAD responses from a CacheStore, honoring max-age,
+// stale-while-revalidate and stale-if-error (RFC 5861). Responses with a
+// Vary header are only served to requests with matching header values.
+type Cache struct {
+	// Key derives cache keys; it defaults to DefaultCacheKey.
+	Key CacheKeyFunc
+
+	store CacheStore
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+// NewCache returns a Cache backed by store.
+func NewCache(store CacheStore) *Cache {
+	return &Cache{store: store, refreshing: make(map[string]bool)}
+}
+
+// cacheEntry is the serialized form of a cached response.
+type cacheEntry struct {
+	StatusCode           int           `json:"status_code"`
+	Header               http.Header   `json:"header"`
+	Body                 []byte        `json:"body"`
+	StoredAt             time.Time     `json:"stored_at"`
+	MaxAge               time.Duration `json:"max_age"`
+	StaleWhileRevalidate time.Duration `json:"stale_while_revalidate"`
+	StaleIfError         time.Duration `json:"stale_if_error"`
+	Vary                 http.Header   `json:"vary,omitempty"`
+}
+
+func (e *cacheEntry) age() time.Duration { return time.Since(e.StoredAt) }
+
+func (e *cacheEntry) fresh() bool { return e.age() < e.MaxAge }
+
+func (e *cacheEntry) revalidatable() bool { return e.age() < e.MaxAge+e.StaleWhileRevalidate }
+
+func (e *cacheEntry) usableOnError() bool { return e.age() < e.MaxAge+e.StaleIfError }
+
+// matches reports whether the request carries the header values the entry
+// was stored for.
+func (e *cacheEntry) matches(options *RequestOptions) bool {
+	for name, values := range e.Vary {
+		if requestHeader(options, name) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cache) do(options *RequestOptions) (int, http.Header, []byte, error) {
+	if options.Method != http.MethodGet && options.Method != http.MethodHead {
+		return send(options)
+	}
+
+	key := c.key(options)
+	if options.CacheBypass {
+		statusCode, header, body, err := c.fetch(key, options)
+		options.Stats.cacheLookup(false)
+		return statusCode, header, body, err
+	}
+
+	entry := c.load(key)
+	if entry != nil && !entry.matches(options) {
+		entry = nil
+	}
+	if options.CacheOnly || Offline() {
+		if entry == nil {
+			options.Stats.cacheLookup(false)
+			if Offline() {
+				return 0, nil, nil, ErrOffline
+			}
+			return 0, nil, nil, ErrCacheMiss
+		}
+		c.hit(options, !entry.fresh())
+		return entry.StatusCode, entry.Header, entry.Body, nil
+	}
+	if entry != nil {
+		if entry.fresh() {
+			c.hit(options, false)
+			return entry.StatusCode, entry.Header, entry.Body, nil
+		}
+		if entry.revalidatable() {
+			c.hit(options, true)
+			c.revalidate(key, options)
+			return entry.StatusCode, entry.Header, entry.Body, nil
+		}
+	}
+
+	statusCode, header, body, err := c.fetch(key, options)
+	if entry != nil && entry.usableOnError() && (err != nil || statusCode >= http.StatusInternalServerError) {
+		c.hit(options, true)
+		return entry.StatusCode, entry.Header, entry.Body, nil
+	}
+	options.Stats.cacheLookup(false)
+	return statusCode, header, body, err
+}
+
+func (c *Cache) key(options *RequestOptions) string {
+	if c.Key != nil {
+		return c.Key(options)
+	}
+	return DefaultCacheKey(options)
+}
+
+func (c *Cache) hit(options *RequestOptions, stale bool) {
+	options.Stats.cacheLookup(true)
+	options.EventBus.publish(CacheHit{Method: options.Method, URL: scrubText(options, options.URL), Meta: options.Meta, Stale: stale})
+}
+
+func (c *Cache) load(key string) *cacheEntry {
+	data, ok, err := c.store.Get(key)
+	if err != nil || !ok {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// fetch sends the request and stores the response when it is cacheable.
+func (c *Cache) fetch(key string, options *RequestOptions) (int, http.Header, []byte, error) {
+	statusCode, header, body, err := send(options)
+	if err != nil || statusCode != http.StatusOK {
+		return statusCode, header, body, err
+	}
+
+	entry, ok := newCacheEntry(statusCode, header, body, options)
+	if !ok {
+		return statusCode, header, body, nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return statusCode, header, body, fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	ttl := entry.MaxAge + max(entry.StaleWhileRevalidate, entry.StaleIfError)
+	if err := c.store.Set(key, data, ttl); err != nil {
+		return statusCode, header, body, fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return statusCode, header, body, nil
+}
+
+// revalidate refreshes key in the background, at most once at a time.
+func (c *Cache) revalidate(key string, options *RequestOptions) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	// The refresh outlives the request that triggered it, so it must not
+	// write to the caller's outputs.
+	refresh := detachedOptions(options)
+	refresh.Context = context.WithoutCancel(options.Context)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		defer recoverPanic(refresh.OnPanic, nil)
+		c.fetch(key, refresh)
+	}()
+}
+
+// newCacheEntry builds an entry from the response directives, with any
+// explicit stale windows on options taking precedence.
+func newCacheEntry(statusCode int, header http.Header, body []byte, options *RequestOptions) (*cacheEntry, bool) {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return nil, false
+	}
+
+	entry := &cacheEntry{
+		StatusCode:           statusCode,
+		Header:               header,
+		Body:                 body,
+		StoredAt:             time.Now(),
+		MaxAge:               directiveSeconds(directives, "max-age"),
+		StaleWhileRevalidate: directiveSeconds(directives, "stale-while-revalidate"),
+		StaleIfError:         directiveSeconds(directives, "stale-if-error"),
+	}
+	if _, ok := directives["max-age"]; !ok {
+		if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+			entry.MaxAge = max(time.Until(expires), 0)
+		}
+	}
+	for _, name := range strings.Split(header.Get("Vary"), ",") {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "*":
+			return nil, false
+		}
+		if entry.Vary == nil {
+			entry.Vary = make(http.Header)
+		}
+		entry.Vary.Set(name, requestHeader(options, name))
+	}
+	if options.StaleWhileRevalidate > 0 {
+		entry.StaleWhileRevalidate = options.StaleWhileRevalidate
+	}
+	if options.StaleIfError > 0 {
+		entry.StaleIfError = options.StaleIfError
+	}
+
+	if entry.MaxAge+max(entry.StaleWhileRevalidate, entry.StaleIfError) <= 0 {
+		return nil, false
+	}
+	return entry, true
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+	}
+	return directives
+}
+
+// requestHeader returns the value of the named request header, matching
+// names case-insensitively since Headers is a plain map.
+func requestHeader(options *RequestOptions, name string) string {
+	for key, value := range options.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+func directiveSeconds(directives map[string]string, name string) time.Duration {
+	seconds, err := strconv.Atoi(directives[name])
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// MemoryStore is an in-process CacheStore.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,180 @@
+package httpclientutils_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_ServesFreshEntry(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("cached"))
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	for i := 0; i < 3; i++ {
+		status, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithCache(cache),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "cached", string(body))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		if n == 1 {
+			w.Write([]byte("first"))
+			return
+		}
+		w.Write([]byte("second"))
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	_, _, body, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(body))
+
+	_, _, body, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(body))
+
+	assert.Eventually(t, func() bool {
+		_, _, body, _ := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+		return string(body) == "second"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCache_RevalidationLeavesCallerOutputsAlone(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		fmt.Fprintf(w, "v%d", n)
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+	assert.NoError(t, err)
+
+	var resp httpclientutils.Response
+	var raw *http.Response
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithCache(cache),
+		httpclientutils.WithResponse(&resp),
+		httpclientutils.WithRawResponse(&raw),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(body))
+
+	// Under -race, any write by the background refresh races these reads.
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&hits) == 2 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, "v1", string(resp.Body))
+	assert.Nil(t, resp.Conn)
+	assert.Nil(t, raw)
+}
+
+func TestCache_StaleIfErrorOverride(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) > 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Write([]byte("good"))
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	for i := 0; i < 2; i++ {
+		status, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithCache(cache),
+			httpclientutils.WithStaleIfError(time.Minute),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "good", string(body))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestCache_BypassAndCacheOnly(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+"/other"), httpclientutils.WithCache(cache), httpclientutils.WithCacheOnly())
+	assert.ErrorIs(t, err, httpclientutils.ErrCacheMiss)
+
+	_, _, body, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+	assert.NoError(t, err)
+	assert.Equal(t, "response 1", string(body))
+
+	_, _, body, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache), httpclientutils.WithCacheBypass())
+	assert.NoError(t, err)
+	assert.Equal(t, "response 2", string(body))
+
+	_, _, body, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache), httpclientutils.WithCacheOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, "response 2", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestCache_KeysByVaryAndSelectedHeaders(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprintf(w, "%s/%s", r.Header.Get("Accept-Language"), r.Header.Get("X-Tenant"))
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	cache.Key = httpclientutils.HeaderCacheKey("X-Tenant")
+	get := func(language, tenant string) string {
+		_, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithCache(cache),
+			httpclientutils.WithHeaders(map[string]string{"Accept-Language": language, "x-tenant": tenant}),
+		)
+		assert.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "en/a", get("en", "a"))
+	assert.Equal(t, "en/b", get("en", "b"))
+	assert.Equal(t, "en/a", get("en", "a"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// A different Vary value replaces the entry rather than being served it.
+	assert.Equal(t, "de/a", get("de", "a"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,99 @@
+package httpclientutils
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CertExpiry describes a server certificate nearing expiry.
+type CertExpiry struct {
+	Host      string
+	Subject   string
+	NotAfter  time.Time
+	Remaining time.Duration
+}
+
+// CertExpiring is published when a CertExpiryMonitor finds a server
+// certificate that expires within its window.
+type CertExpiring struct {
+	CertExpiry
+	Meta Meta
+}
+
+func (CertExpiring) isEvent() {}
+
+// CertExpiryMonitor inspects the certificate chain of every new TLS
+// connection and reports certificates expiring within Window, through
+// OnExpiring and as a CertExpiring event on the request's EventBus.
+type CertExpiryMonitor struct {
+	// Window is how far ahead expiry is reported; it defaults to 30 days.
+	Window time.Duration
+	// Every limits reports to one per host per interval; zero reports on
+	// every new connection.
+	Every      time.Duration
+	OnExpiring func(CertExpiry)
+
+	mu       sync.Mutex
+	reported map[string]time.Time
+}
+
+const defaultCertExpiryWindow = 30 * 24 * time.Hour
+
+func (m *CertExpiryMonitor) trace(options *RequestOptions) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err == nil {
+				m.check(state, options)
+			}
+		},
+	}
+}
+
+func (m *CertExpiryMonitor) check(state tls.ConnectionState, options *RequestOptions) {
+	window := m.Window
+	if window <= 0 {
+		window = defaultCertExpiryWindow
+	}
+	now := time.Now()
+	info := newTLSInfo(&state)
+	expiry := info.Expiry()
+	if expiry.IsZero() || expiry.Sub(now) > window {
+		return
+	}
+
+	host := state.ServerName
+	if host == "" {
+		// No SNI is sent to IP literals.
+		if u, err := url.Parse(options.URL); err == nil {
+			host = u.Hostname()
+		}
+	}
+	if m.Every > 0 {
+		m.mu.Lock()
+		if last, ok := m.reported[host]; ok && now.Sub(last) < m.Every {
+			m.mu.Unlock()
+			return
+		}
+		if m.reported == nil {
+			m.reported = make(map[string]time.Time)
+		}
+		m.reported[host] = now
+		m.mu.Unlock()
+	}
+
+	cert := state.PeerCertificates[0]
+	for _, c := range state.PeerCertificates {
+		if c.NotAfter.Equal(expiry) {
+			cert = c
+			break
+		}
+	}
+	report := CertExpiry{Host: host, Subject: cert.Subject.String(), NotAfter: expiry, Remaining: expiry.Sub(now)}
+	if m.OnExpiring != nil {
+		m.OnExpiring(report)
+	}
+	options.EventBus.publish(CertExpiring{CertExpiry: report, Meta: options.Meta})
+}
@@ -0,0 +1,58 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertExpiryMonitor(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+
+	var reports []httpclientutils.CertExpiry
+	monitor := &httpclientutils.CertExpiryMonitor{
+		Window:     100 * 365 * 24 * time.Hour,
+		Every:      time.Hour,
+		OnExpiring: func(expiry httpclientutils.CertExpiry) { reports = append(reports, expiry) },
+	}
+	bus := httpclientutils.NewEventBus()
+	events, unsubscribe := bus.Subscribe(10)
+	defer unsubscribe()
+
+	for range 2 {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithTLSConfig(tlsConfig),
+			httpclientutils.WithCertExpiryMonitor(monitor),
+			httpclientutils.WithEventBus(bus),
+		)
+		assert.NoError(t, err)
+	}
+
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, "127.0.0.1", reports[0].Host)
+		assert.Equal(t, ts.Certificate().NotAfter, reports[0].NotAfter)
+		assert.Positive(t, reports[0].Remaining)
+	}
+	var expiring []httpclientutils.CertExpiring
+	for len(events) > 0 {
+		if e, ok := (<-events).(httpclientutils.CertExpiring); ok {
+			expiring = append(expiring, e)
+		}
+	}
+	assert.Len(t, expiring, 1)
+
+	quiet := &httpclientutils.CertExpiryMonitor{OnExpiring: func(httpclientutils.CertExpiry) { t.Error("reported a certificate outside the window") }}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(tlsConfig),
+		httpclientutils.WithCertExpiryMonitor(quiet),
+	)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,186 @@
+package httpclientutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const defaultChunkSize = 8 << 20
+
+// Chunk is one piece of a ChunkedUpload.
+type Chunk struct {
+	Index  int
+	Offset int64
+	Data   []byte
+	Last   bool
+}
+
+// PermanentError marks an error returned by ChunkedUpload.Upload that
+// retrying cannot fix, e.g. a 4xx response.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// ChunkError reports the chunk a ChunkedUpload failed at.
+type ChunkError struct {
+	Index    int
+	Offset   int64
+	Attempts int
+	Err      error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk %d at offset %d failed after %d attempts: %v", e.Index, e.Offset, e.Attempts, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error { return e.Err }
+
+// ChunkedUpload splits a reader into chunks and uploads them one by one,
+// or Parallelism at a time, retrying each chunk on its own so a transient
+// failure costs one chunk rather than the whole transfer. Upload sends a
+// chunk with whatever protocol the server speaks; Finalize, if set, runs
+// once every chunk has been uploaded.
+type ChunkedUpload struct {
+	ChunkSize      int64 // defaults to 8 MiB
+	Parallelism    int   // chunks in flight at once; defaults to 1
+	MaxAttempts    int   // per chunk; defaults to 3
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	Upload   func(ctx context.Context, chunk Chunk) error
+	Finalize func(ctx context.Context, size int64) error
+}
+
+// Run uploads everything read from r and returns the number of bytes
+// uploaded. An empty reader is uploaded as a single empty last chunk.
+func (u *ChunkedUpload) Run(ctx context.Context, r io.Reader) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, max(u.Parallelism, 1))
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	// Each chunk is dispatched only after the next one has been read, so
+	// the last chunk is known when it is sent.
+	var size int64
+	current, eof, err := u.readChunk(r, 0, 0)
+	for err == nil {
+		var next Chunk
+		var nextEOF bool
+		if eof {
+			current.Last = true
+		} else if next, nextEOF, err = u.readChunk(r, current.Index+1, current.Offset+int64(len(current.Data))); err != nil {
+			break
+		} else if nextEOF && len(next.Data) == 0 {
+			current.Last = true
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		size += int64(len(current.Data))
+		wg.Add(1)
+		go func(chunk Chunk) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := u.uploadChunk(ctx, chunk); err != nil {
+				fail(err)
+			}
+		}(current)
+		if current.Last {
+			break
+		}
+		current, eof = next, nextEOF
+	}
+	wg.Wait()
+
+	if err != nil {
+		fail(fmt.Errorf("failed to read upload: %w", err))
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if u.Finalize != nil {
+		if err := u.Finalize(ctx, size); err != nil {
+			return size, fmt.Errorf("failed to finalize upload: %w", err)
+		}
+	}
+	return size, nil
+}
+
+// readChunk reads the chunk at offset, reporting whether the reader is
+// exhausted.
+func (u *ChunkedUpload) readChunk(r io.Reader, index int, offset int64) (Chunk, bool, error) {
+	size := u.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	data := make([]byte, size)
+	n, err := io.ReadFull(r, data)
+	chunk := Chunk{Index: index, Offset: offset, Data: data[:n]}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return chunk, true, nil
+	}
+	return chunk, false, err
+}
+
+func (u *ChunkedUpload) uploadChunk(ctx context.Context, chunk Chunk) error {
+	attempts := u.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	initial := u.InitialBackoff
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = u.upload(ctx, chunk); err == nil {
+			return nil
+		}
+		var permanent *PermanentError
+		var panicked *PanicError
+		if errors.As(err, &permanent) || errors.As(err, &panicked) || ctx.Err() != nil || attempt == attempts {
+			return &ChunkError{Index: chunk.Index, Offset: chunk.Offset, Attempts: attempt, Err: err}
+		}
+		select {
+		case <-time.After(exponentialBackoff(initial, u.MaxBackoff, attempt)):
+		case <-ctx.Done():
+			return &ChunkError{Index: chunk.Index, Offset: chunk.Offset, Attempts: attempt, Err: ctx.Err()}
+		}
+	}
+	return err
+}
+
+// upload calls Upload on a worker goroutine, converting panics into errors.
+func (u *ChunkedUpload) upload(ctx context.Context, chunk Chunk) (err error) {
+	defer recoverPanic(nil, &err)
+	return u.Upload(ctx, chunk)
+}
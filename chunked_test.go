@@ -0,0 +1,116 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkedUpload_RetriesFailedChunk(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		assembled = make([]byte, 10)
+		failures  int32
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset == 4 && atomic.AddInt32(&failures, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		copy(assembled[offset:], body)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var finalized int64
+	var lastIndex int
+	upload := &httpclientutils.ChunkedUpload{
+		ChunkSize:      4,
+		Parallelism:    2,
+		InitialBackoff: time.Millisecond,
+		Upload: func(ctx context.Context, chunk httpclientutils.Chunk) error {
+			if chunk.Last {
+				lastIndex = chunk.Index
+			}
+			status, _, _, err := httpclientutils.MakeHTTPRequest(
+				httpclientutils.WithContext(ctx),
+				httpclientutils.WithMethod(http.MethodPut),
+				httpclientutils.WithURL(fmt.Sprintf("%s?offset=%d", ts.URL, chunk.Offset)),
+				httpclientutils.WithBody(chunk.Data),
+			)
+			if err == nil && status != http.StatusOK {
+				err = fmt.Errorf("status %d", status)
+			}
+			return err
+		},
+		Finalize: func(ctx context.Context, size int64) error {
+			finalized = size
+			return nil
+		},
+	}
+	size, err := upload.Run(context.Background(), bytes.NewReader([]byte("0123456789")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+	assert.Equal(t, int64(10), finalized)
+	assert.Equal(t, 2, lastIndex)
+	assert.Equal(t, "0123456789", string(assembled))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&failures))
+}
+
+func TestChunkedUpload_ExactMultipleMarksLastChunk(t *testing.T) {
+	var chunks []httpclientutils.Chunk
+	upload := &httpclientutils.ChunkedUpload{
+		ChunkSize: 4,
+		Upload: func(ctx context.Context, chunk httpclientutils.Chunk) error {
+			chunks = append(chunks, chunk)
+			return nil
+		},
+	}
+	_, err := upload.Run(context.Background(), bytes.NewReader([]byte("01234567")))
+	assert.NoError(t, err)
+	if assert.Len(t, chunks, 2) {
+		assert.False(t, chunks[0].Last)
+		assert.True(t, chunks[1].Last)
+		assert.Equal(t, int64(4), chunks[1].Offset)
+	}
+}
+
+func TestChunkedUpload_PermanentErrorStops(t *testing.T) {
+	var attempts int32
+	rejected := errors.New("rejected")
+	upload := &httpclientutils.ChunkedUpload{
+		ChunkSize: 2,
+		Upload: func(ctx context.Context, chunk httpclientutils.Chunk) error {
+			atomic.AddInt32(&attempts, 1)
+			return &httpclientutils.PermanentError{Err: rejected}
+		},
+		Finalize: func(ctx context.Context, size int64) error {
+			t.Error("finalize called after a failed chunk")
+			return nil
+		},
+	}
+	_, err := upload.Run(context.Background(), bytes.NewReader([]byte("abcdef")))
+
+	var chunkErr *httpclientutils.ChunkError
+	if assert.ErrorAs(t, err, &chunkErr) {
+		assert.Equal(t, 0, chunkErr.Index)
+		assert.Equal(t, 1, chunkErr.Attempts)
+	}
+	assert.ErrorIs(t, err, rejected)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
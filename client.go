@@ -0,0 +1,199 @@
+package httpclientutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultClientTimeout bounds requests sent through a Client unless
+// WithTimeout overrides it.
+const defaultClientTimeout = 30 * time.Second
+
+// ErrClientClosed is returned for requests sent through a Client after
+// Shutdown or Close, and is the cancellation cause of requests they cut
+// off.
+var ErrClientClosed = errors.New("client closed")
+
+// Client applies a fixed set of options to every request and, unlike bare
+// MakeHTTPRequest calls, keeps connections pooled across requests.
+type Client struct {
+	options []Option
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+}
+
+// NewClient returns a Client applying opts to every request, after a 30s
+// timeout and a connection pool of its own that opts may replace.
+func NewClient(opts ...Option) *Client {
+	defaults := []Option{WithTimeout(defaultClientTimeout), WithTenantPartitions(NewTenantPartitions(0, 0))}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{options: append(defaults, opts...), ctx: ctx, cancel: cancel}
+}
+
+// Do sends a request built from the client options followed by opts.
+func (c *Client) Do(opts ...Option) (int, http.Header, []byte, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, nil, nil, ErrClientClosed
+	}
+	c.inFlight.Add(1)
+	c.mu.Unlock()
+	defer c.inFlight.Done()
+
+	// Bound last, so the request context set by any option is the parent.
+	stop := func() {}
+	bind := func(options *RequestOptions) {
+		ctx, cancel := context.WithCancelCause(options.Context)
+		unregister := context.AfterFunc(c.ctx, func() { cancel(ErrClientClosed) })
+		options.Context = ctx
+		stop = func() {
+			unregister()
+			cancel(nil)
+		}
+	}
+	defer func() { stop() }()
+	return MakeHTTPRequest(append(append(append([]Option{}, c.options...), opts...), bind)...)
+}
+
+// Context returns a context that is cancelled once the client shuts down,
+// for tying background work such as LongPoll, DoEvery or Outbox.Run to the
+// client's lifetime with WithContext.
+func (c *Client) Context() context.Context { return c.ctx }
+
+// Shutdown stops the client accepting requests and waits for in-flight
+// ones to finish until ctx is done, cancelling any still running after
+// that. It then cancels Context and closes idle pooled connections. It is
+// safe to call more than once; it returns ctx's error if the grace period
+// ran out.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(drained)
+	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	c.cancel()
+	<-drained
+	if partitions := newRequestOptions(c.options).TenantPartitions; partitions != nil {
+		partitions.CloseIdleConnections()
+	}
+	return err
+}
+
+// Close cancels in-flight requests and releases the client's resources
+// without a grace period; see Shutdown.
+func (c *Client) Close() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Shutdown(ctx)
+	return nil
+}
+
+// Get sends a GET request to url.
+func (c *Client) Get(url string, opts ...Option) (int, http.Header, []byte, error) {
+	return c.Do(append([]Option{WithURL(url), WithMethod(http.MethodGet)}, opts...)...)
+}
+
+// Post sends a POST request to url with body, encoded as WithBody does.
+func (c *Client) Post(url string, body interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	return c.Do(append([]Option{WithURL(url), WithMethod(http.MethodPost), WithBody(body)}, opts...)...)
+}
+
+// PutJSON sends a PUT request to url with body encoded as JSON, with a
+// Content-Type of application/json.
+func (c *Client) PutJSON(url string, body interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	return c.Do(append([]Option{WithURL(url), WithMethod(http.MethodPut), WithBody(JSONBody(body))}, opts...)...)
+}
+
+// Warmup resolves, connects to and completes the TLS handshake with each
+// host ahead of the first real request, leaving the connections in the
+// client's pool. hosts are URLs or host[:port] names, the latter reached
+// over HTTPS. Each host receives a HEAD request for its root, sent through
+// Do so host policies, SSRF protection, robots rules and offline mode
+// apply; statuses are ignored and only failures to get a response are
+// returned.
+func (c *Client) Warmup(ctx context.Context, hosts ...string) error {
+	if !pooledTransport(newRequestOptions(c.options)) {
+		// Nothing would outlive the warmup.
+		return nil
+	}
+
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.warmup(ctx, host)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *Client) warmup(ctx context.Context, host string) error {
+	target := host
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("failed to warm up %s: %w", host, err)
+	}
+	u.Path, u.RawPath, u.RawQuery = "/", "", ""
+	statusCode, _, _, err := c.Do(WithContext(ctx), WithMethod(http.MethodHead), WithURL(u.String()))
+	if err != nil && statusCode == 0 {
+		return fmt.Errorf("failed to warm up %s: %w", host, err)
+	}
+	return nil
+}
+
+var defaultClient atomic.Pointer[Client]
+
+func init() { defaultClient.Store(NewClient()) }
+
+// SetDefault replaces the client used by the package-level Get, Post and
+// PutJSON.
+func SetDefault(client *Client) { defaultClient.Store(client) }
+
+// Default returns the client used by the package-level Get, Post and
+// PutJSON.
+func Default() *Client { return defaultClient.Load() }
+
+// Get sends a GET request to url through the default client.
+func Get(url string, opts ...Option) (int, http.Header, []byte, error) {
+	return Default().Get(url, opts...)
+}
+
+// Post sends a POST request to url with body through the default client.
+func Post(url string, body interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	return Default().Post(url, body, opts...)
+}
+
+// PutJSON sends a PUT request to url with a JSON body through the default
+// client.
+func PutJSON(url string, body interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	return Default().PutJSON(url, body, opts...)
+}
@@ -0,0 +1,158 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_PoolsConnections(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Client")))
+	}))
+	defer ts.Close()
+
+	client := httpclientutils.NewClient(httpclientutils.WithHeaders(map[string]string{"X-Client": "svc"}))
+	var first, second httpclientutils.Response
+	_, _, body, err := client.Get(ts.URL, httpclientutils.WithResponse(&first))
+	assert.NoError(t, err)
+	assert.Equal(t, "svc", string(body))
+	_, _, _, err = client.Get(ts.URL, httpclientutils.WithResponse(&second))
+	assert.NoError(t, err)
+	if assert.NotNil(t, second.Conn) {
+		assert.True(t, second.Conn.Reused)
+	}
+}
+
+func TestClient_PoolsConnectionsWithSSRFProtection(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	// The option builds a new policy on every request; equal policies must
+	// still share one pooled transport.
+	client := httpclientutils.NewClient(httpclientutils.WithSSRFProtection(netip.MustParsePrefix("127.0.0.0/8")))
+	for i := range 5 {
+		var resp httpclientutils.Response
+		_, _, _, err := client.Get(ts.URL, httpclientutils.WithResponse(&resp))
+		assert.NoError(t, err)
+		if i > 0 && assert.NotNil(t, resp.Conn) {
+			assert.True(t, resp.Conn.Reused, "request %d", i)
+		}
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.Header.Get("Content-Type") + " " + r.Header.Get("X-Default") + " " + string(body)))
+	}))
+	defer ts.Close()
+
+	previous := httpclientutils.Default()
+	defer httpclientutils.SetDefault(previous)
+	httpclientutils.SetDefault(httpclientutils.NewClient(httpclientutils.WithHeaders(map[string]string{"X-Default": "yes"})))
+
+	_, _, body, err := httpclientutils.PutJSON(ts.URL, map[string]int{"n": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, `PUT application/json yes {"n":1}`, string(body))
+
+	_, _, body, err = httpclientutils.Post(ts.URL, "raw")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST  yes raw", string(body))
+
+	_, _, body, err = httpclientutils.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "GET  yes ", string(body))
+}
+
+func TestClient_Warmup(t *testing.T) {
+	var methods []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+	}))
+	defer ts.Close()
+
+	client := httpclientutils.NewClient(httpclientutils.WithTLSConfig(ts.Client().Transport.(*http.Transport).TLSClientConfig))
+	assert.NoError(t, client.Warmup(context.Background(), strings.TrimPrefix(ts.URL, "https://")))
+
+	var resp httpclientutils.Response
+	_, _, _, err := client.Get(ts.URL, httpclientutils.WithResponse(&resp))
+	assert.NoError(t, err)
+	if assert.NotNil(t, resp.Conn) {
+		assert.True(t, resp.Conn.Reused)
+	}
+	assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+
+	err = client.Warmup(context.Background(), "127.0.0.1:1")
+	assert.ErrorContains(t, err, "failed to warm up 127.0.0.1:1")
+}
+
+func TestClient_WarmupAppliesRequestPolicies(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer ts.Close()
+
+	denied := httpclientutils.NewClient(httpclientutils.WithDeniedHosts("127.0.0.1"))
+	assert.ErrorIs(t, denied.Warmup(context.Background(), ts.URL), httpclientutils.ErrHostNotAllowed)
+
+	protected := httpclientutils.NewClient(httpclientutils.WithSSRFProtection())
+	assert.ErrorIs(t, protected.Warmup(context.Background(), ts.URL), httpclientutils.ErrSSRFBlocked)
+	assert.Equal(t, 0, hits)
+
+	// A response of any status counts as warmed up.
+	assert.NoError(t, httpclientutils.NewClient().Warmup(context.Background(), ts.URL+"/ignored?q=1"))
+	assert.Equal(t, 1, hits)
+}
+
+func TestClient_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		<-release
+	}))
+	defer ts.Close()
+
+	drained := httpclientutils.NewClient()
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := drained.Get(ts.URL)
+		done <- err
+	}()
+	<-entered
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, drained.Shutdown(ctx))
+	assert.NoError(t, <-done)
+	assert.Error(t, drained.Context().Err())
+	_, _, _, err := drained.Get(ts.URL)
+	assert.ErrorIs(t, err, httpclientutils.ErrClientClosed)
+
+	cut := httpclientutils.NewClient()
+	go func() {
+		_, _, _, err := cut.Get(ts.URL + "/slow")
+		done <- err
+	}()
+	<-entered
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cut.Shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, <-done, httpclientutils.ErrClientClosed)
+	assert.NoError(t, cut.Close())
+}
@@ -0,0 +1,62 @@
+package httpclientutils
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// NewRequestOptions applies opts once, so a request shared by many calls
+// can be derived with With or sent with Do without re-running every option.
+func NewRequestOptions(opts ...Option) *RequestOptions {
+	return newRequestOptions(opts)
+}
+
+// Clone returns a copy of o that can be modified without affecting o.
+// Headers and Meta are copied; pointers such as Response, Cache or
+// Scheduler are shared.
+func (o *RequestOptions) Clone() *RequestOptions {
+	clone := *o
+	clone.Headers = maps.Clone(o.Headers)
+	clone.Meta = maps.Clone(o.Meta)
+	// Clipped so appending options reallocate instead of writing into o.
+	clone.AllowedHosts = slices.Clip(o.AllowedHosts)
+	clone.DeniedHosts = slices.Clip(o.DeniedHosts)
+	clone.ResolveWriterTypes = slices.Clip(o.ResolveWriterTypes)
+	clone.RequestTrailers = slices.Clip(o.RequestTrailers)
+	return &clone
+}
+
+// With returns a clone of o with opts applied on top.
+func (o *RequestOptions) With(opts ...Option) *RequestOptions {
+	clone := o.Clone()
+	for _, opt := range opts {
+		opt(clone)
+	}
+	return clone
+}
+
+// Do sends the request described by o with opts applied on top, leaving o
+// unchanged for the next call.
+func (o *RequestOptions) Do(opts ...Option) (int, http.Header, []byte, error) {
+	return makeHTTPRequest(o.With(opts...))
+}
+
+// WithPath resolves path against the URL set so far, e.g. "users/42" or
+// "/v2/users", for requests derived from a shared base.
+func WithPath(path string) Option {
+	return func(opts *RequestOptions) {
+		base, err := url.Parse(opts.URL)
+		if err != nil {
+			opts.URL = path
+			return
+		}
+		ref, err := base.Parse(path)
+		if err != nil {
+			opts.URL = path
+			return
+		}
+		opts.URL = ref.String()
+	}
+}
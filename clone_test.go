@@ -0,0 +1,54 @@
+package httpclientutils_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestOptions_Do(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Api-Key") + " " + string(body)))
+	}))
+	defer ts.Close()
+
+	base := httpclientutils.NewRequestOptions(
+		httpclientutils.WithURL(ts.URL+"/api/"),
+		httpclientutils.WithHeaders(map[string]string{"X-Api-Key": "k"}),
+		httpclientutils.WithMeta("service", "users"),
+	)
+
+	_, _, body, err := base.Do(httpclientutils.WithPath("users/1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "GET /api/users/1 k ", string(body))
+
+	_, _, body, err = base.Do(
+		httpclientutils.WithPath("users"),
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithBody("ada"),
+		httpclientutils.WithMeta("op", "create"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST /api/users k ada", string(body))
+
+	assert.Equal(t, ts.URL+"/api/", base.URL)
+	assert.Equal(t, http.MethodGet, base.Method)
+	assert.Equal(t, httpclientutils.Meta{"service": "users"}, base.Meta)
+}
+
+func TestRequestOptions_Clone(t *testing.T) {
+	base := httpclientutils.NewRequestOptions(httpclientutils.WithAllowedHosts("a.example"))
+	clone := base.With(httpclientutils.WithAllowedHosts("b.example"), httpclientutils.WithPath("http://c.example/x"))
+	clone.Headers["X-Only-Clone"] = "1"
+
+	assert.Equal(t, []string{"a.example"}, base.AllowedHosts)
+	assert.Equal(t, []string{"a.example", "b.example"}, clone.AllowedHosts)
+	assert.Empty(t, base.Headers)
+	assert.Equal(t, "", base.URL)
+	assert.Equal(t, "http://c.example/x", clone.URL)
+}
@@ -0,0 +1,82 @@
+package httpclientutils
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+)
+
+// CollectionStep is one request of a Collection. Extract maps variable
+// names to JSON paths in the response body; the extracted values are
+// available to the templates of later steps.
+type CollectionStep struct {
+	Name         string            `json:"name"`
+	Request      RequestTemplate   `json:"request"`
+	Extract      map[string]string `json:"extract,omitempty"`
+	ExpectStatus int               `json:"expect_status,omitempty"` // defaults to any 2xx status
+}
+
+// Collection is an ordered list of templated requests, for smoke tests and
+// provisioning workflows.
+type Collection struct {
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Steps     []CollectionStep       `json:"steps"`
+}
+
+// CollectionError reports the step a Collection run stopped at.
+type CollectionError struct {
+	Step       string
+	StatusCode int
+	Err        error
+}
+
+func (e *CollectionError) Error() string {
+	return fmt.Sprintf("collection step %s: %v", e.Step, e.Err)
+}
+
+func (e *CollectionError) Unwrap() error { return e.Err }
+
+// Run executes the steps in order and returns the variables after the last
+// one. It stops at the first step that fails, returns an unexpected status
+// or whose extraction does not match. Variables passed to Run override the
+// collection's own; opts are applied to every request.
+func (c Collection) Run(ctx context.Context, vars map[string]interface{}, opts ...Option) (map[string]interface{}, error) {
+	state := make(map[string]interface{}, len(c.Variables)+len(vars))
+	maps.Copy(state, c.Variables)
+	maps.Copy(state, vars)
+
+	for i, step := range c.Steps {
+		name := orDefault(step.Name, fmt.Sprintf("#%d", i+1))
+		statusCode, _, body, err := step.Request.Do(state, append([]Option{WithContext(ctx)}, opts...)...)
+		if err != nil {
+			return state, &CollectionError{Step: name, StatusCode: statusCode, Err: err}
+		}
+		if !step.expects(statusCode) {
+			return state, &CollectionError{Step: name, StatusCode: statusCode, Err: fmt.Errorf("unexpected status %d: %s", statusCode, body)}
+		}
+		if len(step.Extract) == 0 {
+			continue
+		}
+
+		doc, err := decodeJSONDocument(body)
+		if err != nil {
+			return state, &CollectionError{Step: name, StatusCode: statusCode, Err: err}
+		}
+		for variable, path := range step.Extract {
+			value, err := lookupJSONPath(doc, path)
+			if err != nil {
+				return state, &CollectionError{Step: name, StatusCode: statusCode, Err: err}
+			}
+			state[variable] = value
+		}
+	}
+	return state, nil
+}
+
+func (s CollectionStep) expects(statusCode int) bool {
+	if s.ExpectStatus != 0 {
+		return statusCode == s.ExpectStatus
+	}
+	return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+}
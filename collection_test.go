@@ -0,0 +1,67 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollection_ExtractsBetweenSteps(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"session":{"token":"s3cr3t"}}`))
+	})
+	mux.HandleFunc("POST /projects", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":[{"id":9007199254740993}]}`))
+	})
+	mux.HandleFunc("GET /projects/9007199254740993", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"demo"}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	var collection httpclientutils.Collection
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"steps": [
+			{"name": "login", "request": {"method": "POST", "url": "{{.base}}/login"}, "extract": {"token": "$.session.token"}},
+			{"name": "create", "request": {"method": "POST", "url": "{{.base}}/projects", "headers": {"Authorization": "Bearer {{.token}}"}},
+			 "expect_status": 201, "extract": {"project": "data[0].id"}},
+			{"name": "fetch", "request": {"url": "{{.base}}/projects/{{.project}}"}, "extract": {"name": "name"}}
+		]
+	}`), &collection))
+
+	vars, err := collection.Run(context.Background(), map[string]interface{}{"base": ts.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, "demo", vars["name"])
+	assert.Equal(t, json.Number("9007199254740993"), vars["project"])
+}
+
+func TestCollection_StopsAtFailingStep(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer ts.Close()
+
+	collection := httpclientutils.Collection{
+		Variables: map[string]interface{}{"base": ts.URL},
+		Steps: []httpclientutils.CollectionStep{
+			{Name: "list", Request: httpclientutils.RequestTemplate{URL: "{{.base}}"}, Extract: map[string]string{"first": "items[0]"}},
+			{Name: "never", Request: httpclientutils.RequestTemplate{URL: "{{.base}}/{{.first}}"}},
+		},
+	}
+	_, err := collection.Run(context.Background(), nil)
+
+	var stepErr *httpclientutils.CollectionError
+	if assert.ErrorAs(t, err, &stepErr) {
+		assert.Equal(t, "list", stepErr.Step)
+	}
+	assert.ErrorIs(t, err, httpclientutils.ErrPathNotFound)
+}
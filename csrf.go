@@ -0,0 +1,134 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrCSRFTokenNotFound is returned when the CSRF page carries no token in
+// any of the configured places.
+var ErrCSRFTokenNotFound = errors.New("csrf token not found")
+
+// CSRFConfig describes where a web framework publishes its CSRF token.
+type CSRFConfig struct {
+	URL            string // page fetched to obtain the token and session cookies
+	Cookie         string // cookie carrying the token, e.g. csrftoken or XSRF-TOKEN
+	ResponseHeader string // response header carrying the token, e.g. X-CSRF-Token
+	Meta           string // <meta> name carrying the token; defaults to csrf-token
+	Header         string // request header the token is sent in; defaults to X-CSRF-Token
+}
+
+// CSRF harvests a CSRF token with a GET of its page before the first
+// mutating request and sends it, along with the cookies set by the page,
+// on POST, PUT, PATCH and DELETE requests to the same host. A 403 or 419
+// response discards the token so the next request fetches a new one.
+type CSRF struct {
+	config CSRFConfig
+
+	mu      sync.Mutex
+	token   string
+	cookies []*http.Cookie
+}
+
+// NewCSRF returns a CSRF for config.
+func NewCSRF(config CSRFConfig) *CSRF {
+	if config.Meta == "" {
+		config.Meta = "csrf-token"
+	}
+	if config.Header == "" {
+		config.Header = "X-CSRF-Token"
+	}
+	return &CSRF{config: config}
+}
+
+func (c *CSRF) applies(options *RequestOptions) bool {
+	switch options.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	target, err := url.Parse(options.URL)
+	if err != nil {
+		return false
+	}
+	page, err := url.Parse(c.config.URL)
+	return err == nil && strings.EqualFold(target.Host, page.Host)
+}
+
+func (c *CSRF) apply(options *RequestOptions) error {
+	if !c.applies(options) {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" {
+		if options.DryRun != nil {
+			// Dry runs never dial, so there is no token to preview yet.
+			return nil
+		}
+		if err := c.fetch(options); err != nil {
+			return err
+		}
+	}
+
+	withHeader(c.config.Header, c.token)(options)
+	cookies := make([]string, 0, len(c.cookies)+1)
+	if existing := requestHeader(options, "Cookie"); existing != "" {
+		cookies = append(cookies, existing)
+	}
+	for _, cookie := range c.cookies {
+		cookies = append(cookies, cookie.Name+"="+cookie.Value)
+	}
+	if len(cookies) > 0 {
+		withHeader("Cookie", strings.Join(cookies, "; "))(options)
+	}
+	return nil
+}
+
+func (c *CSRF) fetch(options *RequestOptions) error {
+	// The page is fetched under the same policies as the request itself:
+	// offline mode, robots, scheduling, deadlines and auditing.
+	fetch := detachedOptions(options)
+	fetch.Method = http.MethodGet
+	fetch.URL = c.config.URL
+	fetch.Body = nil
+	statusCode, header, body, err := send(fetch)
+	if err != nil {
+		return fmt.Errorf("failed to fetch csrf token: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch csrf token: unexpected status %d", statusCode)
+	}
+
+	cookies := (&http.Response{Header: header}).Cookies()
+	token := ""
+	if c.config.ResponseHeader != "" {
+		token = header.Get(c.config.ResponseHeader)
+	}
+	for _, cookie := range cookies {
+		if token == "" && c.config.Cookie != "" && cookie.Name == c.config.Cookie {
+			token = cookie.Value
+		}
+	}
+	if token == "" && isHTML(header.Get("Content-Type")) {
+		token = ParseHTML(body, c.config.URL).Meta[strings.ToLower(c.config.Meta)]
+	}
+	if token == "" {
+		return ErrCSRFTokenNotFound
+	}
+	c.token, c.cookies = token, cookies
+	return nil
+}
+
+// observe discards the token when the server rejected it.
+func (c *CSRF) observe(options *RequestOptions, statusCode int) {
+	if (statusCode != http.StatusForbidden && statusCode != 419) || !c.applies(options) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.cookies = "", nil
+}
@@ -0,0 +1,112 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRF_HarvestsMetaTokenAndCookies(t *testing.T) {
+	var pages int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			n := atomic.AddInt32(&pages, 1)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			w.Header().Set("Content-Type", "text/html")
+			token := "t1"
+			if n > 1 {
+				token = "t2"
+			}
+			w.Write([]byte(`<html><head><meta name="csrf-token" content="` + token + `"></head></html>`))
+			return
+		}
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "s1" || r.Header.Get("X-CSRF-Token") != "t2" {
+			w.WriteHeader(419)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	csrf := httpclientutils.NewCSRF(httpclientutils.CSRFConfig{URL: ts.URL + "/login"})
+	post := func() int {
+		status, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithMethod(http.MethodPost),
+			httpclientutils.WithURL(ts.URL+"/items"),
+			httpclientutils.WithCSRF(csrf),
+		)
+		assert.NoError(t, err)
+		return status
+	}
+
+	// The first token is rejected, so the next request fetches a new one.
+	assert.Equal(t, 419, post())
+	assert.Equal(t, http.StatusCreated, post())
+	assert.Equal(t, http.StatusCreated, post())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&pages))
+}
+
+func TestCSRF_CookieToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.SetCookie(w, &http.Cookie{Name: "XSRF-TOKEN", Value: "abc"})
+			return
+		}
+		cookie, err := r.Cookie("XSRF-TOKEN")
+		if err != nil || cookie.Value != r.Header.Get("X-XSRF-TOKEN") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodDelete),
+		httpclientutils.WithURL(ts.URL+"/items/1"),
+		httpclientutils.WithCSRF(httpclientutils.NewCSRF(httpclientutils.CSRFConfig{URL: ts.URL, Cookie: "XSRF-TOKEN", Header: "X-XSRF-TOKEN"})),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithCSRF(httpclientutils.NewCSRF(httpclientutils.CSRFConfig{URL: ts.URL, Cookie: "csrftoken"})),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrCSRFTokenNotFound)
+}
+
+func TestCSRF_DryRunAndOffline(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("X-CSRF-Token", "abc")
+	}))
+	defer ts.Close()
+
+	csrf := httpclientutils.NewCSRF(httpclientutils.CSRFConfig{URL: ts.URL, ResponseHeader: "X-CSRF-Token"})
+	var prepared httpclientutils.PreparedRequest
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/items"),
+		httpclientutils.WithCSRF(csrf),
+		httpclientutils.WithDryRun(&prepared),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, prepared.Method)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	httpclientutils.SetOffline(true)
+	defer httpclientutils.SetOffline(false)
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/items"),
+		httpclientutils.WithCSRF(csrf),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrOffline)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+}
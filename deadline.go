@@ -0,0 +1,28 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInsufficientDeadline is returned when the request context expires
+// sooner than the configured minimum remaining deadline.
+var ErrInsufficientDeadline = errors.New("insufficient deadline remaining")
+
+// checkDeadline fails fast when the context cannot outlive
+// MinRemainingDeadline, sparing the backend work whose result would be
+// discarded anyway.
+func checkDeadline(options *RequestOptions) error {
+	if options.MinRemainingDeadline <= 0 {
+		return nil
+	}
+	deadline, ok := options.Context.Deadline()
+	if !ok {
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining < options.MinRemainingDeadline {
+		return fmt.Errorf("%w: %s left, need %s", ErrInsufficientDeadline, max(remaining, 0).Round(time.Millisecond), options.MinRemainingDeadline)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinRemainingDeadline_FailsFast(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithContext(ctx),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithMinRemainingDeadline(time.Second),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrInsufficientDeadline)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithContext(ctx),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithMinRemainingDeadline(10*time.Millisecond),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,205 @@
+package httpclientutils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// AddressFamily controls which IP versions outbound connections use.
+type AddressFamily int
+
+const (
+	// FamilyAuto keeps Go's default dual-stack behavior.
+	FamilyAuto AddressFamily = iota
+	// PreferIPv4 dials IPv4 addresses first, falling back to IPv6 after
+	// the fallback delay.
+	PreferIPv4
+	// PreferIPv6 dials IPv6 addresses first, falling back to IPv4 after
+	// the fallback delay.
+	PreferIPv6
+	// IPv4Only never dials IPv6 addresses.
+	IPv4Only
+	// IPv6Only never dials IPv4 addresses.
+	IPv6Only
+)
+
+// defaultFallbackDelay matches net.Dialer's Happy Eyeballs default.
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// dialContext returns a dial func honoring family on top of dialer.
+func dialContext(dialer *net.Dialer, family AddressFamily) func(ctx context.Context, network, address string) (net.Conn, error) {
+	switch family {
+	case IPv4Only:
+		return func(ctx context.Context, _, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp4", address)
+		}
+	case IPv6Only:
+		return func(ctx context.Context, _, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp6", address)
+		}
+	case PreferIPv4, PreferIPv6:
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialPreferred(ctx, dialer, network, address, family == PreferIPv6)
+		}
+	}
+	return dialer.DialContext
+}
+
+// dialInterface binds each connection to an address of the named network
+// interface, looked up at dial time since interface addresses can change.
+func dialInterface(dialer *net.Dialer, name string, family AddressFamily) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		ip, err := interfaceAddr(name, family == PreferIPv6 || family == IPv6Only)
+		if err != nil {
+			return nil, err
+		}
+		bound := *dialer
+		bound.LocalAddr = &net.TCPAddr{IP: ip}
+		return dialContext(&bound, family)(ctx, network, address)
+	}
+}
+
+// interfaceAddr returns a global unicast address of the named interface,
+// of the preferred IP version when it has one.
+func interfaceAddr(name string, preferIPv6 bool) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find network interface %q: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses of network interface %q: %w", name, err)
+	}
+	var found net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if (ipNet.IP.To4() == nil) == preferIPv6 {
+			return ipNet.IP, nil
+		}
+		if found == nil {
+			found = ipNet.IP
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("network interface %q has no usable address", name)
+	}
+	return found, nil
+}
+
+// dialPreferred resolves address itself so the preferred family can be
+// dialed first, whatever order the resolver returned.
+func dialPreferred(ctx context.Context, dialer *net.Dialer, network, address string, preferIPv6 bool) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+	resolver := dialer.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var primary, fallback []string
+	for _, ip := range ips {
+		addr := net.JoinHostPort(ip.IP.String(), port)
+		if (ip.IP.To4() == nil) == preferIPv6 {
+			primary = append(primary, addr)
+		} else {
+			fallback = append(fallback, addr)
+		}
+	}
+	if len(primary) == 0 {
+		primary, fallback = fallback, nil
+	}
+	if len(fallback) == 0 || dialer.FallbackDelay < 0 {
+		return dialSerial(ctx, dialer, append(primary, fallback...))
+	}
+	return dialRace(ctx, dialer, primary, fallback)
+}
+
+// dialSerial dials addrs in order and returns the first connection.
+func dialSerial(ctx context.Context, dialer *net.Dialer, addrs []string) (net.Conn, error) {
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// dialRace dials primary, starting on fallback once the fallback delay has
+// passed or primary has failed, and returns whichever connects first.
+func dialRace(ctx context.Context, dialer *net.Dialer, primary, fallback []string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result, 2)
+	start := func(addrs []string) {
+		go func() {
+			conn, err := dialSerial(ctx, dialer, addrs)
+			results <- result{conn, err}
+		}()
+	}
+
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = defaultFallbackDelay
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	start(primary)
+	pending, fallbackStarted := 1, false
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				start(fallback)
+				pending, fallbackStarted = pending+1, true
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// Close a connection the other dial may still complete.
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if other := <-results; other.conn != nil {
+							other.conn.Close()
+						}
+					}
+				}(pending)
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if !fallbackStarted {
+				start(fallback)
+				pending, fallbackStarted = pending+1, true
+			} else if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
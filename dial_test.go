@@ -0,0 +1,112 @@
+package httpclientutils_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAddressFamily(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	url := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+
+	for _, family := range []httpclientutils.AddressFamily{httpclientutils.IPv4Only, httpclientutils.PreferIPv4, httpclientutils.PreferIPv6} {
+		status, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(url),
+			httpclientutils.WithAddressFamily(family),
+			httpclientutils.WithFallbackDelay(50*time.Millisecond),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", string(body))
+	}
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithAddressFamily(httpclientutils.IPv6Only),
+	)
+	assert.Error(t, err)
+}
+
+func TestWithLocalAddr(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		w.Write([]byte(host))
+	}))
+	defer ts.Close()
+
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithLocalAddr(net.ParseIP("127.0.0.1")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", string(body))
+}
+
+func TestWithNetworkInterface(t *testing.T) {
+	interfaces, _ := net.Interfaces()
+	var loopback string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			loopback = iface.Name
+		}
+	}
+	if loopback == "" {
+		t.Skip("no loopback interface")
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithNetworkInterface(loopback),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithNetworkInterface("no-such-nic0"),
+	)
+	assert.ErrorContains(t, err, "no-such-nic0")
+}
+
+func TestDial_FallsBackToNextAddress(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	// 127.0.0.2 is loopback too, but nothing listens there.
+	doh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, _ := io.ReadAll(r.Body)
+		w.Write(dnsAnswer(query, net.IPv4(127, 0, 0, 2), net.IPv4(127, 0, 0, 1)))
+	}))
+	defer doh.Close()
+
+	var resp httpclientutils.Response
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://multi.dial-test.example:"+port),
+		httpclientutils.WithDoHResolver(doh.URL),
+		httpclientutils.WithResponse(&resp),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	if assert.NotNil(t, resp.Conn) {
+		assert.Equal(t, "127.0.0.1:"+port, resp.Conn.RemoteAddr)
+		assert.Equal(t, []string{"127.0.0.2:" + port}, resp.Conn.FailedAddrs)
+	}
+}
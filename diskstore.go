@@ -0,0 +1,203 @@
+package httpclientutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const diskIndexFile = "index.json"
+
+// DiskStore is a CacheStore persisting entries under a directory as
+// content-addressed files plus an index, evicting least recently used
+// entries once the total size exceeds the configured bound.
+type DiskStore struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	index map[string]*diskIndexEntry
+}
+
+type diskIndexEntry struct {
+	Hash       string    `json:"hash"`
+	Size       int64     `json:"size"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// NewDiskStore opens (or creates) a DiskStore in dir. A maxBytes of zero
+// disables the size bound.
+func NewDiskStore(dir string, maxBytes int64) (*DiskStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "objects"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	s := &DiskStore{dir: dir, maxBytes: maxBytes, index: make(map[string]*diskIndexEntry)}
+	data, err := os.ReadFile(filepath.Join(dir, diskIndexFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read cache index: %w", err)
+	default:
+		if err := json.Unmarshal(data, &s.index); err != nil {
+			return nil, fmt.Errorf("failed to parse cache index: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *DiskStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		return nil, false, s.remove(key)
+	}
+
+	value, err := os.ReadFile(s.objectPath(entry.Hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, s.remove(key)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cache object: %w", err)
+	}
+	entry.LastAccess = time.Now()
+	return value, true, nil
+}
+
+func (s *DiskStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := sha256.Sum256(value)
+	hash := hex.EncodeToString(sum[:])
+	if err := writeFileAtomic(s.objectPath(hash), value); err != nil {
+		return fmt.Errorf("failed to write cache object: %w", err)
+	}
+
+	if old, ok := s.index[key]; ok && old.Hash != hash {
+		delete(s.index, key)
+		s.releaseObject(old.Hash)
+	}
+	entry := &diskIndexEntry{Hash: hash, Size: int64(len(value)), LastAccess: time.Now()}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	s.index[key] = entry
+
+	s.evict()
+	return s.saveIndex()
+}
+
+func (s *DiskStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remove(key)
+}
+
+func (s *DiskStore) remove(key string) error {
+	entry, ok := s.index[key]
+	if !ok {
+		return nil
+	}
+	delete(s.index, key)
+	s.releaseObject(entry.Hash)
+	return s.saveIndex()
+}
+
+// evict drops least recently used entries until the store fits maxBytes.
+func (s *DiskStore) evict() {
+	if s.maxBytes <= 0 {
+		return
+	}
+	size := s.size()
+	if size <= s.maxBytes {
+		return
+	}
+
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.index[keys[i]].LastAccess.Before(s.index[keys[j]].LastAccess) })
+
+	for _, key := range keys {
+		if size <= s.maxBytes {
+			return
+		}
+		entry := s.index[key]
+		delete(s.index, key)
+		if s.releaseObject(entry.Hash) {
+			size -= entry.Size
+		}
+	}
+}
+
+// size returns the bytes used by distinct objects.
+func (s *DiskStore) size() int64 {
+	var size int64
+	seen := make(map[string]bool)
+	for _, entry := range s.index {
+		if !seen[entry.Hash] {
+			seen[entry.Hash] = true
+			size += entry.Size
+		}
+	}
+	return size
+}
+
+// releaseObject removes the object file unless another key still refers to
+// it, reporting whether it was removed.
+func (s *DiskStore) releaseObject(hash string) bool {
+	for _, entry := range s.index {
+		if entry.Hash == hash {
+			return false
+		}
+	}
+	os.Remove(s.objectPath(hash))
+	return true
+}
+
+func (s *DiskStore) saveIndex() error {
+	data, err := json.Marshal(s.index)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache index: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, diskIndexFile), data); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	return nil
+}
+
+func (s *DiskStore) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,53 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskStore_PersistsAcrossInstances(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("persisted"))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		store, err := httpclientutils.NewDiskStore(dir, 0)
+		assert.NoError(t, err)
+
+		_, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithCache(httpclientutils.NewCache(store)),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "persisted", string(body))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestDiskStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store, err := httpclientutils.NewDiskStore(t.TempDir(), 10)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Set("a", []byte("aaaa"), 0))
+	assert.NoError(t, store.Set("b", []byte("bbbb"), 0))
+	_, ok, _ := store.Get("a")
+	assert.True(t, ok)
+	assert.NoError(t, store.Set("c", []byte("cccc"), 0))
+
+	_, ok, _ = store.Get("a")
+	assert.True(t, ok)
+	_, ok, _ = store.Get("b")
+	assert.False(t, ok)
+	_, ok, _ = store.Get("c")
+	assert.True(t, ok)
+}
@@ -0,0 +1,118 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// dnsMessageType is the RFC 8484 media type for DNS wire-format messages.
+const dnsMessageType = "application/dns-message"
+
+// encryptedResolver returns a resolver sending queries to the DNS-over-HTTPS
+// endpoint dohURL or, failing that, the DNS-over-TLS server dotAddr.
+func encryptedResolver(dohURL, dotAddr string) *net.Resolver {
+	if dohURL != "" {
+		return &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, url: dohURL}, nil
+		}}
+	}
+	host, _, err := net.SplitHostPort(dotAddr)
+	if err != nil {
+		host, dotAddr = dotAddr, net.JoinHostPort(dotAddr, "853")
+	}
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: host}}
+	return &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp", dotAddr)
+	}}
+}
+
+// dohConn carries DNS queries over HTTPS. It is not a net.PacketConn, so the
+// Go resolver speaks the length-prefixed TCP framing to it; each framed
+// query written is POSTed to the endpoint and the framed answer read back.
+type dohConn struct {
+	ctx      context.Context
+	url      string
+	deadline time.Time
+	query    bytes.Buffer
+	answer   bytes.Buffer
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.query.Write(b)
+	for c.query.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.query.Bytes()))
+		if c.query.Len() < 2+size {
+			break
+		}
+		c.query.Next(2)
+		if err := c.exchange(c.query.Next(size)); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (c *dohConn) exchange(query []byte) error {
+	// The dial context carries the httptrace hooks of the request being
+	// dialed, which must not fire for the resolver's own requests; only
+	// its cancellation is kept.
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	defer context.AfterFunc(c.ctx, stop)()
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(query))
+	if err != nil {
+		return fmt.Errorf("failed to create DNS-over-HTTPS request: %w", err)
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send DNS-over-HTTPS request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DNS-over-HTTPS server returned status %d", resp.StatusCode)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 0xffff+1))
+	if err != nil {
+		return fmt.Errorf("failed to read DNS-over-HTTPS response: %w", err)
+	}
+	if len(answer) > 0xffff {
+		return errors.New("DNS-over-HTTPS response too large")
+	}
+	c.answer.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer))))
+	c.answer.Write(answer)
+	return nil
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.answer.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.answer.Read(b)
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr{} }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "https" }
+func (dohAddr) String() string  { return "dns-over-https" }
@@ -0,0 +1,67 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// dnsAnswer answers an A query with ips and every other query with an empty
+// answer section.
+func dnsAnswer(query []byte, ips ...net.IP) []byte {
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	question := query[12 : end+5]
+	qtype := binary.BigEndian.Uint16(query[end+1:])
+
+	var answer bytes.Buffer
+	answer.Write(query[:2])
+	answer.Write([]byte{0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0})
+	answer.Write(question)
+	if qtype == 1 {
+		answer.Bytes()[7] = byte(len(ips))
+		for _, ip := range ips {
+			answer.Write([]byte{0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4})
+			answer.Write(ip.To4())
+		}
+	}
+	return answer.Bytes()
+}
+
+func TestWithDoHResolver(t *testing.T) {
+	var queries int32
+	doh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/dns-message", r.Header.Get("Content-Type"))
+		query, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(dnsAnswer(query, net.IPv4(127, 0, 0, 1)))
+	}))
+	defer doh.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+
+	url := strings.Replace(ts.URL, "127.0.0.1", "api.doh-test.example", 1)
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(url),
+		httpclientutils.WithDoHResolver(doh.URL+"/dns-query"),
+	)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "api.doh-test.example:"))
+	assert.NotZero(t, atomic.LoadInt32(&queries))
+}
@@ -0,0 +1,208 @@
+// Package docker is a small Docker Engine API client built on
+// httpclientutils, for tools that need a few endpoints of the local daemon
+// without the full Docker SDK.
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/InheritxSolution/httpclientutils"
+)
+
+// DefaultSocket is the daemon socket used when DOCKER_HOST is not set to a
+// unix:// address.
+const DefaultSocket = "/var/run/docker.sock"
+
+// Client calls the Docker Engine API over a unix socket.
+type Client struct {
+	socket     string
+	apiVersion string
+	options    []httpclientutils.Option
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSocket sets the daemon socket path.
+func WithSocket(path string) Option { return func(c *Client) { c.socket = path } }
+
+// WithAPIVersion pins requests to an API version such as "1.43"; by
+// default the daemon's own version is used.
+func WithAPIVersion(version string) Option { return func(c *Client) { c.apiVersion = version } }
+
+// WithRequestOptions adds httpclientutils options to every request, e.g.
+// WithTimeout or WithStats.
+func WithRequestOptions(opts ...httpclientutils.Option) Option {
+	return func(c *Client) { c.options = append(c.options, opts...) }
+}
+
+// NewClient returns a Client for the daemon named by DOCKER_HOST, or
+// DefaultSocket.
+func NewClient(opts ...Option) *Client {
+	c := &Client{socket: DefaultSocket}
+	if host, ok := strings.CutPrefix(os.Getenv("DOCKER_HOST"), "unix://"); ok && host != "" {
+		c.socket = host
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error response from the daemon.
+type Error struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("docker: %d %s", e.StatusCode, e.Message)
+}
+
+// Version describes the daemon (GET /version).
+type Version struct {
+	Version       string `json:"Version"`
+	APIVersion    string `json:"ApiVersion"`
+	MinAPIVersion string `json:"MinAPIVersion"`
+	GitCommit     string `json:"GitCommit"`
+	GoVersion     string `json:"GoVersion"`
+	Os            string `json:"Os"`
+	Arch          string `json:"Arch"`
+	KernelVersion string `json:"KernelVersion"`
+}
+
+// Container is an entry of GET /containers/json.
+type Container struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	ImageID string            `json:"ImageID"`
+	Command string            `json:"Command"`
+	Created int64             `json:"Created"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Labels  map[string]string `json:"Labels"`
+}
+
+// PullProgress is one message of the image pull progress stream.
+type PullProgress struct {
+	Status         string `json:"status"`
+	ID             string `json:"id"`
+	Progress       string `json:"progress"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// Version returns the daemon version.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if err := c.getJSON(ctx, "/version", &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ContainerList lists running containers, or all of them when all is set.
+func (c *Client) ContainerList(ctx context.Context, all bool) ([]Container, error) {
+	var containers []Container
+	if err := c.getJSON(ctx, "/containers/json?all="+strconv.FormatBool(all), &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ImagePull pulls ref (e.g. "alpine:3.20"), passing each progress message
+// to progress as it arrives. It fails if the stream reports an error.
+func (c *Client) ImagePull(ctx context.Context, ref string, progress func(PullProgress)) error {
+	image, tag := ref, "latest"
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		image, tag = ref[:i], ref[i+1:]
+	}
+	query := url.Values{"fromImage": {image}, "tag": {tag}}
+
+	// The body is streamed through a pipe so progress is reported while
+	// the pull runs rather than once it has finished.
+	reader, writer := io.Pipe()
+	type result struct {
+		statusCode int
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		statusCode, _, _, err := httpclientutils.MakeHTTPRequest(c.requestOptions(ctx, http.MethodPost, "/images/create?"+query.Encode(),
+			httpclientutils.WithResolveToWriter(writer))...)
+		writer.CloseWithError(err)
+		done <- result{statusCode, err}
+	}()
+
+	var streamErr error
+	decoder := json.NewDecoder(reader)
+	for {
+		var message PullProgress
+		if err := decoder.Decode(&message); err != nil {
+			if !errors.Is(err, io.EOF) {
+				streamErr = err
+			}
+			break
+		}
+		if message.Error != "" && streamErr == nil {
+			streamErr = errors.New("docker: " + message.Error)
+		}
+		if progress != nil {
+			progress(message)
+		}
+	}
+	reader.CloseWithError(io.ErrClosedPipe)
+	r := <-done
+	switch {
+	case r.err != nil:
+		return r.err
+	case r.statusCode >= http.StatusBadRequest:
+		return &Error{StatusCode: r.statusCode, Message: http.StatusText(r.statusCode)}
+	}
+	return streamErr
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, target interface{}) error {
+	statusCode, _, body, err := httpclientutils.MakeHTTPRequest(c.requestOptions(ctx, http.MethodGet, path)...)
+	if err != nil {
+		return err
+	}
+	if statusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: statusCode}
+		if json.Unmarshal(body, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(statusCode)
+		}
+		return apiErr
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to resolve response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) requestOptions(ctx context.Context, method, path string, extra ...httpclientutils.Option) []httpclientutils.Option {
+	if c.apiVersion != "" {
+		path = "/v" + c.apiVersion + path
+	}
+	opts := append([]httpclientutils.Option{}, c.options...)
+	opts = append(opts,
+		httpclientutils.WithContext(ctx),
+		httpclientutils.WithMethod(method),
+		httpclientutils.WithURL("http://docker"+path),
+		httpclientutils.WithUnixSocket(c.socket),
+	)
+	return append(opts, extra...)
+}
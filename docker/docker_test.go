@@ -0,0 +1,94 @@
+package docker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveDocker(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socket
+}
+
+func TestClient_VersionAndContainerList(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.43/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Version":"24.0.7","ApiVersion":"1.43","Os":"linux","Arch":"amd64"}`))
+	})
+	mux.HandleFunc("GET /v1.43/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("all"))
+		w.Write([]byte(`[{"Id":"abc123","Names":["/web"],"Image":"nginx","State":"running"}]`))
+	})
+	client := docker.NewClient(docker.WithSocket(serveDocker(t, mux)), docker.WithAPIVersion("1.43"))
+
+	version, err := client.Version(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "24.0.7", version.Version)
+	assert.Equal(t, "1.43", version.APIVersion)
+
+	containers, err := client.ContainerList(context.Background(), true)
+	assert.NoError(t, err)
+	if assert.Len(t, containers, 1) {
+		assert.Equal(t, "abc123", containers[0].ID)
+		assert.Equal(t, []string{"/web"}, containers[0].Names)
+	}
+}
+
+func TestClient_ErrorResponse(t *testing.T) {
+	socket := serveDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"daemon is shutting down"}`))
+	}))
+	_, err := docker.NewClient(docker.WithSocket(socket)).Version(context.Background())
+
+	var apiErr *docker.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Equal(t, "daemon is shutting down", apiErr.Message)
+	}
+}
+
+func TestClient_ImagePullStreamsProgress(t *testing.T) {
+	socket := serveDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/images/create", r.URL.Path)
+		assert.Equal(t, "localhost:5000/alpine", r.URL.Query().Get("fromImage"))
+		assert.Equal(t, "3.20", r.URL.Query().Get("tag"))
+		encoder := json.NewEncoder(w)
+		encoder.Encode(map[string]interface{}{"status": "Downloading", "id": "layer1", "progressDetail": map[string]int{"current": 5, "total": 10}})
+		w.(http.Flusher).Flush()
+		encoder.Encode(map[string]string{"status": "Download complete", "id": "layer1"})
+	}))
+
+	var messages []docker.PullProgress
+	err := docker.NewClient(docker.WithSocket(socket)).ImagePull(context.Background(), "localhost:5000/alpine:3.20", func(p docker.PullProgress) {
+		messages = append(messages, p)
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, int64(10), messages[0].ProgressDetail.Total)
+		assert.Equal(t, "Download complete", messages[1].Status)
+	}
+}
+
+func TestClient_ImagePullReportsStreamError(t *testing.T) {
+	socket := serveDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"Pulling"}` + "\n" + `{"error":"manifest unknown"}` + "\n"))
+	}))
+	err := docker.NewClient(docker.WithSocket(socket)).ImagePull(context.Background(), "missing", nil)
+	assert.EqualError(t, err, "docker: manifest unknown")
+}
@@ -0,0 +1,38 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PreparedRequest is a request as it would have been sent by a dry run.
+type PreparedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// errDryRun stops a dry run at the transport, after everything that shapes
+// the request has run.
+var errDryRun = errors.New("dry run")
+
+// dryRunTransport records the request instead of dialing.
+type dryRunTransport struct {
+	prepared *PreparedRequest
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		defer req.Body.Close()
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	*t.prepared = PreparedRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body}
+	return nil, errDryRun
+}
@@ -0,0 +1,37 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDryRun_ReturnsPreparedRequest(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer ts.Close()
+
+	var prepared httpclientutils.PreparedRequest
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodDelete),
+		httpclientutils.WithURL(ts.URL+"/users/42"),
+		httpclientutils.WithBody(map[string]bool{"purge": true}),
+		httpclientutils.WithBasicAuth("admin", "secret"),
+		httpclientutils.WithCache(httpclientutils.NewCache(httpclientutils.NewMemoryStore())),
+		httpclientutils.WithDryRun(&prepared),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	assert.Equal(t, http.MethodDelete, prepared.Method)
+	assert.Equal(t, ts.URL+"/users/42", prepared.URL)
+	assert.Equal(t, "Basic YWRtaW46c2VjcmV0", prepared.Header.Get("Authorization"))
+	assert.JSONEq(t, `{"purge":true}`, string(prepared.Body))
+}
@@ -0,0 +1,101 @@
+package httpclientutils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateRequest is returned when a DuplicateGuard blocks a repeated
+// non-idempotent request.
+var ErrDuplicateRequest = errors.New("duplicate request")
+
+// DuplicateGuard catches accidental double submits: a POST or PATCH with
+// the same method, URL and body as one sent within the window is refused
+// with ErrDuplicateRequest, or with WarnOnly sent anyway and reported as a
+// DuplicateRequest event. Requests carrying an Idempotency-Key header are
+// deliberate retries and are not checked, nor are streamed io.Reader
+// bodies, which cannot be fingerprinted without consuming them.
+type DuplicateGuard struct {
+	WarnOnly bool
+
+	window time.Duration
+
+	mu        sync.Mutex
+	seen      map[[sha256.Size]byte]time.Time
+	pruneSize int
+}
+
+// NewDuplicateGuard returns a DuplicateGuard remembering requests for
+// window.
+func NewDuplicateGuard(window time.Duration) *DuplicateGuard {
+	return &DuplicateGuard{window: window, seen: make(map[[sha256.Size]byte]time.Time)}
+}
+
+func (g *DuplicateGuard) check(options *RequestOptions) error {
+	if options.Method != http.MethodPost && options.Method != http.MethodPatch {
+		return nil
+	}
+	if requestHeader(options, "Idempotency-Key") != "" {
+		return nil
+	}
+	fingerprint, ok := requestFingerprint(options)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	g.mu.Lock()
+	sentAt, seen := g.seen[fingerprint]
+	duplicate := seen && now.Sub(sentAt) < g.window
+	if !duplicate {
+		g.seen[fingerprint] = now
+		g.prune(now)
+	}
+	g.mu.Unlock()
+	if !duplicate {
+		return nil
+	}
+
+	options.EventBus.publish(DuplicateRequest{Method: options.Method, URL: scrubText(options, options.URL), Meta: options.Meta, Blocked: !g.WarnOnly, FirstSent: sentAt})
+	if g.WarnOnly {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s sent %s ago", ErrDuplicateRequest, options.Method, scrubText(options, options.URL), now.Sub(sentAt).Round(time.Millisecond))
+}
+
+// prune drops expired fingerprints once the map has doubled since the
+// last pass.
+func (g *DuplicateGuard) prune(now time.Time) {
+	if len(g.seen) < max(g.pruneSize, 64) {
+		return
+	}
+	for fingerprint, sentAt := range g.seen {
+		if now.Sub(sentAt) >= g.window {
+			delete(g.seen, fingerprint)
+		}
+	}
+	g.pruneSize = 2 * len(g.seen)
+}
+
+func requestFingerprint(options *RequestOptions) ([sha256.Size]byte, bool) {
+	if !replayableBody(options.Body) {
+		return [sha256.Size]byte{}, false
+	}
+	hash := sha256.New()
+	io.WriteString(hash, options.Method+" "+options.URL+"\n")
+	body, err := prepareBody(options.Body, options.DisableEscapeHTML)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	if body != nil {
+		io.Copy(hash, body)
+	}
+	var fingerprint [sha256.Size]byte
+	hash.Sum(fingerprint[:0])
+	return fingerprint, true
+}
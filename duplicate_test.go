@@ -0,0 +1,50 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateGuard(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer ts.Close()
+
+	guard := httpclientutils.NewDuplicateGuard(time.Minute)
+	post := func(body string, opts ...httpclientutils.Option) error {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(append([]httpclientutils.Option{
+			httpclientutils.WithMethod(http.MethodPost),
+			httpclientutils.WithURL(ts.URL + "/orders"),
+			httpclientutils.WithBody(body),
+			httpclientutils.WithDuplicateGuard(guard),
+		}, opts...)...)
+		return err
+	}
+
+	assert.NoError(t, post(`{"sku":1}`))
+	assert.ErrorIs(t, post(`{"sku":1}`), httpclientutils.ErrDuplicateRequest)
+	assert.NoError(t, post(`{"sku":2}`))
+	assert.NoError(t, post(`{"sku":1}`, httpclientutils.WithHeaders(map[string]string{"Idempotency-Key": "abc"})))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+
+	guard.WarnOnly = true
+	bus := httpclientutils.NewEventBus()
+	events, cancel := bus.Subscribe(8)
+	defer cancel()
+	assert.NoError(t, post(`{"sku":1}`, httpclientutils.WithEventBus(bus)))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+	for event := range events {
+		if duplicate, ok := event.(httpclientutils.DuplicateRequest); ok {
+			assert.False(t, duplicate.Blocked)
+			break
+		}
+	}
+}
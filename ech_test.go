@@ -0,0 +1,56 @@
+package httpclientutils_test
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// echConfigList encodes a single-entry ECHConfigList for an X25519,
+// HKDF-SHA256, AES-128-GCM HPKE key.
+func echConfigList(t *testing.T, publicName string) []byte {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	publicKey := key.PublicKey().Bytes()
+
+	var contents []byte
+	contents = append(contents, 1)                             // config_id
+	contents = binary.BigEndian.AppendUint16(contents, 0x0020) // DHKEM(X25519, HKDF-SHA256)
+	contents = binary.BigEndian.AppendUint16(contents, uint16(len(publicKey)))
+	contents = append(contents, publicKey...)
+	contents = binary.BigEndian.AppendUint16(contents, 4)
+	contents = binary.BigEndian.AppendUint16(contents, 0x0001) // HKDF-SHA256
+	contents = binary.BigEndian.AppendUint16(contents, 0x0001) // AES-128-GCM
+	contents = append(contents, 0)                             // maximum_name_length
+	contents = append(contents, byte(len(publicName)))
+	contents = append(contents, publicName...)
+	contents = binary.BigEndian.AppendUint16(contents, 0) // extensions
+
+	config := binary.BigEndian.AppendUint16(nil, 0xfe0d)
+	config = binary.BigEndian.AppendUint16(config, uint16(len(contents)))
+	config = append(config, contents...)
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(config))), config...)
+}
+
+func TestWithECH(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	// The test server does not support ECH, so it answers the outer hello
+	// as example.com, one of its certificate names, and the client reports
+	// the rejection instead of continuing the handshake.
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(ts.Client().Transport.(*http.Transport).TLSClientConfig),
+		httpclientutils.WithECH(echConfigList(t, "example.com")),
+	)
+	var rejection *tls.ECHRejectionError
+	assert.ErrorAs(t, err, &rejection)
+}
@@ -0,0 +1,59 @@
+package httpclientutils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sync"
+)
+
+// EncodedBody is a request body marshaled at most once, on first use, and
+// reused by every request sending it, e.g. retries or a fan-out to several
+// endpoints. Requests with an EncodedBody get its content type unless a
+// Content-Type header is set.
+type EncodedBody struct {
+	contentType string
+	marshal     func() ([]byte, error)
+
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// NewEncodedBody returns a body encoded by marshal and sent as contentType.
+func NewEncodedBody(contentType string, marshal func() ([]byte, error)) *EncodedBody {
+	return &EncodedBody{contentType: contentType, marshal: marshal}
+}
+
+// JSONBody returns v encoded as application/json.
+func JSONBody(v interface{}) *EncodedBody {
+	return NewEncodedBody("application/json", func() ([]byte, error) { return json.Marshal(v) })
+}
+
+// XMLBody returns v encoded as application/xml.
+func XMLBody(v interface{}) *EncodedBody {
+	return NewEncodedBody("application/xml", func() ([]byte, error) { return xml.Marshal(v) })
+}
+
+// Bytes returns the encoded body, marshaling it on the first call.
+func (b *EncodedBody) Bytes() ([]byte, error) {
+	b.once.Do(func() {
+		if b.data, b.err = b.marshal(); b.err != nil {
+			b.err = fmt.Errorf("failed to marshal body: %w", b.err)
+		}
+	})
+	return b.data, b.err
+}
+
+// ContentType returns the media type the body is encoded in.
+func (b *EncodedBody) ContentType() string { return b.contentType }
+
+// bodyContentType returns the Content-Type an EncodedBody implies for
+// options, or "" when there is none or a header sets it explicitly.
+func bodyContentType(options *RequestOptions) string {
+	body, ok := options.Body.(*EncodedBody)
+	if !ok || requestHeader(options, "Content-Type") != "" {
+		return ""
+	}
+	return body.ContentType()
+}
@@ -0,0 +1,49 @@
+package httpclientutils_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodedBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Header.Get("Content-Type") + " " + string(body)))
+	}))
+	defer ts.Close()
+
+	marshals := 0
+	body := httpclientutils.NewEncodedBody("application/vnd.event+json", func() ([]byte, error) {
+		marshals++
+		return []byte(`{"event":"signup"}`), nil
+	})
+	for _, path := range []string{"/a", "/b", "/c"} {
+		_, _, got, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithMethod(http.MethodPost),
+			httpclientutils.WithURL(ts.URL+path),
+			httpclientutils.WithBody(body),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, `application/vnd.event+json {"event":"signup"}`, string(got))
+	}
+	assert.Equal(t, 1, marshals)
+
+	_, _, got, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithBody(httpclientutils.JSONBody(map[string]int{"n": 1})),
+		httpclientutils.WithHeaders(map[string]string{"Content-Type": "text/plain"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, `text/plain {"n":1}`, string(got))
+}
+
+func TestEncodedBody_MarshalError(t *testing.T) {
+	_, err := httpclientutils.JSONBody(func() {}).Bytes()
+	assert.ErrorContains(t, err, "failed to marshal body")
+}
@@ -0,0 +1,73 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Envelope names the fields of a {"data": ..., "error": ...} style
+// response envelope.
+type Envelope struct {
+	Data  string
+	Error string
+}
+
+// EnvelopeError is the non-null error member of an enveloped response.
+// Code and Message are filled from "code" and "message" members, or
+// Message from a bare string; Raw holds the member as sent.
+type EnvelopeError struct {
+	Code    string
+	Message string
+	Raw     json.RawMessage
+}
+
+func (e *EnvelopeError) Error() string {
+	switch {
+	case e.Code != "" && e.Message != "":
+		return fmt.Sprintf("api error %s: %s", e.Code, e.Message)
+	case e.Message != "":
+		return "api error: " + e.Message
+	case e.Code != "":
+		return "api error " + e.Code
+	default:
+		return "api error: " + string(e.Raw)
+	}
+}
+
+// unwrap returns the data member of body, or an *EnvelopeError when the
+// error member is set. A missing or null data member yields nil.
+func (e *Envelope) unwrap(body []byte) ([]byte, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, fmt.Errorf("failed to unwrap envelope: %w", err)
+	}
+	if raw, ok := members[e.Error]; ok && !isJSONNull(raw) {
+		return nil, newEnvelopeError(raw)
+	}
+	if raw, ok := members[e.Data]; ok && !isJSONNull(raw) {
+		return raw, nil
+	}
+	return nil, nil
+}
+
+func newEnvelopeError(raw json.RawMessage) *EnvelopeError {
+	envelopeErr := &EnvelopeError{Raw: raw}
+	if json.Unmarshal(raw, &envelopeErr.Message) == nil {
+		return envelopeErr
+	}
+	var fields struct {
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(raw, &fields) == nil {
+		envelopeErr.Message = fields.Message
+		envelopeErr.Code = strings.Trim(string(fields.Code), `"`)
+	}
+	return envelopeErr
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
@@ -0,0 +1,50 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEnvelope(t *testing.T) {
+	responses := map[string]string{
+		"/ok":     `{"data": {"name": "ada"}, "error": null}`,
+		"/failed": `{"data": null, "error": {"code": 4041, "message": "user not found"}}`,
+		"/text":   `{"error": "maintenance"}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(responses[r.URL.Path]))
+	}))
+	defer ts.Close()
+
+	request := func(path string) (map[string]string, error) {
+		var target map[string]string
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL+path),
+			httpclientutils.WithResolveResponse(&target),
+			httpclientutils.WithEnvelope("data", "error"),
+		)
+		return target, err
+	}
+
+	user, err := request("/ok")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "ada"}, user)
+
+	_, err = request("/failed")
+	var envelopeErr *httpclientutils.EnvelopeError
+	if assert.ErrorAs(t, err, &envelopeErr) {
+		assert.Equal(t, "4041", envelopeErr.Code)
+		assert.Equal(t, "user not found", envelopeErr.Message)
+	}
+
+	_, err = request("/text")
+	if assert.ErrorAs(t, err, &envelopeErr) {
+		assert.Equal(t, "maintenance", envelopeErr.Message)
+		assert.Equal(t, "api error: maintenance", envelopeErr.Error())
+	}
+}
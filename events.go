@@ -0,0 +1,118 @@
+package httpclientutils
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a request lifecycle event published on an EventBus. It is one
+// of RequestStarted, ResponseReceived, RequestFailed, CacheHit,
+// RobotsDisallowed, CertExpiring or DuplicateRequest.
+type Event interface {
+	isEvent()
+}
+
+// RequestStarted is published when a request enters the pipeline.
+type RequestStarted struct {
+	Method string
+	URL    string
+	Meta   Meta
+	Time   time.Time
+}
+
+// ResponseReceived is published when a request completes with a response.
+type ResponseReceived struct {
+	Method     string
+	URL        string
+	Meta       Meta
+	StatusCode int
+	Duration   time.Duration
+}
+
+// RequestFailed is published when a request completes with an error.
+type RequestFailed struct {
+	Method   string
+	URL      string
+	Meta     Meta
+	Err      error
+	Duration time.Duration
+}
+
+// CacheHit is published when a response is served from a Cache.
+type CacheHit struct {
+	Method string
+	URL    string
+	Meta   Meta
+	Stale  bool
+}
+
+// RobotsDisallowed is published when robots.txt disallows a request that
+// is sent anyway because of WithRobotsTxtWarnOnly.
+type RobotsDisallowed struct {
+	URL       string
+	Meta      Meta
+	UserAgent string
+}
+
+// DuplicateRequest is published when a DuplicateGuard sees a request
+// repeated within its window; Blocked is false for WarnOnly guards.
+type DuplicateRequest struct {
+	Method    string
+	URL       string
+	Meta      Meta
+	Blocked   bool
+	FirstSent time.Time
+}
+
+func (RequestStarted) isEvent()   {}
+func (ResponseReceived) isEvent() {}
+func (RequestFailed) isEvent()    {}
+func (CacheHit) isEvent()         {}
+func (RobotsDisallowed) isEvent() {}
+func (DuplicateRequest) isEvent() {}
+
+// EventBus fans events out to subscribers. Delivery never blocks a request:
+// events are dropped for subscribers whose buffer is full. A nil *EventBus
+// publishes nothing.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventBus returns an EventBus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving events, buffered to buffer events,
+// and a func that unsubscribes and closes the channel.
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *EventBus) publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
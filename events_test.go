@@ -0,0 +1,46 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus_PublishesLifecycleEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	defer ts.Close()
+
+	bus := httpclientutils.NewEventBus()
+	events, unsubscribe := bus.Subscribe(16)
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	for i := 0; i < 2; i++ {
+		httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithEventBus(bus),
+			httpclientutils.WithMeta("feature", "events"),
+		)
+	}
+	httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithEventBus(bus), httpclientutils.WithCache(cache))
+	httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithEventBus(bus), httpclientutils.WithCache(cache))
+	unsubscribe()
+
+	var kinds []string
+	for event := range events {
+		switch e := event.(type) {
+		case httpclientutils.RequestStarted:
+			kinds = append(kinds, "started")
+		case httpclientutils.ResponseReceived:
+			assert.Equal(t, http.StatusOK, e.StatusCode)
+			kinds = append(kinds, "received")
+		case httpclientutils.CacheHit:
+			assert.False(t, e.Stale)
+			kinds = append(kinds, "cache_hit")
+		}
+	}
+	assert.Equal(t, []string{"started", "received", "started", "received", "started", "received", "started", "cache_hit", "received"}, kinds)
+}
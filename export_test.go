@@ -0,0 +1,8 @@
+package httpclientutils
+
+// Internal primitives exposed to the external test package so they can be
+// checked against published known-answer vectors.
+var (
+	MD4Sum          = md4Sum
+	NTLMV2Responses = ntlmV2Responses
+)
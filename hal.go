@@ -0,0 +1,175 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrNoLink is returned by FollowLink when the response has no link with
+// the requested relation, e.g. on the last page of a collection.
+var ErrNoLink = errors.New("link not found")
+
+// HALLink is a HAL link object.
+type HALLink struct {
+	Href        string `json:"href"`
+	Templated   bool   `json:"templated,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+}
+
+// HALDocument holds the hypermedia controls of an application/hal+json
+// resource. Links and embedded resources are always lists, whether the
+// document used a single object or an array for the relation.
+type HALDocument struct {
+	Links    map[string][]HALLink
+	Embedded map[string][]json.RawMessage
+}
+
+// ParseHAL extracts the _links and _embedded members of a HAL resource.
+func ParseHAL(body []byte) (*HALDocument, error) {
+	var raw struct {
+		Links    map[string]json.RawMessage `json:"_links"`
+		Embedded map[string]json.RawMessage `json:"_embedded"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse HAL document: %w", err)
+	}
+	doc := &HALDocument{Links: make(map[string][]HALLink), Embedded: make(map[string][]json.RawMessage)}
+	for rel, value := range raw.Links {
+		var links []HALLink
+		if err := unmarshalOneOrMany(value, &links); err != nil {
+			return nil, fmt.Errorf("failed to parse HAL link %q: %w", rel, err)
+		}
+		doc.Links[rel] = links
+	}
+	for rel, value := range raw.Embedded {
+		var resources []json.RawMessage
+		if err := unmarshalOneOrMany(value, &resources); err != nil {
+			return nil, fmt.Errorf("failed to parse HAL embedded %q: %w", rel, err)
+		}
+		doc.Embedded[rel] = resources
+	}
+	return doc, nil
+}
+
+// unmarshalOneOrMany decodes a JSON value or array of values into the
+// slice pointed to by v.
+func unmarshalOneOrMany(data json.RawMessage, v interface{}) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '[' {
+		data = append(append([]byte("["), data...), ']')
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Link returns the first link with relation rel.
+func (d *HALDocument) Link(rel string) (HALLink, bool) {
+	links := d.Links[rel]
+	if len(links) == 0 {
+		return HALLink{}, false
+	}
+	return links[0], true
+}
+
+// DecodeEmbedded decodes the resources embedded under rel into v, which
+// should point to a slice.
+func (d *HALDocument) DecodeEmbedded(rel string, v interface{}) error {
+	data, err := json.Marshal(d.Embedded[rel])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+var uriTemplateExpression = regexp.MustCompile(`\{([?&]?)([^}]*)\}`)
+
+// Expand fills in a templated href with vars. It supports the simple
+// {var}, {?var,...} and {&var,...} forms of RFC 6570, which cover the
+// templates HAL APIs use in practice; unset variables are dropped.
+func (l HALLink) Expand(vars map[string]string) string {
+	if !l.Templated {
+		return l.Href
+	}
+	return uriTemplateExpression.ReplaceAllStringFunc(l.Href, func(expr string) string {
+		match := uriTemplateExpression.FindStringSubmatch(expr)
+		operator, names := match[1], strings.Split(match[2], ",")
+		if operator == "" {
+			var values []string
+			for _, name := range names {
+				if value, ok := vars[name]; ok {
+					values = append(values, url.PathEscape(value))
+				}
+			}
+			return strings.Join(values, ",")
+		}
+		var pairs []string
+		for _, name := range names {
+			if value, ok := vars[name]; ok {
+				pairs = append(pairs, url.QueryEscape(name)+"="+url.QueryEscape(value))
+			}
+		}
+		if len(pairs) == 0 {
+			return ""
+		}
+		prefix := "?"
+		if operator == "&" {
+			prefix = "&"
+		}
+		return prefix + strings.Join(pairs, "&")
+	})
+}
+
+// FollowLink requests the link with relation rel from the HAL resource in
+// resp, decodes the result into target and replaces resp with the new
+// response, so pagination can be written as a loop:
+//
+//	for err == nil { ...; err = FollowLink(&resp, "next", &page) }
+//
+// opts are applied to the request, e.g. credentials. Relative hrefs are
+// resolved against resp.URL() and templated ones are expanded without
+// variables. It returns ErrNoLink when there is no such link.
+func FollowLink(resp *Response, rel string, target interface{}, opts ...Option) error {
+	doc, err := ParseHAL(resp.Body)
+	if err != nil {
+		return err
+	}
+	link, ok := doc.Link(rel)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNoLink, rel)
+	}
+	href := link.Expand(nil)
+	if base, err := url.Parse(resp.URL()); err == nil && base.IsAbs() {
+		if ref, err := base.Parse(href); err == nil {
+			href = ref.String()
+		}
+	}
+
+	opts = append(append([]Option{}, opts...),
+		WithURL(href),
+		WithMethod(http.MethodGet),
+		withHeader("Accept", "application/hal+json, application/json"),
+		WithResponse(resp),
+	)
+	statusCode, _, body, err := MakeHTTPRequest(opts...)
+	if err != nil {
+		return err
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to follow %q link: status %d", rel, statusCode)
+	}
+	if target == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to resolve response: %w", err)
+	}
+	return nil
+}
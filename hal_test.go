@@ -0,0 +1,71 @@
+package httpclientutils_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderPage struct {
+	Total int `json:"total"`
+}
+
+func TestFollowLink_PaginatesHALCollection(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		page := r.URL.Query().Get("page")
+		next := ""
+		if page != "3" {
+			next = fmt.Sprintf(`"next": {"href": "/orders?page=%c"},`, page[0]+1)
+		}
+		fmt.Fprintf(w, `{
+  "_links": {%s "self": {"href": "/orders?page=%s"}, "find": {"href": "/orders{?id}", "templated": true},
+             "curies": [{"name": "ea", "href": "https://example.com/docs/rels/{rel}", "templated": true}]},
+  "_embedded": {"ea:order": [{"id": "%s-a"}, {"id": "%s-b"}]},
+  "total": 6
+}`, next, page, page, page)
+	}))
+	defer ts.Close()
+
+	var resp httpclientutils.Response
+	var page orderPage
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/orders?page=1"),
+		httpclientutils.WithResolveResponse(&page),
+		httpclientutils.WithResponse(&resp),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, page.Total)
+
+	var ids []string
+	for err == nil {
+		doc, parseErr := httpclientutils.ParseHAL(resp.Body)
+		assert.NoError(t, parseErr)
+		var orders []struct {
+			ID string `json:"id"`
+		}
+		assert.NoError(t, doc.DecodeEmbedded("ea:order", &orders))
+		for _, order := range orders {
+			ids = append(ids, order.ID)
+		}
+		err = httpclientutils.FollowLink(&resp, "next", &page)
+	}
+	assert.True(t, errors.Is(err, httpclientutils.ErrNoLink))
+	assert.Equal(t, []string{"1-a", "1-b", "2-a", "2-b", "3-a", "3-b"}, ids)
+	assert.Equal(t, ts.URL+"/orders?page=3", resp.URL())
+}
+
+func TestHALLink_Expand(t *testing.T) {
+	doc, err := httpclientutils.ParseHAL([]byte(`{"_links": {"find": {"href": "/orders/{id}{?fields,sort}", "templated": true}, "self": [{"href": "/a{b}"}]}}`))
+	assert.NoError(t, err)
+	find, ok := doc.Link("find")
+	assert.True(t, ok)
+	assert.Equal(t, "/orders/a%2Fb?sort=asc", find.Expand(map[string]string{"id": "a/b", "sort": "asc"}))
+	self, _ := doc.Link("self")
+	assert.Equal(t, "/a{b}", self.Expand(map[string]string{"b": "x"}))
+}
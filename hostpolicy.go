@@ -0,0 +1,52 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrHostNotAllowed is returned when a destination host is rejected by the
+// allowed or denied host lists.
+var ErrHostNotAllowed = errors.New("destination host not allowed")
+
+// checkHost enforces AllowedHosts and DeniedHosts against u. Denied hosts
+// take precedence; a non-empty allow list rejects every unlisted host.
+func checkHost(options *RequestOptions, u *url.URL) error {
+	if len(options.AllowedHosts) == 0 && len(options.DeniedHosts) == 0 {
+		return nil
+	}
+	host := normalizeHost(u.Hostname())
+	for _, pattern := range options.DeniedHosts {
+		if matchHost(pattern, host) {
+			return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+		}
+	}
+	if len(options.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, pattern := range options.AllowedHosts {
+		if matchHost(pattern, host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allow list", ErrHostNotAllowed, host)
+}
+
+// matchHost matches host against pattern, where "*.example.com" matches any
+// subdomain of example.com but not example.com itself. host must be
+// normalized.
+func matchHost(pattern, host string) bool {
+	pattern = normalizeHost(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return pattern == host
+}
+
+// normalizeHost lowercases host and drops the trailing dot of a fully
+// qualified name, which resolves to the same host as the name without it.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,70 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostPolicy_AllowAndDeny(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithAllowedHosts("*.example.com", "127.0.0.1"),
+	)
+	assert.NoError(t, err)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://api.other.com/"),
+		httpclientutils.WithAllowedHosts("*.example.com"),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrHostNotAllowed)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithAllowedHosts("127.0.0.1"),
+		httpclientutils.WithDeniedHosts("127.0.0.1"),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrHostNotAllowed)
+}
+
+func TestHostPolicy_EnforcedOnRedirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://internal.corp.example/admin", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithDeniedHosts("*.example"),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrHostNotAllowed)
+}
+
+func TestHostPolicy_TrailingDot(t *testing.T) {
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://localhost./"),
+		httpclientutils.WithDeniedHosts("localhost"),
+		httpclientutils.WithDryRun(&httpclientutils.PreparedRequest{}),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrHostNotAllowed)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://API.example.com./"),
+		httpclientutils.WithAllowedHosts("api.example.com"),
+		httpclientutils.WithDryRun(&httpclientutils.PreparedRequest{}),
+	)
+	assert.NoError(t, err)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://api.example.com/"),
+		httpclientutils.WithAllowedHosts("*.Example.com."),
+		httpclientutils.WithDryRun(&httpclientutils.PreparedRequest{}),
+	)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,125 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPlaintextRefused is returned for http:// requests, including redirect
+// targets, sent with WithRequireTLS.
+var ErrPlaintextRefused = errors.New("plaintext HTTP request refused")
+
+// HSTSPolicy remembers Strict-Transport-Security (RFC 6797) responses per
+// host, so later http:// requests to those hosts are upgraded to https://.
+// It is safe for concurrent use.
+type HSTSPolicy struct {
+	mu    sync.Mutex
+	hosts map[string]hstsEntry
+}
+
+type hstsEntry struct {
+	expires           time.Time
+	includeSubdomains bool
+}
+
+// NewHSTSPolicy returns an HSTSPolicy without known hosts.
+func NewHSTSPolicy() *HSTSPolicy {
+	return &HSTSPolicy{hosts: make(map[string]hstsEntry)}
+}
+
+// Add marks host as HTTPS-only for maxAge, e.g. to preload hosts known to
+// require TLS before any response has been seen.
+func (p *HSTSPolicy) Add(host string, maxAge time.Duration, includeSubdomains bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if maxAge <= 0 {
+		delete(p.hosts, host)
+		return
+	}
+	p.hosts[host] = hstsEntry{expires: time.Now().Add(maxAge), includeSubdomains: includeSubdomains}
+}
+
+// Known reports whether requests to host must use HTTPS.
+func (p *HSTSPolicy) Known(host string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	now := time.Now()
+	for candidate, exact := host, true; ; exact = false {
+		if entry, ok := p.hosts[candidate]; ok && now.Before(entry.expires) && (exact || entry.includeSubdomains) {
+			return true
+		}
+		_, parent, found := strings.Cut(candidate, ".")
+		if !found {
+			return false
+		}
+		candidate = parent
+	}
+}
+
+// observe records the Strict-Transport-Security header of a response
+// received over TLS; the header is ignored on plain HTTP as RFC 6797
+// requires.
+func (p *HSTSPolicy) observe(host, header string) {
+	if header == "" || net.ParseIP(host) != nil {
+		return
+	}
+	maxAge, includeSubdomains, found := time.Duration(0), false, false
+	for _, directive := range strings.Split(header, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				return
+			}
+			maxAge, found = time.Duration(seconds)*time.Second, true
+		case "includesubdomains":
+			includeSubdomains = true
+		}
+	}
+	if found {
+		p.Add(host, maxAge, includeSubdomains)
+	}
+}
+
+// tlsPolicyTransport upgrades and refuses plaintext requests, including
+// redirect hops, and learns HSTS hosts from responses.
+type tlsPolicyTransport struct {
+	base       http.RoundTripper
+	hsts       *HSTSPolicy
+	requireTLS bool
+}
+
+func wrapTLSPolicy(options *RequestOptions, base http.RoundTripper) http.RoundTripper {
+	if options.HSTS == nil && !options.RequireTLS {
+		return base
+	}
+	return &tlsPolicyTransport{base: base, hsts: options.HSTS, requireTLS: options.RequireTLS}
+}
+
+func (t *tlsPolicyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" && t.hsts != nil && t.hsts.Known(req.URL.Hostname()) {
+		upgraded := req.Clone(req.Context())
+		upgraded.URL.Scheme = "https"
+		if req.URL.Port() == "80" {
+			upgraded.URL.Host = net.JoinHostPort(req.URL.Hostname(), "443")
+		}
+		req = upgraded
+	}
+	if req.URL.Scheme == "http" && t.requireTLS {
+		return nil, fmt.Errorf("%w: %s", ErrPlaintextRefused, req.URL.Redacted())
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && t.hsts != nil && resp.TLS != nil {
+		t.hsts.observe(req.URL.Hostname(), resp.Header.Get("Strict-Transport-Security"))
+	}
+	return resp, err
+}
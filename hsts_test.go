@@ -0,0 +1,70 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHSTS_UpgradesKnownHosts(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=3600; includeSubDomains")
+		w.Write([]byte("secure"))
+	}))
+	defer ts.Close()
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	tlsConfig.ServerName = "example.com"
+	secureURL := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+	plainURL := strings.Replace(secureURL, "https://", "http://", 1)
+
+	policy := httpclientutils.NewHSTSPolicy()
+	assert.False(t, policy.Known("localhost"))
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(secureURL),
+		httpclientutils.WithTLSConfig(tlsConfig),
+		httpclientutils.WithHSTS(policy),
+	)
+	assert.NoError(t, err)
+	assert.True(t, policy.Known("localhost"))
+
+	var raw *http.Response
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(plainURL),
+		httpclientutils.WithTLSConfig(tlsConfig),
+		httpclientutils.WithHSTS(policy),
+		httpclientutils.WithRawResponse(&raw),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "secure", string(body))
+	assert.Equal(t, "https", raw.Request.URL.Scheme)
+}
+
+func TestHSTSPolicy_Subdomains(t *testing.T) {
+	policy := httpclientutils.NewHSTSPolicy()
+	policy.Add("example.com", time.Hour, true)
+	policy.Add("exact.test", time.Hour, false)
+
+	assert.True(t, policy.Known("api.example.com"))
+	assert.True(t, policy.Known("EXAMPLE.com."))
+	assert.True(t, policy.Known("exact.test"))
+	assert.False(t, policy.Known("www.exact.test"))
+	assert.False(t, policy.Known("example.org"))
+
+	policy.Add("example.com", 0, false)
+	assert.False(t, policy.Known("example.com"))
+}
+
+func TestWithRequireTLS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("plaintext request reached the server")
+	}))
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithRequireTLS())
+	assert.ErrorIs(t, err, httpclientutils.ErrPlaintextRefused)
+}
@@ -0,0 +1,349 @@
+package httpclientutils
+
+import (
+	"errors"
+	"html"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// ErrNotHTML is returned by WithResolveHTML for non-HTML responses.
+var ErrNotHTML = errors.New("response is not HTML")
+
+// HTMLDocument holds the metadata, links and forms of an HTML page.
+type HTMLDocument struct {
+	Title     string
+	Meta      map[string]string // content by lower-cased name, property or http-equiv
+	Canonical string
+	Links     []HTMLLink
+	Forms     []HTMLForm
+}
+
+// HTMLLink is a <link> or <a> element.
+type HTMLLink struct {
+	Rel  string
+	Href string
+	Text string // text of an <a>; empty for <link>
+}
+
+// HTMLForm is a <form> element with its named fields.
+type HTMLForm struct {
+	ID     string
+	Name   string
+	Action string
+	Method string
+	Fields []HTMLFormField
+}
+
+// HTMLFormField is a named input, select or textarea with its initial value.
+type HTMLFormField struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// Values returns the form's fields as form values, ready to be submitted.
+// Unchecked checkboxes and radio buttons are left out.
+func (f HTMLForm) Values() url.Values {
+	values := make(url.Values)
+	for _, field := range f.Fields {
+		values.Add(field.Name, field.Value)
+	}
+	return values
+}
+
+// ParseHTML extracts the document metadata, links and forms from body,
+// resolving URLs against baseURL. It is a forgiving tag scanner rather
+// than a full HTML5 parser: it does not build a tree, which is all
+// link-preview and form-scraping code needs.
+func ParseHTML(body []byte, baseURL string) *HTMLDocument {
+	base, _ := url.Parse(baseURL)
+	p := &htmlScanner{src: string(body), base: base, doc: &HTMLDocument{Meta: make(map[string]string)}, anchor: -1, form: -1, textarea: -1, sel: -1}
+	p.scan()
+	return p.doc
+}
+
+type htmlScanner struct {
+	src  string
+	pos  int
+	base *url.URL
+	doc  *HTMLDocument
+
+	// Indices of the open elements, or -1. Fields index the current form.
+	anchor, form, textarea, sel int
+
+	optionSeen bool             // the open select has a default option
+	selected   bool             // the open select has an explicitly selected option
+	option     *strings.Builder // text of the chosen option, when it has no value attribute
+	title      *strings.Builder
+}
+
+func (p *htmlScanner) scan() {
+	for p.pos < len(p.src) {
+		next := strings.IndexByte(p.src[p.pos:], '<')
+		if next < 0 {
+			p.text(p.src[p.pos:])
+			return
+		}
+		p.text(p.src[p.pos : p.pos+next])
+		p.pos += next
+
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], "<!--"):
+			p.skipPast("-->")
+		case strings.HasPrefix(p.src[p.pos:], "<!"), strings.HasPrefix(p.src[p.pos:], "<?"):
+			p.skipPast(">")
+		default:
+			name, attrs, closing := p.tag()
+			if closing {
+				p.end(name)
+			} else {
+				p.start(name, attrs)
+			}
+		}
+	}
+}
+
+func (p *htmlScanner) skipPast(marker string) {
+	end := strings.Index(p.src[p.pos:], marker)
+	if end < 0 {
+		p.pos = len(p.src)
+		return
+	}
+	p.pos += end + len(marker)
+}
+
+// tag parses the tag at p.pos, returning its lower-cased name, attributes
+// and whether it is an end tag.
+func (p *htmlScanner) tag() (string, map[string]string, bool) {
+	p.pos++ // '<'
+	closing := p.pos < len(p.src) && p.src[p.pos] == '/'
+	if closing {
+		p.pos++
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n/>", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	name := strings.ToLower(p.src[start:p.pos])
+
+	attrs := make(map[string]string)
+	for p.pos < len(p.src) {
+		for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n/", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			break
+		}
+		if p.src[p.pos] == '>' {
+			p.pos++
+			break
+		}
+		start := p.pos
+		for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n/>=", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		key := strings.ToLower(p.src[start:p.pos])
+		value := ""
+		if p.pos < len(p.src) && p.src[p.pos] == '=' {
+			p.pos++
+			value = p.attrValue()
+		}
+		if _, seen := attrs[key]; !seen && key != "" {
+			attrs[key] = html.UnescapeString(value)
+		}
+	}
+
+	if !closing && (name == "script" || name == "style") {
+		end := strings.Index(strings.ToLower(p.src[p.pos:]), "</"+name)
+		if end < 0 {
+			p.pos = len(p.src)
+		} else {
+			p.pos += end
+		}
+	}
+	return name, attrs, closing
+}
+
+func (p *htmlScanner) attrValue() string {
+	if p.pos >= len(p.src) {
+		return ""
+	}
+	if quote := p.src[p.pos]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(p.src[p.pos+1:], quote)
+		if end < 0 {
+			value := p.src[p.pos+1:]
+			p.pos = len(p.src)
+			return value
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n>", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *htmlScanner) text(raw string) {
+	if raw == "" {
+		return
+	}
+	text := html.UnescapeString(raw)
+	switch {
+	case p.title != nil:
+		p.title.WriteString(text)
+	case p.textarea >= 0:
+		p.field(p.textarea).Value += text
+	case p.option != nil:
+		p.option.WriteString(text)
+	}
+	if p.anchor >= 0 {
+		p.doc.Links[p.anchor].Text += text
+	}
+}
+
+func (p *htmlScanner) start(name string, attrs map[string]string) {
+	switch name {
+	case "title":
+		if p.doc.Title == "" {
+			p.title = &strings.Builder{}
+		}
+	case "meta":
+		content := attrs["content"]
+		for _, key := range []string{"name", "property", "http-equiv"} {
+			if attrs[key] != "" {
+				p.doc.Meta[strings.ToLower(attrs[key])] = content
+			}
+		}
+	case "link":
+		link := HTMLLink{Rel: strings.ToLower(attrs["rel"]), Href: p.resolve(attrs["href"])}
+		p.doc.Links = append(p.doc.Links, link)
+		if link.Rel == "canonical" && p.doc.Canonical == "" {
+			p.doc.Canonical = link.Href
+		}
+	case "a":
+		if href, ok := attrs["href"]; ok {
+			p.doc.Links = append(p.doc.Links, HTMLLink{Rel: strings.ToLower(attrs["rel"]), Href: p.resolve(href)})
+			p.anchor = len(p.doc.Links) - 1
+		}
+	case "base":
+		if href := attrs["href"]; href != "" && p.base != nil {
+			if base, err := p.base.Parse(href); err == nil {
+				p.base = base
+			}
+		}
+	case "form":
+		p.closeFields()
+		p.doc.Forms = append(p.doc.Forms, HTMLForm{
+			ID:     attrs["id"],
+			Name:   attrs["name"],
+			Action: p.resolve(attrs["action"]),
+			Method: strings.ToUpper(orDefault(attrs["method"], "GET")),
+		})
+		p.form = len(p.doc.Forms) - 1
+	case "input":
+		fieldType := strings.ToLower(orDefault(attrs["type"], "text"))
+		if _, checked := attrs["checked"]; (fieldType == "checkbox" || fieldType == "radio") && !checked {
+			return
+		}
+		if fieldType == "checkbox" || fieldType == "radio" {
+			attrs["value"] = orDefault(attrs["value"], "on")
+		}
+		p.addField(HTMLFormField{Name: attrs["name"], Type: fieldType, Value: attrs["value"]})
+	case "textarea":
+		p.textarea = p.addField(HTMLFormField{Name: attrs["name"], Type: "textarea"})
+	case "select":
+		p.sel = p.addField(HTMLFormField{Name: attrs["name"], Type: "select"})
+		p.optionSeen, p.selected = false, false
+	case "option":
+		p.endOption()
+		_, selected := attrs["selected"]
+		// The first option is the default until one is marked selected.
+		if p.sel < 0 || p.selected || p.optionSeen && !selected {
+			return
+		}
+		p.optionSeen, p.selected = true, selected
+		value, hasValue := attrs["value"]
+		p.field(p.sel).Value = value
+		if !hasValue {
+			p.option = &strings.Builder{}
+		}
+	}
+}
+
+func (p *htmlScanner) end(name string) {
+	switch name {
+	case "title":
+		if p.title != nil {
+			p.doc.Title = strings.TrimSpace(p.title.String())
+			p.title = nil
+		}
+	case "a":
+		if p.anchor >= 0 {
+			p.doc.Links[p.anchor].Text = strings.TrimSpace(p.doc.Links[p.anchor].Text)
+			p.anchor = -1
+		}
+	case "form":
+		p.closeFields()
+		p.form = -1
+	case "textarea":
+		p.textarea = -1
+	case "option":
+		p.endOption()
+	case "select":
+		p.endOption()
+		p.sel = -1
+	}
+}
+
+// closeFields ends any field left open in the current form.
+func (p *htmlScanner) closeFields() {
+	p.endOption()
+	p.textarea, p.sel = -1, -1
+}
+
+// endOption takes the text of a chosen option without a value attribute as
+// the select's value.
+func (p *htmlScanner) endOption() {
+	if p.option != nil {
+		p.field(p.sel).Value = strings.TrimSpace(p.option.String())
+		p.option = nil
+	}
+}
+
+// addField appends a named field to the current form, returning its index
+// or -1 when there is no open form.
+func (p *htmlScanner) addField(field HTMLFormField) int {
+	if p.form < 0 || field.Name == "" {
+		return -1
+	}
+	form := &p.doc.Forms[p.form]
+	form.Fields = append(form.Fields, field)
+	return len(form.Fields) - 1
+}
+
+func (p *htmlScanner) field(i int) *HTMLFormField {
+	return &p.doc.Forms[p.form].Fields[i]
+}
+
+func (p *htmlScanner) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if p.base == nil {
+		return ref
+	}
+	u, err := p.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// isHTML reports whether contentType is an HTML media type.
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
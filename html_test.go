@@ -0,0 +1,86 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+const samplePage = `<!DOCTYPE html>
+<html><head>
+<title>Example &amp; Co</title>
+<meta charset="utf-8">
+<meta name="Description" content="A sample page">
+<meta property="og:image" content="/img/card.png">
+<link rel="canonical" href="/articles/1">
+<script>if (a < b) { document.write("<title>nope</title>") }</script>
+<!-- <meta name="description" content="commented out"> -->
+</head><body>
+<a href="../about" rel=nofollow>About <b>us</b></a>
+<form id="login" action="/session" method="post">
+  <input type="hidden" name="csrf" value="t0k3n">
+  <input name="user">
+  <input type="checkbox" name="remember" checked>
+  <input type="checkbox" name="newsletter">
+  <select name="lang"><option value="en">English<option value="fr" selected>French</select>
+  <select name="tz"><option>UTC</option><option>CET</option></select>
+  <textarea name="note">hi &lt;there&gt;</textarea>
+</form>
+</body></html>`
+
+func TestParseHTML_ExtractsMetadataLinksAndForms(t *testing.T) {
+	doc := httpclientutils.ParseHTML([]byte(samplePage), "https://example.com/articles/page")
+
+	assert.Equal(t, "Example & Co", doc.Title)
+	assert.Equal(t, "A sample page", doc.Meta["description"])
+	assert.Equal(t, "/img/card.png", doc.Meta["og:image"])
+	assert.Equal(t, "https://example.com/articles/1", doc.Canonical)
+	assert.Contains(t, doc.Links, httpclientutils.HTMLLink{Rel: "nofollow", Href: "https://example.com/about", Text: "About us"})
+
+	if assert.Len(t, doc.Forms, 1) {
+		form := doc.Forms[0]
+		assert.Equal(t, "login", form.ID)
+		assert.Equal(t, "https://example.com/session", form.Action)
+		assert.Equal(t, http.MethodPost, form.Method)
+		assert.Equal(t, url.Values{
+			"csrf":     {"t0k3n"},
+			"user":     {""},
+			"remember": {"on"},
+			"lang":     {"fr"},
+			"tz":       {"UTC"},
+			"note":     {"hi <there>"},
+		}, form.Values())
+	}
+}
+
+func TestParseHTML_TruncatedTags(t *testing.T) {
+	for _, src := range []string{"<script ", "<style/", "<script\n", "<title", "<a href=", `<a href="x`, "<meta name=x ", "<", "</"} {
+		assert.NotPanics(t, func() { httpclientutils.ParseHTML([]byte(src), "https://example.com/") }, "%q", src)
+	}
+}
+
+func TestWithResolveHTML(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(samplePage))
+	}))
+	defer ts.Close()
+
+	var doc httpclientutils.HTMLDocument
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+"/page"), httpclientutils.WithResolveHTML(&doc))
+	assert.NoError(t, err)
+	assert.Equal(t, "Example & Co", doc.Title)
+	assert.Equal(t, ts.URL+"/articles/1", doc.Canonical)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+"/json"), httpclientutils.WithResolveHTML(&doc))
+	assert.ErrorIs(t, err, httpclientutils.ErrNotHTML)
+}
@@ -0,0 +1,222 @@
+package httpclientutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RFC 9421 signature algorithms.
+const (
+	SigAlgRSAPSSSHA512    = "rsa-pss-sha512"
+	SigAlgRSAv15SHA256    = "rsa-v1_5-sha256"
+	SigAlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	SigAlgECDSAP384SHA384 = "ecdsa-p384-sha384"
+	SigAlgEd25519         = "ed25519"
+	SigAlgHMACSHA256      = "hmac-sha256"
+)
+
+// MessageSignatureOptions configures HTTP Message Signatures (RFC 9421).
+type MessageSignatureOptions struct {
+	Label   string // signature label; defaults to "sig1"
+	KeyID   string
+	Key     crypto.Signer // for the asymmetric algorithms
+	HMACKey []byte        // for hmac-sha256
+
+	// Algorithm is sent as the alg parameter when set; otherwise it is
+	// inferred from the key and left out, as most profiles expect.
+	Algorithm string
+
+	// Components lists the covered components: derived components such as
+	// "@method", "@target-uri", "@authority", "@path" and "@query", and
+	// lower-case header names. A covered "content-digest" is computed from
+	// the body (RFC 9530) when the header is not already set. Defaults to
+	// "@method", "@target-uri" and, for requests with a body,
+	// "content-digest".
+	Components []string
+
+	Expires time.Duration // adds an expires parameter this long after created
+	Nonce   string
+	Tag     string
+}
+
+// SignMessage adds Signature-Input and Signature headers to req as of now.
+// Like SignSigV4 it must run after every covered header has been set;
+// WithMessageSignature does this for requests made by this package.
+func SignMessage(req *http.Request, opts MessageSignatureOptions, now time.Time) error {
+	alg := opts.Algorithm
+	if alg == "" {
+		var err error
+		if alg, err = messageSignatureAlgorithm(opts); err != nil {
+			return err
+		}
+	}
+	label := orDefault(opts.Label, "sig1")
+
+	components := opts.Components
+	if len(components) == 0 {
+		components = []string{"@method", "@target-uri"}
+		if req.Body != nil && req.Body != http.NoBody {
+			components = append(components, "content-digest")
+		}
+	}
+
+	var base strings.Builder
+	quoted := make([]string, len(components))
+	for i, component := range components {
+		value, err := signatureComponent(req, component)
+		if err != nil {
+			return err
+		}
+		quoted[i] = strconv.Quote(component)
+		fmt.Fprintf(&base, "%s: %s\n", quoted[i], value)
+	}
+
+	params := "(" + strings.Join(quoted, " ") + ");created=" + strconv.FormatInt(now.Unix(), 10)
+	if opts.Expires > 0 {
+		params += ";expires=" + strconv.FormatInt(now.Add(opts.Expires).Unix(), 10)
+	}
+	if opts.Nonce != "" {
+		params += ";nonce=" + strconv.Quote(opts.Nonce)
+	}
+	if opts.Algorithm != "" {
+		params += ";alg=" + strconv.Quote(opts.Algorithm)
+	}
+	if opts.KeyID != "" {
+		params += ";keyid=" + strconv.Quote(opts.KeyID)
+	}
+	if opts.Tag != "" {
+		params += ";tag=" + strconv.Quote(opts.Tag)
+	}
+	fmt.Fprintf(&base, "\"@signature-params\": %s", params)
+
+	signature, err := messageSign(opts, alg, []byte(base.String()))
+	if err != nil {
+		return fmt.Errorf("failed to sign message: %w", err)
+	}
+	req.Header.Add("Signature-Input", label+"="+params)
+	req.Header.Add("Signature", label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// signatureComponent returns the canonical value of a covered component
+// (RFC 9421 section 2).
+func signatureComponent(req *http.Request, component string) (string, error) {
+	switch component {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return strings.ToLower(req.URL.Scheme) + "://" + signatureAuthority(req) + req.URL.RequestURI(), nil
+	case "@authority":
+		return signatureAuthority(req), nil
+	case "@scheme":
+		return strings.ToLower(req.URL.Scheme), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		return orDefault(req.URL.EscapedPath(), "/"), nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	case "content-length":
+		if req.ContentLength > 0 {
+			return strconv.FormatInt(req.ContentLength, 10), nil
+		}
+	case "content-digest":
+		if req.Header.Get("Content-Digest") == "" {
+			body, err := replayBody(req)
+			if err != nil {
+				return "", fmt.Errorf("failed to read body for content-digest: %w", err)
+			}
+			digest := sha256.Sum256(body)
+			req.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(digest[:])+":")
+		}
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("unsupported signature component %q", component)
+	}
+
+	// Values returns the header's own slice; trim a copy so signing leaves
+	// the request's headers as they were.
+	values := slices.Clone(req.Header.Values(component))
+	if len(values) == 0 {
+		return "", fmt.Errorf("covered header %q is not set", component)
+	}
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// signatureAuthority returns the lower-cased host, without a default port.
+func signatureAuthority(req *http.Request) string {
+	host := strings.ToLower(orDefault(req.Host, req.URL.Host))
+	switch {
+	case req.URL.Scheme == "https" && strings.HasSuffix(host, ":443"):
+		return strings.TrimSuffix(host, ":443")
+	case req.URL.Scheme == "http" && strings.HasSuffix(host, ":80"):
+		return strings.TrimSuffix(host, ":80")
+	}
+	return host
+}
+
+func messageSignatureAlgorithm(opts MessageSignatureOptions) (string, error) {
+	if opts.HMACKey != nil {
+		return SigAlgHMACSHA256, nil
+	}
+	if opts.Key == nil {
+		return "", errors.New("message signature requires a key")
+	}
+	switch pub := opts.Key.Public().(type) {
+	case *rsa.PublicKey:
+		return SigAlgRSAPSSSHA512, nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return SigAlgECDSAP256SHA256, nil
+		case elliptic.P384():
+			return SigAlgECDSAP384SHA384, nil
+		}
+	case ed25519.PublicKey:
+		return SigAlgEd25519, nil
+	}
+	return "", errors.New("unsupported message signature key type")
+}
+
+func messageSign(opts MessageSignatureOptions, alg string, base []byte) ([]byte, error) {
+	if alg == SigAlgHMACSHA256 {
+		return hmacSHA256(opts.HMACKey, string(base)), nil
+	}
+	if opts.Key == nil {
+		return nil, fmt.Errorf("%s requires a signing key", alg)
+	}
+	switch alg {
+	case SigAlgRSAPSSSHA512:
+		digest := sha512.Sum512(base)
+		return opts.Key.Sign(rand.Reader, digest[:], &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512})
+	case SigAlgRSAv15SHA256:
+		digest := sha256.Sum256(base)
+		return opts.Key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case SigAlgECDSAP256SHA256:
+		digest := sha256.Sum256(base)
+		return ecdsaJOSESignature(opts.Key, digest[:], crypto.SHA256, 32)
+	case SigAlgECDSAP384SHA384:
+		digest := sha512.Sum384(base)
+		return ecdsaJOSESignature(opts.Key, digest[:], crypto.SHA384, 48)
+	case SigAlgEd25519:
+		return opts.Key.Sign(rand.Reader, base, crypto.Hash(0))
+	}
+	return nil, fmt.Errorf("unsupported signature algorithm %q", alg)
+}
@@ -0,0 +1,89 @@
+package httpclientutils_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc9421Request is the example request from RFC 9421 section 2.
+func rfc9421Request(t *testing.T) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+var rfc9421Created = time.Unix(1618884473, 0)
+
+func TestSignMessage_RFC9421HMACVector(t *testing.T) {
+	key, _ := base64.StdEncoding.DecodeString("uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+	req := rfc9421Request(t)
+
+	err := httpclientutils.SignMessage(req, httpclientutils.MessageSignatureOptions{
+		Label:      "sig-b25",
+		KeyID:      "test-shared-secret",
+		HMACKey:    key,
+		Components: []string{"date", "@authority", "content-type"},
+	}, rfc9421Created)
+	assert.NoError(t, err)
+	assert.Equal(t, `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`, req.Header.Get("Signature-Input"))
+	assert.Equal(t, "sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:", req.Header.Get("Signature"))
+}
+
+func TestSignMessage_RFC9421Ed25519Vector(t *testing.T) {
+	seed, _ := base64.StdEncoding.DecodeString("n4Ni+HpISpVObnQMW0wOhCKROaIKqKtW/2ZYb2p9KcU=")
+	req := rfc9421Request(t)
+
+	err := httpclientutils.SignMessage(req, httpclientutils.MessageSignatureOptions{
+		Label:      "sig-b26",
+		KeyID:      "test-key-ed25519",
+		Key:        ed25519.NewKeyFromSeed(seed),
+		Components: []string{"date", "@method", "@path", "@authority", "content-type", "content-length"},
+	}, rfc9421Created)
+	assert.NoError(t, err)
+	assert.Equal(t, "sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:", req.Header.Get("Signature"))
+}
+
+func TestSignMessage_LeavesHeaderValuesUntouched(t *testing.T) {
+	req := rfc9421Request(t)
+	req.Header["X-Tags"] = []string{" a ", "b "}
+
+	err := httpclientutils.SignMessage(req, httpclientutils.MessageSignatureOptions{
+		KeyID:      "k1",
+		HMACKey:    []byte("secret"),
+		Components: []string{"x-tags"},
+	}, rfc9421Created)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{" a ", "b "}, req.Header["X-Tags"])
+}
+
+func TestWithMessageSignature_DefaultsCoverBodyDigest(t *testing.T) {
+	var input, digest string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input = r.Header.Get("Signature-Input")
+		digest = r.Header.Get("Content-Digest")
+		assert.NotEmpty(t, r.Header.Get("Signature"))
+	}))
+	defer ts.Close()
+
+	_, key, _ := ed25519.GenerateKey(nil)
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithBody(`{"hello": "world"}`),
+		httpclientutils.WithMessageSignature(httpclientutils.MessageSignatureOptions{KeyID: "k1", Key: key, Algorithm: httpclientutils.SigAlgEd25519}),
+	)
+	assert.NoError(t, err)
+	assert.Contains(t, input, `sig1=("@method" "@target-uri" "content-digest");created=`)
+	assert.Contains(t, input, `;alg="ed25519";keyid="k1"`)
+	assert.Equal(t, "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:", digest)
+}
@@ -0,0 +1,302 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PageConfig describes where a paginated JSON API puts the items of a page
+// and the link to the next one.
+type PageConfig struct {
+	// Items is the JSON path of the item array, e.g. "data"; empty when
+	// the body itself is the array.
+	Items string
+	// Next is the JSON path of the next page URL, e.g. "links.next"; empty
+	// to follow the Link header's rel="next" (RFC 8288) instead.
+	Next string
+
+	// PageSizeParam and PageSize request a page size on the first page,
+	// e.g. per_page or limit, unless the URL already sets one.
+	PageSizeParam string
+	PageSize      int
+	// CursorParam and CursorField page by cursor instead: the next page
+	// sets the CursorParam query parameter to the CursorField (a JSON
+	// path) of the last item, e.g. starting_after and id.
+	CursorParam string
+	CursorField string
+	// OffsetParam pages by offset instead, advancing the parameter by the
+	// number of items received and stopping at an empty page or one
+	// shorter than PageSize.
+	OffsetParam string
+	// HasMore is the JSON path of a boolean saying whether more pages
+	// follow, e.g. "has_more"; paging stops when it is false.
+	HasMore string
+}
+
+// Names accepted by PagePreset.
+const (
+	PresetLinkHeader = "link"   // Link rel="next" header with per_page, as GitHub and GitLab
+	PresetGitHub     = "github" // same as PresetLinkHeader
+	PresetStripe     = "stripe" // data/has_more bodies with starting_after cursors
+	PresetOffset     = "offset" // offset/limit query parameters over a bare array
+)
+
+// ErrUnknownPagePreset is returned by PagePreset for names it does not
+// know.
+var ErrUnknownPagePreset = errors.New("unknown pagination preset")
+
+// PagePreset returns the PageConfig for a common pagination style by
+// name, requesting pageSize items per page when it is positive. The
+// result may be adjusted, e.g. to point Items at a different member.
+func PagePreset(name string, pageSize int) (PageConfig, error) {
+	switch strings.ToLower(name) {
+	case PresetLinkHeader, PresetGitHub:
+		return PageConfig{PageSizeParam: "per_page", PageSize: pageSize}, nil
+	case PresetStripe:
+		return PageConfig{Items: "data", HasMore: "has_more", CursorParam: "starting_after", CursorField: "id", PageSizeParam: "limit", PageSize: pageSize}, nil
+	case PresetOffset:
+		return PageConfig{OffsetParam: "offset", PageSizeParam: "limit", PageSize: pageSize}, nil
+	default:
+		return PageConfig{}, fmt.Errorf("%w: %q", ErrUnknownPagePreset, name)
+	}
+}
+
+// errStopIteration ends a callback-driven walk when the range loop breaks.
+var errStopIteration = errors.New("stop iteration")
+
+// Pages iterates the items of every page of a collection, starting at
+// pageURL and fetching the next page only once the current one has been
+// consumed. A failed request or decode is yielded as the final error.
+//
+//	for item, err := range httpclientutils.Pages[Repo](url, httpclientutils.PageConfig{}) {
+func Pages[T any](pageURL string, config PageConfig, opts ...Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if config.PageSizeParam != "" && config.PageSize > 0 {
+			if u, err := url.Parse(pageURL); err == nil && !u.Query().Has(config.PageSizeParam) {
+				pageURL = withQueryParam(u, config.PageSizeParam, fmt.Sprint(config.PageSize))
+			}
+		}
+		for pageURL != "" {
+			items, next, err := fetchPage(pageURL, config, opts)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, data := range items {
+				var item T
+				if err := json.Unmarshal(data, &item); err != nil {
+					yield(zero, fmt.Errorf("failed to resolve response: %w", err))
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+			pageURL = next
+		}
+	}
+}
+
+func fetchPage(pageURL string, config PageConfig, opts []Option) ([]json.RawMessage, string, error) {
+	statusCode, header, body, err := MakeHTTPRequest(append(append([]Option{}, opts...), WithURL(pageURL), WithMethod(http.MethodGet))...)
+	if err != nil {
+		return nil, "", err
+	}
+	if statusCode >= http.StatusBadRequest {
+		return nil, "", fmt.Errorf("failed to fetch page: status %d: %s", statusCode, body)
+	}
+
+	doc, err := decodeJSONDocument(body)
+	if err != nil {
+		return nil, "", err
+	}
+	value, err := lookupJSONPath(doc, config.Items)
+	if err != nil {
+		return nil, "", err
+	}
+	values, ok := value.([]interface{})
+	if !ok && value != nil {
+		return nil, "", fmt.Errorf("failed to resolve response: items at %q are not an array", config.Items)
+	}
+	items := make([]json.RawMessage, len(values))
+	for i, value := range values {
+		if items[i], err = json.Marshal(value); err != nil {
+			return nil, "", fmt.Errorf("failed to resolve response: %w", err)
+		}
+	}
+
+	if config.HasMore != "" {
+		if more, err := lookupJSONPath(doc, config.HasMore); err != nil || more != true {
+			return items, "", nil
+		}
+	}
+	next, err := nextPage(pageURL, header, doc, values, config)
+	if err != nil {
+		return nil, "", err
+	}
+	if next != "" {
+		if base, err := url.Parse(pageURL); err == nil {
+			if ref, err := base.Parse(next); err == nil {
+				next = ref.String()
+			}
+		}
+	}
+	return items, next, nil
+}
+
+// nextPage returns the URL of the page after pageURL, or "" after the
+// last page.
+func nextPage(pageURL string, header http.Header, doc interface{}, values []interface{}, config PageConfig) (string, error) {
+	switch {
+	case config.Next != "":
+		if value, err := lookupJSONPath(doc, config.Next); err == nil && value != nil {
+			return fmt.Sprint(value), nil
+		}
+		return "", nil
+	case config.CursorParam != "":
+		if len(values) == 0 {
+			return "", nil
+		}
+		cursor, err := lookupJSONPath(values[len(values)-1], config.CursorField)
+		if err != nil || cursor == nil {
+			return "", fmt.Errorf("failed to resolve response: no cursor at %q in last item", config.CursorField)
+		}
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return "", err
+		}
+		return withQueryParam(u, config.CursorParam, fmt.Sprint(cursor)), nil
+	case config.OffsetParam != "":
+		if len(values) == 0 || len(values) < config.PageSize {
+			return "", nil
+		}
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return "", err
+		}
+		offset, _ := strconv.Atoi(u.Query().Get(config.OffsetParam))
+		return withQueryParam(u, config.OffsetParam, strconv.Itoa(offset+len(values))), nil
+	default:
+		return linkRelation(header.Get("Link"), "next"), nil
+	}
+}
+
+// withQueryParam returns u with the query parameter name set to value.
+func withQueryParam(u *url.URL, name, value string) string {
+	query := u.Query()
+	query.Set(name, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// linkRelation returns the target of the first link with relation rel in
+// a Link header value.
+func linkRelation(header, rel string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, _ := strings.Cut(link, ";")
+		target = strings.TrimSpace(target)
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if !strings.EqualFold(name, "rel") {
+				continue
+			}
+			for _, r := range strings.Fields(strings.Trim(value, `"`)) {
+				if strings.EqualFold(r, rel) {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ODataItems iterates the values of every page of an OData collection,
+// following @odata.nextLink.
+func ODataItems[T any](pageURL string, opts ...Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		err := ODataPages(pageURL, func(values []json.RawMessage) error {
+			for _, data := range values {
+				var item T
+				if err := json.Unmarshal(data, &item); err != nil {
+					return fmt.Errorf("failed to resolve response: %w", err)
+				}
+				if !yield(item, nil) {
+					return errStopIteration
+				}
+			}
+			return nil
+		}, opts...)
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield(zero, err)
+		}
+	}
+}
+
+// NDJSON streams a newline-delimited JSON response, yielding each value as
+// it arrives. Breaking out of the loop cancels the request. A status of
+// 400 or above is yielded as an error without decoding the body.
+func NDJSON[T any](opts ...Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		ctx, cancel := context.WithCancel(optionsContext(opts))
+		defer cancel()
+
+		reader, writer := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			_, _, _, err := MakeHTTPRequest(append(append([]Option{}, opts...),
+				WithContext(ctx), WithResolveToWriter(&ndjsonWriter{writer}))...)
+			writer.CloseWithError(err)
+			done <- err
+		}()
+		defer func() {
+			reader.CloseWithError(io.ErrClosedPipe)
+			<-done
+		}()
+
+		decoder := json.NewDecoder(reader)
+		for {
+			var item T
+			err := decoder.Decode(&item)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				var requestErr *RequestError
+				if !errors.As(err, &requestErr) {
+					err = fmt.Errorf("failed to resolve response: %w", err)
+				}
+				yield(zero, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ndjsonWriter rejects error responses before their body is streamed.
+type ndjsonWriter struct {
+	io.Writer
+}
+
+func (w *ndjsonWriter) writeStatus(statusCode int) error {
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to stream response: status %d", statusCode)
+	}
+	return nil
+}
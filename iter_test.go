@@ -0,0 +1,159 @@
+package httpclientutils_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type iterItem struct {
+	ID int `json:"id"`
+}
+
+func TestPages_LinkHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `</items?page=2>; rel="next", </items?page=2>; rel="last"`)
+			w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "2":
+			w.Write([]byte(`[{"id":3}]`))
+		}
+	}))
+	defer ts.Close()
+
+	var ids []int
+	for item, err := range httpclientutils.Pages[iterItem](ts.URL+"/items", httpclientutils.PageConfig{}) {
+		assert.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestPages_BodyCursorAndBreak(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"data":[{"id":%d}],"links":{"next":"?page=%d"}}`, requests, requests+1)
+	}))
+	defer ts.Close()
+
+	var ids []int
+	for item, err := range httpclientutils.Pages[iterItem](ts.URL, httpclientutils.PageConfig{Items: "data", Next: "links.next"}) {
+		assert.NoError(t, err)
+		ids = append(ids, item.ID)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	assert.Equal(t, 3, requests)
+}
+
+func TestPages_StripePreset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		switch r.URL.Query().Get("starting_after") {
+		case "":
+			w.Write([]byte(`{"data":[{"id":1},{"id":2}],"has_more":true}`))
+		case "2":
+			w.Write([]byte(`{"data":[{"id":3}],"has_more":false}`))
+		}
+	}))
+	defer ts.Close()
+
+	config, err := httpclientutils.PagePreset(httpclientutils.PresetStripe, 2)
+	assert.NoError(t, err)
+	var ids []int
+	for item, err := range httpclientutils.Pages[iterItem](ts.URL+"/v1/customers", config) {
+		assert.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestPages_OffsetPreset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("offset") {
+		case "":
+			w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "2":
+			w.Write([]byte(`[{"id":3}]`))
+		default:
+			t.Errorf("unexpected offset %q", r.URL.Query().Get("offset"))
+		}
+	}))
+	defer ts.Close()
+
+	config, err := httpclientutils.PagePreset("offset", 2)
+	assert.NoError(t, err)
+	var ids []int
+	for item, err := range httpclientutils.Pages[iterItem](ts.URL, config) {
+		assert.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	_, err = httpclientutils.PagePreset("graphql", 10)
+	assert.ErrorIs(t, err, httpclientutils.ErrUnknownPagePreset)
+}
+
+func TestODataItems(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("$skiptoken") == "" {
+			fmt.Fprintf(w, `{"value":[{"id":1}],"@odata.nextLink":"%s/?$skiptoken=x"}`, ts.URL)
+			return
+		}
+		w.Write([]byte(`{"value":[{"id":2}]}`))
+	}))
+	defer ts.Close()
+
+	var ids []int
+	for item, err := range httpclientutils.ODataItems[iterItem](ts.URL) {
+		assert.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int{1, 2}, ids)
+}
+
+func TestNDJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id":404}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "{\"id\":%d}\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	var ids []int
+	for item, err := range httpclientutils.NDJSON[iterItem](httpclientutils.WithURL(ts.URL)) {
+		assert.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	for item, err := range httpclientutils.NDJSON[iterItem](httpclientutils.WithURL(ts.URL)) {
+		assert.NoError(t, err)
+		assert.Equal(t, 1, item.ID)
+		break
+	}
+
+	var errs []error
+	for _, err := range httpclientutils.NDJSON[iterItem](httpclientutils.WithURL(ts.URL + "/missing")) {
+		errs = append(errs, err)
+	}
+	if assert.Len(t, errs, 1) {
+		assert.ErrorContains(t, errs[0], "status 404")
+	}
+}
@@ -0,0 +1,221 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// JSONAPIDocument is a JSON:API (application/vnd.api+json) top-level
+// document. Data holds the primary data as received: a resource object, an
+// array of them, or null.
+type JSONAPIDocument struct {
+	Data     json.RawMessage        `json:"data,omitempty"`
+	Included []JSONAPIResource      `json:"included,omitempty"`
+	Links    map[string]JSONAPILink `json:"links,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Errors   JSONAPIErrors          `json:"errors,omitempty"`
+}
+
+// JSONAPIResource is a resource object.
+type JSONAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id"`
+	Attributes    json.RawMessage                `json:"attributes,omitempty"`
+	Relationships map[string]JSONAPIRelationship `json:"relationships,omitempty"`
+	Links         map[string]JSONAPILink         `json:"links,omitempty"`
+	Meta          map[string]interface{}         `json:"meta,omitempty"`
+}
+
+// JSONAPIRelationship is a relationship object. Data is a resource
+// identifier, an array of them, or null; use Identifiers to read it.
+type JSONAPIRelationship struct {
+	Data  json.RawMessage        `json:"data,omitempty"`
+	Links map[string]JSONAPILink `json:"links,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// JSONAPIIdentifier is a resource identifier object.
+type JSONAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// JSONAPILink is a link, given either as a URL string or a link object.
+type JSONAPILink struct {
+	Href string                 `json:"href"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+func (l *JSONAPILink) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &l.Href)
+	}
+	type link JSONAPILink
+	return json.Unmarshal(data, (*link)(l))
+}
+
+// JSONAPIError is an error object.
+type JSONAPIError struct {
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	Source struct {
+		Pointer   string `json:"pointer,omitempty"`
+		Parameter string `json:"parameter,omitempty"`
+		Header    string `json:"header,omitempty"`
+	} `json:"source"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+func (e JSONAPIError) Error() string {
+	var parts []string
+	for _, part := range []string{e.Title, e.Detail} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	message := strings.Join(parts, ": ")
+	if e.Source.Pointer != "" {
+		message += " (" + e.Source.Pointer + ")"
+	}
+	if e.Status != "" {
+		return e.Status + " " + message
+	}
+	return message
+}
+
+// JSONAPIErrors is the errors member of a document. It is returned by
+// requests using WithResolveJSONAPI when the server reports errors.
+type JSONAPIErrors []JSONAPIError
+
+func (e JSONAPIErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "JSON:API errors: " + strings.Join(messages, "; ")
+}
+
+// Identifiers returns the resource identifiers of the relationship.
+func (r JSONAPIRelationship) Identifiers() ([]JSONAPIIdentifier, error) {
+	data := bytes.TrimSpace(r.Data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case data[0] == '[':
+		var ids []JSONAPIIdentifier
+		err := json.Unmarshal(data, &ids)
+		return ids, err
+	}
+	var id JSONAPIIdentifier
+	if err := json.Unmarshal(data, &id); err != nil {
+		return nil, err
+	}
+	return []JSONAPIIdentifier{id}, nil
+}
+
+// Unmarshal decodes the resource's attributes into v, together with its
+// "id" and "type", so structs can carry them in fields tagged accordingly.
+func (r JSONAPIResource) Unmarshal(v interface{}) error {
+	flat, err := r.flatten()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(flat, v)
+}
+
+func (r JSONAPIResource) flatten() ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(r.Attributes) > 0 && !bytes.Equal(bytes.TrimSpace(r.Attributes), []byte("null")) {
+		if err := json.Unmarshal(r.Attributes, &fields); err != nil {
+			return nil, err
+		}
+	}
+	fields["id"], _ = json.Marshal(r.ID)
+	fields["type"], _ = json.Marshal(r.Type)
+	return json.Marshal(fields)
+}
+
+// Resource finds a resource by type and id in the primary data or the
+// included resources.
+func (d *JSONAPIDocument) Resource(resourceType, id string) *JSONAPIResource {
+	primary, _ := d.Resources()
+	for _, list := range [][]JSONAPIResource{primary, d.Included} {
+		for i := range list {
+			if list[i].Type == resourceType && list[i].ID == id {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Resources returns the primary data as a list of resources.
+func (d *JSONAPIDocument) Resources() ([]JSONAPIResource, error) {
+	data := bytes.TrimSpace(d.Data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case data[0] == '[':
+		var resources []JSONAPIResource
+		err := json.Unmarshal(data, &resources)
+		return resources, err
+	}
+	var resource JSONAPIResource
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, err
+	}
+	return []JSONAPIResource{resource}, nil
+}
+
+// resolveJSONAPI decodes a JSON:API body into options.JSONAPIDocument and
+// unwraps the primary data into options.JSONAPITarget: a struct for a
+// single resource or a slice for a collection. Error documents are
+// returned as JSONAPIErrors.
+func resolveJSONAPI(contentType string, body []byte, options *RequestOptions) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/vnd.api+json" && mediaType != "application/json" {
+		return fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	var doc JSONAPIDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON:API document: %w", err)
+	}
+	if options.JSONAPIDocument != nil {
+		*options.JSONAPIDocument = doc
+	}
+	if len(doc.Errors) > 0 {
+		return doc.Errors
+	}
+	if options.JSONAPITarget == nil {
+		return nil
+	}
+
+	resources, err := doc.Resources()
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal JSON:API data: %w", err)
+	}
+	flat := make([]json.RawMessage, len(resources))
+	for i, resource := range resources {
+		if flat[i], err = resource.flatten(); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON:API attributes: %w", err)
+		}
+	}
+	var unwrapped interface{} = flat
+	if data := bytes.TrimSpace(doc.Data); len(data) == 0 || data[0] != '[' {
+		if len(flat) == 0 {
+			return nil // null primary data leaves the target untouched
+		}
+		unwrapped = flat[0]
+	}
+	data, err := json.Marshal(unwrapped)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, options.JSONAPITarget)
+}
@@ -0,0 +1,88 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type article struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type person struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func jsonAPIServer(body string, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestWithResolveJSONAPI_UnwrapsCollectionAndIncluded(t *testing.T) {
+	ts := jsonAPIServer(`{
+  "links": {"next": "https://example.com/articles?page[offset]=2"},
+  "data": [{
+    "type": "articles", "id": "1",
+    "attributes": {"title": "JSON:API paints my bikeshed!"},
+    "relationships": {
+      "author": {"links": {"related": {"href": "https://example.com/articles/1/author"}}, "data": {"type": "people", "id": "9"}}
+    }
+  }],
+  "included": [{"type": "people", "id": "9", "attributes": {"name": "Dan"}}]
+}`, http.StatusOK)
+	defer ts.Close()
+
+	var articles []article
+	var doc httpclientutils.JSONAPIDocument
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithResolveJSONAPI(&articles, &doc))
+	assert.NoError(t, err)
+	assert.Equal(t, []article{{ID: "1", Title: "JSON:API paints my bikeshed!"}}, articles)
+	assert.Equal(t, "https://example.com/articles?page[offset]=2", doc.Links["next"].Href)
+
+	primary, err := doc.Resources()
+	assert.NoError(t, err)
+	author := primary[0].Relationships["author"]
+	assert.Equal(t, "https://example.com/articles/1/author", author.Links["related"].Href)
+	ids, err := author.Identifiers()
+	assert.NoError(t, err)
+	assert.Equal(t, []httpclientutils.JSONAPIIdentifier{{Type: "people", ID: "9"}}, ids)
+
+	var p person
+	assert.NoError(t, doc.Resource("people", "9").Unmarshal(&p))
+	assert.Equal(t, person{ID: "9", Type: "people", Name: "Dan"}, p)
+}
+
+func TestWithResolveJSONAPI_SingleResource(t *testing.T) {
+	ts := jsonAPIServer(`{"data": {"type": "articles", "id": "2", "attributes": {"title": "Rails is Omakase"}}}`, http.StatusOK)
+	defer ts.Close()
+
+	var a article
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithResolveJSONAPI(&a, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, article{ID: "2", Title: "Rails is Omakase"}, a)
+}
+
+func TestWithResolveJSONAPI_MapsErrors(t *testing.T) {
+	ts := jsonAPIServer(`{"errors": [{"status": "422", "code": "invalid", "title": "Invalid Attribute", "detail": "First name must contain at least two characters.", "source": {"pointer": "/data/attributes/firstName"}}]}`, http.StatusUnprocessableEntity)
+	defer ts.Close()
+
+	var a article
+	status, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithResolveJSONAPI(&a, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	var apiErrs httpclientutils.JSONAPIErrors
+	if assert.ErrorAs(t, err, &apiErrs) {
+		assert.Equal(t, "invalid", apiErrs[0].Code)
+		assert.Equal(t, "/data/attributes/firstName", apiErrs[0].Source.Pointer)
+	}
+	assert.ErrorContains(t, err, "422 Invalid Attribute: First name must contain at least two characters. (/data/attributes/firstName)")
+}
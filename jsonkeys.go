@@ -0,0 +1,79 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// KeyTransform rewrites a JSON object key before a response is unmarshaled.
+type KeyTransform func(key string) string
+
+// SnakeToCamel turns "user_name" into "userName", which encoding/json
+// matches to a UserName field without a tag.
+func SnakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+	var b strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			runes := []rune(part)
+			runes[0] = unicode.ToUpper(runes[0])
+			part = string(runes)
+		}
+		b.WriteString(part)
+	}
+	if b.Len() == 0 {
+		return key
+	}
+	return b.String()
+}
+
+// CamelToSnake turns "userName" into "user_name" and "HTTPStatus" into
+// "http_status", for structs tagged with snake_case names.
+func CamelToSnake(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// transformJSONKeys rewrites every object key in data, keeping numbers
+// exactly as sent.
+func transformJSONKeys(data []byte, transform KeyTransform) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(transformKeys(value, transform))
+}
+
+func transformKeys(value interface{}, transform KeyTransform) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			renamed[transform(key)] = transformKeys(item, transform)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range value {
+			value[i] = transformKeys(item, transform)
+		}
+	}
+	return value
+}
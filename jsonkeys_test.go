@@ -0,0 +1,44 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithJSONKeys(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user_id": 9007199254740993, "display_name": "Ada", "linked_accounts": [{"account_type": "github"}]}`))
+	}))
+	defer ts.Close()
+
+	var user struct {
+		UserID         int64
+		DisplayName    string
+		LinkedAccounts []struct{ AccountType string }
+	}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&user),
+		httpclientutils.WithJSONKeys(httpclientutils.SnakeToCamel),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), user.UserID)
+	assert.Equal(t, "Ada", user.DisplayName)
+	if assert.Len(t, user.LinkedAccounts, 1) {
+		assert.Equal(t, "github", user.LinkedAccounts[0].AccountType)
+	}
+}
+
+func TestKeyTransforms(t *testing.T) {
+	for in, want := range map[string]string{"user_name": "userName", "_id": "id", "already": "already"} {
+		assert.Equal(t, want, httpclientutils.SnakeToCamel(in), in)
+	}
+	for in, want := range map[string]string{"userName": "user_name", "HTTPStatus": "http_status", "userID": "user_id", "v2Token": "v2_token"} {
+		assert.Equal(t, want, httpclientutils.CamelToSnake(in), in)
+	}
+}
@@ -0,0 +1,93 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// NumberDecoder converts a JSON number, kept as its exact literal, into the
+// value stored where the response target has no concrete type, such as
+// interface{} fields and map[string]interface{} values. A decimal type is
+// plugged in with e.g.
+//
+//	func(n json.Number) (interface{}, error) { return decimal.NewFromString(n.String()) }
+type NumberDecoder func(number json.Number) (interface{}, error)
+
+// NumberAsString stores JSON numbers as their literal strings.
+func NumberAsString(number json.Number) (interface{}, error) { return number.String(), nil }
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// decodeJSON unmarshals data into target, passing numbers in untyped
+// slots through numbers when it is set instead of decoding them as
+// float64.
+func decodeJSON(data []byte, target interface{}, numbers NumberDecoder) error {
+	if numbers == nil {
+		return json.Unmarshal(data, target)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(target); err != nil {
+		return err
+	}
+	return convertNumbers(reflect.ValueOf(target), numbers)
+}
+
+// convertNumbers replaces the json.Number values the decoder stored in
+// interface{} slots under v.
+func convertNumbers(v reflect.Value, numbers NumberDecoder) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if v.Kind() == reflect.Interface && elem.Type() == jsonNumberType {
+			if !v.CanSet() {
+				return nil
+			}
+			converted, err := numbers(elem.Interface().(json.Number))
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(&converted).Elem())
+			return nil
+		}
+		return convertNumbers(elem, numbers)
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if value.Kind() != reflect.Interface || value.IsNil() {
+				continue
+			}
+			if value.Elem().Type() != jsonNumberType {
+				if err := convertNumbers(value.Elem(), numbers); err != nil {
+					return err
+				}
+				continue
+			}
+			converted, err := numbers(value.Elem().Interface().(json.Number))
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(&converted).Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := convertNumbers(v.Index(i), numbers); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				if err := convertNumbers(v.Field(i), numbers); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
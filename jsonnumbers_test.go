@@ -0,0 +1,51 @@
+package httpclientutils_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithJSONNumbers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"balance": 12345678901234567.89, "history": [0.1, {"fee": 0.30000000000000004}], "count": 2}`))
+	}))
+	defer ts.Close()
+
+	var account map[string]interface{}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&account),
+		httpclientutils.WithJSONNumbers(httpclientutils.NumberAsString),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901234567.89", account["balance"])
+	assert.Equal(t, []interface{}{"0.1", map[string]interface{}{"fee": "0.30000000000000004"}}, account["history"])
+
+	// A decimal type plugs in through NumberDecoder; big.Rat stands in for
+	// one here.
+	var typed struct {
+		Balance interface{}
+		Count   int
+	}
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&typed),
+		httpclientutils.WithJSONNumbers(func(n json.Number) (interface{}, error) {
+			r, _ := new(big.Rat).SetString(n.String())
+			return r, nil
+		}),
+	)
+	assert.NoError(t, err)
+	want, _ := new(big.Rat).SetString("12345678901234567.89")
+	if balance, ok := typed.Balance.(*big.Rat); assert.True(t, ok) {
+		assert.Zero(t, want.Cmp(balance))
+	}
+	assert.Equal(t, 2, typed.Count)
+}
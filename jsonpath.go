@@ -0,0 +1,90 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPathNotFound is returned when a JSON path does not match a value.
+var ErrPathNotFound = errors.New("json path not found")
+
+// decodeJSONDocument decodes body keeping numbers as json.Number, so large
+// IDs survive extraction unchanged.
+func decodeJSONDocument(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+	return doc, nil
+}
+
+// lookupJSONPath evaluates a simple JSONPath such as "$.data.items[0].id"
+// or "data['user-id']" against a decoded document. The leading "$" is
+// optional and negative indexes count from the end.
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	rest := strings.TrimSpace(path)
+	if strings.HasPrefix(rest, "$") {
+		rest = rest[1:]
+	} else if rest != "" && rest[0] != '[' {
+		// A relative path: "data.items" is read as "$.data.items".
+		rest = "." + rest
+	}
+	value := doc
+	for rest != "" {
+		var key string
+		index, isIndex := 0, false
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key, rest = rest[:end], rest[end:]
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			end := strings.Index(rest[2:], string(rest[1])+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path %q: unterminated key", path)
+			}
+			key, rest = rest[2:2+end], rest[2+end+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path %q: unterminated index", path)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid json path %q: bad index %q", path, rest[1:end])
+			}
+			index, isIndex, rest = n, true, rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid json path %q", path)
+		}
+
+		if isIndex {
+			items, ok := value.([]interface{})
+			if index < 0 {
+				index += len(items)
+			}
+			if !ok || index < 0 || index >= len(items) {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+			}
+			value = items[index]
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+		}
+		if value, ok = object[key]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+		}
+	}
+	return value, nil
+}
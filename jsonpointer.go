@@ -0,0 +1,74 @@
+package httpclientutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPointerTargets decodes parts of a response into separate targets.
+// Keys are JSON pointers (RFC 6901), so an envelope such as
+// {"data": [...], "meta": {...}} can be resolved with
+//
+//	WithResolveResponse(JSONPointerTargets{"/data": &items, "/meta": &meta})
+//
+// without a wrapper struct. The pointer "" selects the whole document.
+type JSONPointerTargets map[string]interface{}
+
+func (t JSONPointerTargets) unmarshal(data []byte, numbers NumberDecoder) error {
+	for pointer, target := range t {
+		value, err := jsonPointerValue(data, pointer)
+		if err != nil {
+			return err
+		}
+		if err := decodeJSON(value, target, numbers); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", pointer, err)
+		}
+	}
+	return nil
+}
+
+// jsonPointerToken unescapes a reference token of a JSON pointer.
+var jsonPointerToken = strings.NewReplacer("~1", "/", "~0", "~")
+
+// jsonPointerValue returns the raw value pointer refers to in data.
+func jsonPointerValue(data []byte, pointer string) (json.RawMessage, error) {
+	if pointer == "" {
+		return data, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+	value := json.RawMessage(data)
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = jsonPointerToken.Replace(token)
+		var object map[string]json.RawMessage
+		if json.Unmarshal(value, &object) == nil && object != nil {
+			var ok bool
+			if value, ok = object[token]; !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, pointer)
+			}
+			continue
+		}
+		var array []json.RawMessage
+		if err := json.Unmarshal(value, &array); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, pointer)
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(array) {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, pointer)
+		}
+		value = array[index]
+	}
+	return value, nil
+}
+
+// unmarshalTarget decodes JSON data into target, which may be a
+// JSONPointerTargets.
+func unmarshalTarget(data []byte, target interface{}, numbers NumberDecoder) error {
+	if targets, ok := target.(JSONPointerTargets); ok {
+		return targets.unmarshal(data, numbers)
+	}
+	return decodeJSON(data, target, numbers)
+}
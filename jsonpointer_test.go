@@ -0,0 +1,45 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPointerTargets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"id":1},{"id":2}],"meta":{"total":2,"a/b":"slash"}}`))
+	}))
+	defer ts.Close()
+
+	var (
+		items []struct{ ID int }
+		meta  struct{ Total int }
+		last  struct{ ID int }
+		slash string
+	)
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(httpclientutils.JSONPointerTargets{
+			"/data":      &items,
+			"/meta":      &meta,
+			"/data/1":    &last,
+			"/meta/a~1b": &slash,
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, 2, meta.Total)
+	assert.Equal(t, 2, last.ID)
+	assert.Equal(t, "slash", slash)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(httpclientutils.JSONPointerTargets{"/links": &meta}),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrPathNotFound)
+}
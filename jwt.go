@@ -0,0 +1,219 @@
+package httpclientutils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultJWTTTL = 5 * time.Minute
+
+// JWTClaims are the claims of a minted assertion. Extra claims are merged
+// in verbatim.
+type JWTClaims struct {
+	Issuer   string
+	Subject  string
+	Audience string
+	Scope    string
+	KeyID    string        // sets the kid header
+	TTL      time.Duration // defaults to five minutes
+	Extra    map[string]interface{}
+}
+
+// SignJWT mints a compact JWT signed with key. The algorithm follows the key
+// type: RS256 for RSA, ES256/ES384 for ECDSA P-256/P-384, EdDSA for Ed25519.
+func SignJWT(key crypto.Signer, claims JWTClaims, now time.Time) (string, error) {
+	alg, err := jwtAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if claims.KeyID != "" {
+		header["kid"] = claims.KeyID
+	}
+	ttl := claims.TTL
+	if ttl <= 0 {
+		ttl = defaultJWTTTL
+	}
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	payload := map[string]interface{}{}
+	for name, value := range claims.Extra {
+		payload[name] = value
+	}
+	for name, value := range map[string]string{"iss": claims.Issuer, "sub": claims.Subject, "aud": claims.Audience, "scope": claims.Scope} {
+		if value != "" {
+			payload[name] = value
+		}
+	}
+	payload["iat"] = now.Unix()
+	payload["exp"] = now.Add(ttl).Unix()
+	payload["jti"] = jti
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWT claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	signature, err := jwtSign(key, alg, []byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func jwtAlgorithm(key crypto.Signer) (string, error) {
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return "ES256", nil
+		case 384:
+			return "ES384", nil
+		}
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	}
+	return "", errors.New("unsupported JWT signing key type")
+}
+
+func jwtSign(key crypto.Signer, alg string, input []byte) ([]byte, error) {
+	switch alg {
+	case "EdDSA":
+		return key.Sign(rand.Reader, input, crypto.Hash(0))
+	case "ES384":
+		digest := sha512.Sum384(input)
+		return ecdsaJOSESignature(key, digest[:], crypto.SHA384, 48)
+	case "ES256":
+		digest := sha256.Sum256(input)
+		return ecdsaJOSESignature(key, digest[:], crypto.SHA256, 32)
+	default:
+		digest := sha256.Sum256(input)
+		return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+}
+
+// ecdsaJOSESignature converts the ASN.1 signature from crypto.Signer into
+// the fixed-width r||s form JWS requires.
+func ecdsaJOSESignature(key crypto.Signer, digest []byte, hash crypto.Hash, size int) ([]byte, error) {
+	der, err := key.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return nil, err
+	}
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2*size)
+	sig.R.FillBytes(out[:size])
+	sig.S.FillBytes(out[size:])
+	return out, nil
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded PKCS #8, PKCS #1 or SEC 1 private
+// key, such as the private_key of a Google service-account JSON file.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported private key type")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key")
+}
+
+// JWTAssertionOptions holds the key and claims of WithJWTAssertionAuth.
+type JWTAssertionOptions struct {
+	Key    crypto.Signer
+	Claims JWTClaims
+}
+
+func applyJWTAssertion(req *http.Request, options *RequestOptions) error {
+	if options.JWTAssertion == nil {
+		return nil
+	}
+	token, err := SignJWT(options.JWTAssertion.Key, options.JWTAssertion.Claims, time.Now())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// JWTBearerTokenSource exchanges a signed assertion for an access token
+// (RFC 7523), caching the token until shortly before it expires. With
+// ClientAssertion set, the JWT authenticates the client (private_key_jwt)
+// in a client_credentials grant instead of being the grant itself. It is a
+// SecretProvider, so it can be used with WithBearerFromSecret.
+type JWTBearerTokenSource struct {
+	TokenURL        string
+	Key             crypto.Signer
+	Claims          JWTClaims
+	ClientAssertion bool
+
+	cache tokenCache
+}
+
+func (s *JWTBearerTokenSource) Secret(ctx context.Context) (string, error) {
+	return s.cache.get(ctx, func(ctx context.Context) (string, time.Duration, error) {
+		assertion, err := SignJWT(s.Key, s.Claims, time.Now())
+		if err != nil {
+			return "", 0, err
+		}
+		form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {assertion}}
+		if s.ClientAssertion {
+			form = url.Values{
+				"grant_type":            {"client_credentials"},
+				"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+				"client_assertion":      {assertion},
+			}
+			if s.Claims.Scope != "" {
+				form.Set("scope", s.Claims.Scope)
+			}
+		}
+		token, err := requestToken(ctx, s.TokenURL, form)
+		if err != nil {
+			return "", 0, err
+		}
+		if token.Expiry.IsZero() {
+			return token.AccessToken, time.Hour, nil
+		}
+		return token.AccessToken, time.Until(token.Expiry), nil
+	})
+}
@@ -0,0 +1,96 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeJWT(t *testing.T, token string) (map[string]interface{}, map[string]interface{}, []byte, []byte) {
+	parts := strings.Split(token, ".")
+	assert.Len(t, parts, 3)
+	var header, claims map[string]interface{}
+	h, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	c, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	json.Unmarshal(h, &header)
+	json.Unmarshal(c, &claims)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return header, claims, sig, digest[:]
+}
+
+func TestSignJWT_ES256(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now := time.Unix(1700000000, 0)
+	token, err := httpclientutils.SignJWT(key, httpclientutils.JWTClaims{Issuer: "team", KeyID: "ABC123", Audience: "appstoreconnect-v1"}, now)
+	assert.NoError(t, err)
+
+	header, claims, sig, digest := decodeJWT(t, token)
+	assert.Equal(t, "ES256", header["alg"])
+	assert.Equal(t, "ABC123", header["kid"])
+	assert.Equal(t, "team", claims["iss"])
+	assert.Equal(t, float64(now.Add(5*time.Minute).Unix()), claims["exp"])
+	assert.Len(t, sig, 64)
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	assert.True(t, ecdsa.Verify(&key.PublicKey, digest, r, s))
+}
+
+func TestWithJWTAssertionAuth_SignsEachRequest(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		header, claims, sig, digest := decodeJWT(t, token)
+		assert.Equal(t, "RS256", header["alg"])
+		assert.Equal(t, "svc@example.iam", claims["sub"])
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest, sig))
+	}))
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithJWTAssertionAuth(key, httpclientutils.JWTClaims{Subject: "svc@example.iam"}),
+	)
+	assert.NoError(t, err)
+}
+
+func TestJWTBearerTokenSource_ExchangesAssertion(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	signer, err := httpclientutils.ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+		_, claims, _, _ := decodeJWT(t, r.Form.Get("assertion"))
+		assert.Equal(t, "https://www.googleapis.com/auth/cloud-platform", claims["scope"])
+		w.Write([]byte(`{"access_token":"exchanged","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	source := &httpclientutils.JWTBearerTokenSource{
+		TokenURL: ts.URL,
+		Key:      signer,
+		Claims:   httpclientutils.JWTClaims{Issuer: "svc@example.iam", Audience: ts.URL, Scope: "https://www.googleapis.com/auth/cloud-platform"},
+	}
+	token, err := source.Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "exchanged", token)
+}
@@ -0,0 +1,100 @@
+package httpclientutils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ErrNotInCluster is returned by requests using WithKubernetesInCluster
+// when the process is not running in a Kubernetes pod.
+var ErrNotInCluster = errors.New("kubernetes: not running in a cluster")
+
+// KubernetesInCluster locates the API server and the pod's service account
+// credentials. Empty fields take the standard in-cluster defaults.
+type KubernetesInCluster struct {
+	Host      string // API server base URL; defaults to KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT
+	TokenFile string // defaults to /var/run/secrets/kubernetes.io/serviceaccount/token
+	CAFile    string // defaults to /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+}
+
+func (k KubernetesInCluster) host() (string, error) {
+	if k.Host != "" {
+		return strings.TrimSuffix(k.Host, "/"), nil
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return "", ErrNotInCluster
+	}
+	return "https://" + net.JoinHostPort(host, port), nil
+}
+
+// apply points options at the API server: relative URLs are resolved
+// against it, and the CA and the service account token are used unless TLS
+// or bearer auth were configured explicitly.
+func (k KubernetesInCluster) apply(options *RequestOptions) error {
+	host, err := k.host()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(options.URL, "/") {
+		options.URL = host + options.URL
+	}
+	if options.TLSConfig == nil {
+		config, err := kubernetesTLSConfig(orDefault(k.CAFile, kubernetesServiceAccountDir+"/ca.crt"))
+		if err != nil {
+			return err
+		}
+		options.TLSConfig = config
+	}
+	if options.BearerSecret == nil {
+		// Projected tokens rotate, so the file is read on every request.
+		options.BearerSecret = FileSecret(orDefault(k.TokenFile, kubernetesServiceAccountDir+"/token"))
+	}
+	return nil
+}
+
+// kubernetesCAs caches TLS configs by CA file, so requests share a config
+// (and its pooled transport) until the file changes on disk.
+var kubernetesCAs = struct {
+	sync.Mutex
+	configs map[string]kubernetesCA
+}{configs: make(map[string]kubernetesCA)}
+
+type kubernetesCA struct {
+	config   *tls.Config
+	loadedAt time.Time
+}
+
+func kubernetesTLSConfig(caFile string) (*tls.Config, error) {
+	kubernetesCAs.Lock()
+	defer kubernetesCAs.Unlock()
+
+	modified, err := latestModTime(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat kubernetes CA: %w", err)
+	}
+	if cached, ok := kubernetesCAs.configs[caFile]; ok && !modified.After(cached.loadedAt) {
+		return cached.config, nil
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubernetes CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("kubernetes CA contains no certificates")
+	}
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	kubernetesCAs.configs[caFile] = kubernetesCA{config: config, loadedAt: modified}
+	return config, nil
+}
@@ -0,0 +1,45 @@
+package httpclientutils_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithKubernetes_UsesServiceAccount(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/default/pods", r.URL.Path)
+		assert.Equal(t, "Bearer sa-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"kind":"PodList"}`))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	caFile, tokenFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "token")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("sa-token\n"), 0o600))
+
+	status, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("/api/v1/namespaces/default/pods"),
+		httpclientutils.WithKubernetes(httpclientutils.KubernetesInCluster{Host: ts.URL, TokenFile: tokenFile, CAFile: caFile}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"kind":"PodList"}`, string(body))
+}
+
+func TestWithKubernetesInCluster_OutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("/api"),
+		httpclientutils.WithKubernetesInCluster(),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrNotInCluster)
+}
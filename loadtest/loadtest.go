@@ -0,0 +1,172 @@
+// Package loadtest fires a request built from httpclientutils options at a
+// target rate and concurrency, for capacity checks that use exactly the
+// client configuration production uses.
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+)
+
+// Config describes a load test run.
+type Config struct {
+	// Options build the request sent on every iteration.
+	Options []httpclientutils.Option
+	// Rate is the target requests per second; zero sends as fast as the
+	// workers allow.
+	Rate float64
+	// Concurrency is the number of workers; it defaults to one.
+	Concurrency int
+	// Duration bounds the run; the run also ends when the context is done.
+	Duration time.Duration
+	// Success decides whether a result counts as successful; by default
+	// requests without an error and with a status below 400 do.
+	Success func(statusCode int, err error) bool
+}
+
+// Latencies summarizes request latencies.
+type Latencies struct {
+	Min, Mean, P50, P90, P95, P99, Max time.Duration
+}
+
+// Report is the outcome of a load test run.
+type Report struct {
+	Requests    int
+	Errors      int
+	Dropped     int // ticks skipped because every worker was busy
+	StatusCodes map[int]int
+	Duration    time.Duration
+	Throughput  float64 // completed requests per second
+	ErrorRate   float64 // Errors / Requests
+	Latency     Latencies
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("%d requests in %s (%.1f/s), %.2f%% errors, %d dropped; latency p50 %s p90 %s p99 %s max %s",
+		r.Requests, r.Duration.Round(time.Millisecond), r.Throughput, r.ErrorRate*100, r.Dropped,
+		r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+}
+
+type sample struct {
+	statusCode int
+	ok         bool
+	latency    time.Duration
+}
+
+// Run sends requests until config.Duration has passed or ctx is done, then
+// waits for in-flight requests and reports. With a Rate, ticks arriving
+// while every worker is busy are counted as Dropped rather than queued, so
+// the report shows when the configuration cannot sustain the target.
+func Run(ctx context.Context, config Config) (*Report, error) {
+	if config.Duration <= 0 {
+		return nil, fmt.Errorf("loadtest: duration must be positive")
+	}
+	concurrency := max(config.Concurrency, 1)
+	success := config.Success
+	if success == nil {
+		success = func(statusCode int, err error) bool { return err == nil && statusCode < http.StatusBadRequest }
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Duration)
+	defer cancel()
+	opts := append(append([]httpclientutils.Option{}, config.Options...), httpclientutils.WithContext(ctx))
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		wg      sync.WaitGroup
+	)
+	work := make(chan struct{})
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range work {
+				start := time.Now()
+				statusCode, _, _, err := httpclientutils.MakeHTTPRequest(opts...)
+				latency := time.Since(start)
+				if ctx.Err() != nil {
+					// Cut short by the end of the run, not by the target.
+					return
+				}
+				mu.Lock()
+				samples = append(samples, sample{statusCode: statusCode, ok: success(statusCode, err), latency: latency})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+	dropped := dispatch(ctx, work, config.Rate)
+	close(work)
+	wg.Wait()
+	return newReport(samples, dropped, time.Since(start)), nil
+}
+
+// dispatch hands work to idle workers until ctx is done, at rate per
+// second when rate is positive, returning how many ticks found no worker.
+func dispatch(ctx context.Context, work chan<- struct{}, rate float64) int {
+	if rate <= 0 {
+		for {
+			select {
+			case work <- struct{}{}:
+			case <-ctx.Done():
+				return 0
+			}
+		}
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+	dropped := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return dropped
+		case <-ticker.C:
+			select {
+			case work <- struct{}{}:
+			default:
+				dropped++
+			}
+		}
+	}
+}
+
+func newReport(samples []sample, dropped int, elapsed time.Duration) *Report {
+	report := &Report{Requests: len(samples), Dropped: dropped, StatusCodes: make(map[int]int), Duration: elapsed}
+	if len(samples) == 0 {
+		return report
+	}
+	latencies := make([]time.Duration, len(samples))
+	var total time.Duration
+	for i, s := range samples {
+		if !s.ok {
+			report.Errors++
+		}
+		report.StatusCodes[s.statusCode]++
+		latencies[i] = s.latency
+		total += s.latency
+	}
+	slices.Sort(latencies)
+	percentile := func(p float64) time.Duration {
+		return latencies[min(int(p*float64(len(latencies))), len(latencies)-1)]
+	}
+	report.Latency = Latencies{
+		Min:  latencies[0],
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  latencies[len(latencies)-1],
+	}
+	report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	report.ErrorRate = float64(report.Errors) / float64(report.Requests)
+	return report
+}
@@ -0,0 +1,47 @@
+package loadtest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/InheritxSolution/httpclientutils/loadtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1)%4 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	report, err := loadtest.Run(context.Background(), loadtest.Config{
+		Options:     []httpclientutils.Option{httpclientutils.WithURL(ts.URL)},
+		Rate:        200,
+		Concurrency: 4,
+		Duration:    300 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Greater(t, report.Requests, 20)
+	assert.LessOrEqual(t, report.Requests, 70)
+	assert.Equal(t, report.StatusCodes[http.StatusServiceUnavailable], report.Errors)
+	assert.InDelta(t, 0.25, report.ErrorRate, 0.1)
+	assert.LessOrEqual(t, report.Latency.Min, report.Latency.P50)
+	assert.LessOrEqual(t, report.Latency.P50, report.Latency.P99)
+	assert.LessOrEqual(t, report.Latency.P99, report.Latency.Max)
+	assert.Contains(t, report.String(), "requests in")
+}
+
+func TestRun_RequiresDuration(t *testing.T) {
+	_, err := loadtest.Run(context.Background(), loadtest.Config{})
+	assert.Error(t, err)
+}
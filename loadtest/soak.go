@@ -0,0 +1,141 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ResourceSample is a snapshot of process resources taken during a soak.
+type ResourceSample struct {
+	Time       time.Time
+	Goroutines int
+	OpenFDs    int // -1 where open descriptors cannot be counted
+	HeapInuse  uint64
+}
+
+// SoakConfig describes a soak run: Load runs for its Duration while
+// resources are sampled, then growth from the baseline beyond the Max*
+// limits is reported as a leak.
+type SoakConfig struct {
+	Load           Config
+	SampleInterval time.Duration // defaults to a second
+	// Settle is how long to wait after the load before the final sample,
+	// letting connections and their goroutines wind down; defaults to a
+	// second.
+	Settle             time.Duration
+	MaxGoroutineGrowth int    // defaults to 10
+	MaxFDGrowth        int    // defaults to 10
+	MaxHeapGrowth      uint64 // bytes; defaults to 64 MiB
+}
+
+// SoakReport is the outcome of a soak run.
+type SoakReport struct {
+	Load     *Report
+	Baseline ResourceSample
+	Final    ResourceSample
+	Samples  []ResourceSample
+	Leaks    []string // one line per resource that grew beyond its limit
+}
+
+// Leaked reports whether any resource grew beyond its limit.
+func (r *SoakReport) Leaked() bool { return len(r.Leaks) > 0 }
+
+// Soak runs config.Load while sampling goroutines, open file descriptors
+// and heap, flagging connection or goroutine leaks in the client setup.
+func Soak(ctx context.Context, config SoakConfig) (*SoakReport, error) {
+	interval := config.SampleInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	settle := config.Settle
+	if settle <= 0 {
+		settle = time.Second
+	}
+
+	report := &SoakReport{Baseline: sampleResources()}
+	var mu sync.Mutex
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				report.Samples = append(report.Samples, sampleResources())
+				mu.Unlock()
+			}
+		}
+	}()
+
+	load, err := Run(ctx, config.Load)
+	close(stop)
+	<-sampled
+	if err != nil {
+		return nil, err
+	}
+	report.Load = load
+
+	select {
+	case <-time.After(settle):
+	case <-ctx.Done():
+	}
+	report.Final = sampleResources()
+	report.Leaks = leaks(report.Baseline, report.Final, config)
+	return report, nil
+}
+
+func leaks(baseline, final ResourceSample, config SoakConfig) []string {
+	maxGoroutines := config.MaxGoroutineGrowth
+	if maxGoroutines <= 0 {
+		maxGoroutines = 10
+	}
+	maxFDs := config.MaxFDGrowth
+	if maxFDs <= 0 {
+		maxFDs = 10
+	}
+	maxHeap := config.MaxHeapGrowth
+	if maxHeap == 0 {
+		maxHeap = 64 << 20
+	}
+
+	var found []string
+	if growth := final.Goroutines - baseline.Goroutines; growth > maxGoroutines {
+		found = append(found, fmt.Sprintf("goroutines grew by %d (%d to %d)", growth, baseline.Goroutines, final.Goroutines))
+	}
+	if baseline.OpenFDs >= 0 && final.OpenFDs >= 0 {
+		if growth := final.OpenFDs - baseline.OpenFDs; growth > maxFDs {
+			found = append(found, fmt.Sprintf("open file descriptors grew by %d (%d to %d)", growth, baseline.OpenFDs, final.OpenFDs))
+		}
+	}
+	if final.HeapInuse > baseline.HeapInuse && final.HeapInuse-baseline.HeapInuse > maxHeap {
+		found = append(found, fmt.Sprintf("heap in use grew by %d bytes (%d to %d)", final.HeapInuse-baseline.HeapInuse, baseline.HeapInuse, final.HeapInuse))
+	}
+	return found
+}
+
+func sampleResources() ResourceSample {
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return ResourceSample{Time: time.Now(), Goroutines: runtime.NumGoroutine(), OpenFDs: openFDs(), HeapInuse: mem.HeapInuse}
+}
+
+// openFDs counts the process's open file descriptors where /proc or
+// /dev/fd lists them, and returns -1 elsewhere.
+func openFDs() int {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			return len(entries)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,60 @@
+package loadtest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/InheritxSolution/httpclientutils/loadtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoak(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	report, err := loadtest.Soak(context.Background(), loadtest.SoakConfig{
+		Load: loadtest.Config{
+			Options:     []httpclientutils.Option{httpclientutils.WithURL(ts.URL)},
+			Concurrency: 4,
+			Duration:    300 * time.Millisecond,
+		},
+		SampleInterval: 50 * time.Millisecond,
+		Settle:         100 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Greater(t, report.Load.Requests, 0)
+	assert.NotEmpty(t, report.Samples)
+	assert.False(t, report.Leaked(), "%v", report.Leaks)
+}
+
+func TestSoak_FlagsGoroutineLeak(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+	report, err := loadtest.Soak(context.Background(), loadtest.SoakConfig{
+		Load: loadtest.Config{
+			Options: []httpclientutils.Option{
+				httpclientutils.WithURL(ts.URL),
+				// A hook that strands a goroutine per request.
+				func(opts *httpclientutils.RequestOptions) { go func() { <-block }() },
+			},
+			Rate:     200,
+			Duration: 200 * time.Millisecond,
+		},
+		SampleInterval: 50 * time.Millisecond,
+		Settle:         50 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, report.Leaked())
+	assert.Contains(t, report.Leaks[0], "goroutines grew")
+}
@@ -0,0 +1,86 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// LongPollConfig configures LongPoll.
+type LongPollConfig struct {
+	InitialBackoff time.Duration // wait after the first failure; defaults to one second, doubling per failure
+	MaxBackoff     time.Duration // defaults to one minute
+	Buffer         int           // capacity of the payload channel
+
+	// Next returns options for the following poll given the last payload,
+	// e.g. an updated offset query parameter; they are applied after the
+	// options passed to LongPoll.
+	Next func(payload []byte) []Option
+
+	// OnError is called for each failed poll before backing off.
+	OnError func(err error)
+}
+
+// LongPoll repeatedly sends the request, delivering each non-empty 2xx body
+// to the returned channel. Empty cycles, where the server answers 204 or
+// the hold outlasts WithTimeout, are polled again at once; other failures
+// back off exponentially. The channel is closed once the context set with
+// WithContext is done.
+func LongPoll(config LongPollConfig, opts ...Option) <-chan []byte {
+	payloads := make(chan []byte, config.Buffer)
+	ctx := optionsContext(opts)
+	go func() {
+		defer close(payloads)
+		var next []Option
+		failures := 0
+		for ctx.Err() == nil {
+			statusCode, _, body, err := MakeHTTPRequest(append(append([]Option{}, opts...), next...)...)
+			if ctx.Err() != nil {
+				return
+			}
+			switch {
+			case emptyPollCycle(statusCode, body, err):
+				failures = 0
+				continue
+			case err == nil && statusCode < 300:
+				failures = 0
+				select {
+				case payloads <- body:
+				case <-ctx.Done():
+					return
+				}
+				if config.Next != nil {
+					next = config.Next(body)
+				}
+				continue
+			case err == nil:
+				err = fmt.Errorf("long poll failed with status %d", statusCode)
+			}
+
+			failures++
+			if config.OnError != nil {
+				config.OnError(err)
+			}
+			timer := time.NewTimer(exponentialBackoff(config.InitialBackoff, config.MaxBackoff, failures))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	return payloads
+}
+
+// emptyPollCycle reports whether the server held the request without
+// anything to deliver.
+func emptyPollCycle(statusCode int, body []byte, err error) bool {
+	if err == nil {
+		return statusCode == http.StatusNoContent || statusCode < 300 && len(body) == 0
+	}
+	var netErr net.Error
+	return statusCode == http.StatusRequestTimeout || errors.As(err, &netErr) && netErr.Timeout()
+}
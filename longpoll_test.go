@@ -0,0 +1,56 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongPoll_DeliversAcrossEmptyCyclesAndErrors(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusNoContent)
+		case 2:
+			time.Sleep(50 * time.Millisecond) // outlasts the client timeout
+		case 3:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("update@" + r.URL.Query().Get("offset")))
+		}
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var errs int32
+	offset := 0
+	updates := httpclientutils.LongPoll(httpclientutils.LongPollConfig{
+		InitialBackoff: time.Millisecond,
+		Next: func(payload []byte) []httpclientutils.Option {
+			offset++
+			return []httpclientutils.Option{httpclientutils.WithURL(ts.URL + "?offset=" + strconv.Itoa(offset))}
+		},
+		OnError: func(err error) { atomic.AddInt32(&errs, 1) },
+	},
+		httpclientutils.WithURL(ts.URL+"?offset=0"),
+		httpclientutils.WithTimeout(20*time.Millisecond),
+		httpclientutils.WithContext(ctx),
+	)
+
+	assert.Equal(t, "update@0", string(<-updates))
+	assert.Equal(t, "update@1", string(<-updates))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&errs))
+
+	cancel()
+	for range updates {
+	}
+}
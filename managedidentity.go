@@ -0,0 +1,138 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcpMetadataEndpoint = "http://metadata.google.internal"
+	azureIMDSEndpoint   = "http://169.254.169.254"
+
+	metadataTimeout = 5 * time.Second
+	// tokenRefreshMargin refreshes tokens this long before they expire.
+	tokenRefreshMargin = time.Minute
+)
+
+// tokenCache holds an access token until shortly before it expires.
+type tokenCache struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (c *tokenCache) get(ctx context.Context, fetch func(ctx context.Context) (string, time.Duration, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Until(c.expiresAt) > tokenRefreshMargin {
+		return c.token, nil
+	}
+	token, expiresIn, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expiresAt = token, time.Now().Add(expiresIn)
+	return token, nil
+}
+
+// GCPMetadataTokenSource obtains OAuth2 access tokens for the attached
+// service account from the GCE/GKE metadata server. It is a SecretProvider,
+// so it can be used with WithBearerFromSecret.
+type GCPMetadataTokenSource struct {
+	ServiceAccount string   // defaults to "default"
+	Scopes         []string // defaults to the account's scopes
+	Endpoint       string   // defaults to http://metadata.google.internal
+
+	cache tokenCache
+}
+
+func (s *GCPMetadataTokenSource) Secret(ctx context.Context) (string, error) {
+	return s.cache.get(ctx, s.fetch)
+}
+
+func (s *GCPMetadataTokenSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	account := s.ServiceAccount
+	if account == "" {
+		account = "default"
+	}
+	target := orDefault(s.Endpoint, gcpMetadataEndpoint) + "/computeMetadata/v1/instance/service-accounts/" + url.PathEscape(account) + "/token"
+	if len(s.Scopes) > 0 {
+		target += "?scopes=" + url.QueryEscape(strings.Join(s.Scopes, ","))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := fetchMetadataJSON(ctx, target, map[string]string{"Metadata-Flavor": "Google"}, &token); err != nil {
+		return "", 0, fmt.Errorf("failed to fetch GCP metadata token: %w", err)
+	}
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
+
+// AzureIMDSTokenSource obtains access tokens for a managed identity from the
+// Azure Instance Metadata Service. It is a SecretProvider, so it can be used
+// with WithBearerFromSecret.
+type AzureIMDSTokenSource struct {
+	Resource string // e.g. https://management.azure.com/
+	ClientID string // selects a user-assigned identity; empty for system-assigned
+	Endpoint string // defaults to http://169.254.169.254
+
+	cache tokenCache
+}
+
+func (s *AzureIMDSTokenSource) Secret(ctx context.Context) (string, error) {
+	return s.cache.get(ctx, s.fetch)
+}
+
+func (s *AzureIMDSTokenSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {s.Resource}}
+	if s.ClientID != "" {
+		query.Set("client_id", s.ClientID)
+	}
+	target := orDefault(s.Endpoint, azureIMDSEndpoint) + "/metadata/identity/oauth2/token?" + query.Encode()
+
+	// IMDS encodes expires_in as a string.
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+	if err := fetchMetadataJSON(ctx, target, map[string]string{"Metadata": "true"}, &token); err != nil {
+		return "", 0, fmt.Errorf("failed to fetch Azure IMDS token: %w", err)
+	}
+	seconds, err := strconv.ParseInt(token.ExpiresIn, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse Azure IMDS token expiry: %w", err)
+	}
+	return token.AccessToken, time.Duration(seconds) * time.Second, nil
+}
+
+func fetchMetadataJSON(ctx context.Context, target string, headers map[string]string, v interface{}) error {
+	status, _, body, err := MakeHTTPRequest(
+		WithContext(ctx),
+		WithURL(target),
+		WithHeaders(headers),
+		WithTimeout(metadataTimeout),
+	)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", status, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPMetadataTokenSource_CachesToken(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		assert.Equal(t, "/computeMetadata/v1/instance/service-accounts/default/token", r.URL.Path)
+		assert.Equal(t, "https://www.googleapis.com/auth/cloud-platform", r.URL.Query().Get("scopes"))
+		w.Write([]byte(`{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer ts.Close()
+
+	source := &httpclientutils.GCPMetadataTokenSource{
+		Scopes:   []string{"https://www.googleapis.com/auth/cloud-platform"},
+		Endpoint: ts.URL,
+	}
+	for i := 0; i < 2; i++ {
+		token, err := source.Secret(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "ya29.token", token)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestAzureIMDSTokenSource_RefreshesExpiringToken(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, "https://vault.azure.net", r.URL.Query().Get("resource"))
+		assert.Equal(t, "client-1", r.URL.Query().Get("client_id"))
+		w.Write([]byte(`{"access_token":"eyJ0","expires_in":"30","token_type":"Bearer"}`))
+	}))
+	defer ts.Close()
+
+	source := &httpclientutils.AzureIMDSTokenSource{Resource: "https://vault.azure.net", ClientID: "client-1", Endpoint: ts.URL}
+	for i := 0; i < 2; i++ {
+		token, err := source.Secret(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "eyJ0", token)
+	}
+	// Tokens expiring within the refresh margin are fetched again.
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
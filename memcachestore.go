@@ -0,0 +1,116 @@
+package httpclientutils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// memcachedMaxRelativeTTL is the largest expiry memcached treats as
+// relative; larger values are interpreted as Unix timestamps.
+const memcachedMaxRelativeTTL = 30 * 24 * time.Hour
+
+// MemcachedStore is a CacheStore backed by a memcached server using the
+// text protocol. Keys are hashed to satisfy memcached's key restrictions.
+type MemcachedStore struct {
+	storeConn
+	prefix string
+}
+
+// NewMemcachedStore returns a MemcachedStore talking to addr; every key is
+// prefixed with prefix before hashing.
+func NewMemcachedStore(addr, prefix string) *MemcachedStore {
+	return &MemcachedStore{storeConn: storeConn{addr: addr}, prefix: prefix}
+}
+
+func (s *MemcachedStore) Get(key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.do(func(rw *bufio.ReadWriter) error {
+		fmt.Fprintf(rw, "get %s\r\n", s.key(key))
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+		for {
+			line, err := readLine(rw.Reader)
+			if err != nil {
+				return err
+			}
+			if line == "END" {
+				return nil
+			}
+			var name string
+			var flags, size int
+			if _, err := fmt.Sscanf(line, "VALUE %s %d %d", &name, &flags, &size); err != nil {
+				return fmt.Errorf("unexpected reply: %q", line)
+			}
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(rw, buf); err != nil {
+				return err
+			}
+			value = buf[:size]
+		}
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("memcached get failed: %w", err)
+	}
+	return value, value != nil, nil
+}
+
+func (s *MemcachedStore) Set(key string, value []byte, ttl time.Duration) error {
+	err := s.do(func(rw *bufio.ReadWriter) error {
+		fmt.Fprintf(rw, "set %s 0 %d %d\r\n", s.key(key), memcachedExpiry(ttl), len(value))
+		rw.Write(value)
+		rw.WriteString("\r\n")
+		return expectReply(rw, "STORED")
+	})
+	if err != nil {
+		return fmt.Errorf("memcached set failed: %w", err)
+	}
+	return nil
+}
+
+func (s *MemcachedStore) Delete(key string) error {
+	err := s.do(func(rw *bufio.ReadWriter) error {
+		fmt.Fprintf(rw, "delete %s\r\n", s.key(key))
+		return expectReply(rw, "DELETED", "NOT_FOUND")
+	})
+	if err != nil {
+		return fmt.Errorf("memcached delete failed: %w", err)
+	}
+	return nil
+}
+
+func (s *MemcachedStore) key(key string) string {
+	sum := sha256.Sum256([]byte(s.prefix + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func memcachedExpiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > memcachedMaxRelativeTTL {
+		return time.Now().Add(ttl).Unix()
+	}
+	return int64((ttl + time.Second - 1) / time.Second)
+}
+
+func expectReply(rw *bufio.ReadWriter, want ...string) error {
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+	for _, w := range want {
+		if line == w {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected reply: %q", strings.TrimSpace(line))
+}
@@ -0,0 +1,84 @@
+package httpclientutils_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMemcached serves get, set and delete from an in-memory map.
+func fakeMemcached(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := make(map[string][]byte)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fields := strings.Fields(line)
+					mu.Lock()
+					switch fields[0] {
+					case "get":
+						if v, ok := data[fields[1]]; ok {
+							fmt.Fprintf(conn, "VALUE %s 0 %d\r\n%s\r\n", fields[1], len(v), v)
+						}
+						fmt.Fprint(conn, "END\r\n")
+					case "set":
+						var size int
+						fmt.Sscanf(fields[4], "%d", &size)
+						buf := make([]byte, size+2)
+						io.ReadFull(r, buf)
+						data[fields[1]] = buf[:size]
+						fmt.Fprint(conn, "STORED\r\n")
+					case "delete":
+						delete(data, fields[1])
+						fmt.Fprint(conn, "DELETED\r\n")
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestMemcachedStore_GetSetDelete(t *testing.T) {
+	store := httpclientutils.NewMemcachedStore(fakeMemcached(t), "http:")
+	defer store.Close()
+
+	_, ok, err := store.Get("GET https://example.com/a b")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set("GET https://example.com/a b", []byte("value"), time.Minute))
+	value, ok, err := store.Get("GET https://example.com/a b")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value", string(value))
+
+	assert.NoError(t, store.Delete("GET https://example.com/a b"))
+	_, ok, err = store.Get("GET https://example.com/a b")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,51 @@
+package httpclientutils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Meta holds caller-defined tags attributing a request, e.g. to a tenant or
+// feature.
+type Meta map[string]string
+
+// Labels returns the subset of m whose keys are in allowed, suitable for use
+// as metric labels without unbounded cardinality.
+func (m Meta) Labels(allowed ...string) map[string]string {
+	labels := make(map[string]string, len(allowed))
+	for _, key := range allowed {
+		if value, ok := m[key]; ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func (m Meta) String() string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+type metaContextKey struct{}
+
+// MetaFromContext returns the Meta attached to an outbound request context.
+func MetaFromContext(ctx context.Context) Meta {
+	meta, _ := ctx.Value(metaContextKey{}).(Meta)
+	return meta
+}
+
+// MetaError wraps a request error with the Meta of the failed request.
+type MetaError struct {
+	Meta Meta
+	Err  error
+}
+
+func (e *MetaError) Error() string { return fmt.Sprintf("%v [%s]", e.Err, e.Meta) }
+
+func (e *MetaError) Unwrap() error { return e.Err }
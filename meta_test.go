@@ -0,0 +1,27 @@
+package httpclientutils_test
+
+import (
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeta_WrapsErrors(t *testing.T) {
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://127.0.0.1:0"),
+		httpclientutils.WithMeta("tenant", "acme"),
+		httpclientutils.WithMeta("feature", "billing"),
+	)
+
+	var metaErr *httpclientutils.MetaError
+	assert.ErrorAs(t, err, &metaErr)
+	assert.Equal(t, httpclientutils.Meta{"tenant": "acme", "feature": "billing"}, metaErr.Meta)
+	assert.Contains(t, err.Error(), "[feature=billing tenant=acme]")
+	assert.Contains(t, err.Error(), "failed to send request")
+}
+
+func TestMeta_Labels(t *testing.T) {
+	meta := httpclientutils.Meta{"tenant": "acme", "request_id": "42"}
+	assert.Equal(t, map[string]string{"tenant": "acme"}, meta.Labels("tenant", "feature"))
+}
@@ -0,0 +1,155 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ec2MetadataEndpoint = "http://169.254.169.254"
+
+	// instanceMetadataTimeout is kept short: off-cloud the link-local
+	// address is usually unroutable, and startup should not hang on it.
+	instanceMetadataTimeout = 2 * time.Second
+	ec2MetadataTokenTTL     = 6 * time.Hour
+)
+
+// EC2Metadata queries the EC2 instance metadata service using IMDSv2
+// session tokens.
+type EC2Metadata struct {
+	Endpoint string // defaults to http://169.254.169.254
+
+	cache tokenCache
+}
+
+// Get returns the value at path below /latest/meta-data/, e.g.
+// "instance-id" or "placement/region".
+func (m *EC2Metadata) Get(ctx context.Context, path string) (string, error) {
+	token, err := m.cache.get(ctx, m.fetchToken)
+	if err != nil {
+		return "", err
+	}
+	target := orDefault(m.Endpoint, ec2MetadataEndpoint) + "/latest/meta-data/" + strings.TrimPrefix(path, "/")
+	value, err := fetchMetadataText(ctx, http.MethodGet, target, map[string]string{"X-aws-ec2-metadata-token": token})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch EC2 metadata %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// InstanceID returns the instance ID.
+func (m *EC2Metadata) InstanceID(ctx context.Context) (string, error) {
+	return m.Get(ctx, "instance-id")
+}
+
+// Region returns the region the instance runs in.
+func (m *EC2Metadata) Region(ctx context.Context) (string, error) {
+	return m.Get(ctx, "placement/region")
+}
+
+func (m *EC2Metadata) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	token, err := fetchMetadataText(ctx, http.MethodPut, orDefault(m.Endpoint, ec2MetadataEndpoint)+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": fmt.Sprint(int(ec2MetadataTokenTTL.Seconds()))})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to fetch IMDSv2 token: %w", err)
+	}
+	return token, ec2MetadataTokenTTL, nil
+}
+
+// GCEMetadata queries the GCE/GKE metadata server.
+type GCEMetadata struct {
+	Endpoint string // defaults to http://metadata.google.internal
+}
+
+// Get returns the value at path below /computeMetadata/v1/, e.g.
+// "project/project-id" or "instance/zone".
+func (m *GCEMetadata) Get(ctx context.Context, path string) (string, error) {
+	target := orDefault(m.Endpoint, gcpMetadataEndpoint) + "/computeMetadata/v1/" + strings.TrimPrefix(path, "/")
+	value, err := fetchMetadataText(ctx, http.MethodGet, target, map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch GCE metadata %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// ProjectID returns the project ID.
+func (m *GCEMetadata) ProjectID(ctx context.Context) (string, error) {
+	return m.Get(ctx, "project/project-id")
+}
+
+// Zone returns the zone name, e.g. "us-central1-a".
+func (m *GCEMetadata) Zone(ctx context.Context) (string, error) {
+	zone, err := m.Get(ctx, "instance/zone")
+	if err != nil {
+		return "", err
+	}
+	// The server returns projects/<number>/zones/<zone>.
+	return zone[strings.LastIndex(zone, "/")+1:], nil
+}
+
+// AzureMetadata queries the Azure Instance Metadata Service.
+type AzureMetadata struct {
+	Endpoint   string // defaults to http://169.254.169.254
+	APIVersion string // defaults to 2021-02-01
+}
+
+// AzureInstance holds the compute fields of the instance metadata document
+// most services need at startup.
+type AzureInstance struct {
+	Location          string `json:"location"`
+	Name              string `json:"name"`
+	ResourceGroupName string `json:"resourceGroupName"`
+	SubscriptionID    string `json:"subscriptionId"`
+	VMID              string `json:"vmId"`
+	VMSize            string `json:"vmSize"`
+	Zone              string `json:"zone"`
+}
+
+// Get returns the text value at path below /metadata/instance/, e.g.
+// "compute/location".
+func (m *AzureMetadata) Get(ctx context.Context, path string) (string, error) {
+	target := orDefault(m.Endpoint, azureIMDSEndpoint) + "/metadata/instance/" + strings.TrimPrefix(path, "/") + "?" +
+		url.Values{"api-version": {orDefault(m.APIVersion, "2021-02-01")}, "format": {"text"}}.Encode()
+	value, err := fetchMetadataText(ctx, http.MethodGet, target, map[string]string{"Metadata": "true"})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Azure metadata %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// Compute returns the compute section of the instance metadata document.
+func (m *AzureMetadata) Compute(ctx context.Context) (*AzureInstance, error) {
+	target := orDefault(m.Endpoint, azureIMDSEndpoint) + "/metadata/instance/compute?" +
+		url.Values{"api-version": {orDefault(m.APIVersion, "2021-02-01")}}.Encode()
+	body, err := fetchMetadataText(ctx, http.MethodGet, target, map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Azure instance metadata: %w", err)
+	}
+	var instance AzureInstance
+	if err := json.Unmarshal([]byte(body), &instance); err != nil {
+		return nil, fmt.Errorf("failed to parse Azure instance metadata: %w", err)
+	}
+	return &instance, nil
+}
+
+func fetchMetadataText(ctx context.Context, method, target string, headers map[string]string) (string, error) {
+	status, _, body, err := MakeHTTPRequest(
+		WithContext(ctx),
+		WithMethod(method),
+		WithURL(target),
+		WithHeaders(headers),
+		WithTimeout(instanceMetadataTimeout),
+	)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d: %s", status, body)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
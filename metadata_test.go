@@ -0,0 +1,79 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEC2Metadata_TokenHandshake(t *testing.T) {
+	var tokens int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/api/token":
+			atomic.AddInt32(&tokens, 1)
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "21600", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+			w.Write([]byte("session-token"))
+		case "/latest/meta-data/placement/region", "/latest/meta-data/instance-id":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "session-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path == "/latest/meta-data/instance-id" {
+				w.Write([]byte("i-0abc"))
+				return
+			}
+			w.Write([]byte("eu-west-1\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	metadata := &httpclientutils.EC2Metadata{Endpoint: ts.URL}
+	region, err := metadata.Region(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+
+	id, err := metadata.InstanceID(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "i-0abc", id)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokens))
+
+	_, err = metadata.Get(context.Background(), "missing")
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestGCEMetadata_Zone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		assert.Equal(t, "/computeMetadata/v1/instance/zone", r.URL.Path)
+		w.Write([]byte("projects/123456/zones/us-central1-a"))
+	}))
+	defer ts.Close()
+
+	zone, err := (&httpclientutils.GCEMetadata{Endpoint: ts.URL}).Zone(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "us-central1-a", zone)
+}
+
+func TestAzureMetadata_Compute(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, "/metadata/instance/compute", r.URL.Path)
+		assert.Equal(t, "2021-02-01", r.URL.Query().Get("api-version"))
+		w.Write([]byte(`{"location":"westeurope","name":"vm-1","subscriptionId":"sub-1","vmId":"abc"}`))
+	}))
+	defer ts.Close()
+
+	instance, err := (&httpclientutils.AzureMetadata{Endpoint: ts.URL}).Compute(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "westeurope", instance.Location)
+	assert.Equal(t, "sub-1", instance.SubscriptionID)
+}
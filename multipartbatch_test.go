@@ -0,0 +1,71 @@
+package httpclientutils_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendMultipartBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/mixed", mediaType)
+
+		var requests []*http.Request
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			assert.Equal(t, "application/http", part.Header.Get("Content-Type"))
+			req, err := http.ReadRequest(bufio.NewReader(part))
+			if !assert.NoError(t, err) {
+				return
+			}
+			body, _ := io.ReadAll(req.Body)
+			req.Body = io.NopCloser(strings.NewReader(string(body)))
+			requests = append(requests, req)
+		}
+		if !assert.Len(t, requests, 2) {
+			return
+		}
+		assert.Equal(t, "/v1/items/1", requests[0].URL.Path)
+		assert.Equal(t, "application/json", requests[0].Header.Get("Accept"))
+		assert.Equal(t, http.MethodPatch, requests[1].Method)
+		patch, _ := io.ReadAll(requests[1].Body)
+		assert.Equal(t, `{"name":"b"}`, string(patch))
+
+		// The second response is wrapped in an OData change set.
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batch_1")
+		fmt.Fprint(w, "--batch_1\r\nContent-Type: application/http\r\n\r\n"+
+			"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"id\":1}\r\n"+
+			"--batch_1\r\nContent-Type: multipart/mixed; boundary=changeset_1\r\n\r\n"+
+			"--changeset_1\r\nContent-Type: application/http\r\n\r\n"+
+			"HTTP/1.1 404 Not Found\r\n\r\n\r\n"+
+			"--changeset_1--\r\n"+
+			"--batch_1--\r\n")
+	}))
+	defer ts.Close()
+
+	responses, err := httpclientutils.SendMultipartBatch(ts.URL+"/batch", []httpclientutils.MultipartBatchRequest{
+		{Method: http.MethodGet, URL: "/v1/items/1", Header: http.Header{"Accept": {"application/json"}}, ContentID: "item1"},
+		{Method: http.MethodPatch, URL: "/v1/items/2", Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"name":"b"}`)},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, responses, 2) {
+		assert.Equal(t, http.StatusOK, responses[0].StatusCode)
+		assert.Equal(t, `{"id":1}`, string(responses[0].Body))
+		assert.Equal(t, http.StatusNotFound, responses[1].StatusCode)
+	}
+}
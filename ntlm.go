@@ -0,0 +1,256 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	ntlmNegotiateUnicode     = 0x00000001
+	ntlmNegotiateOEM         = 0x00000002
+	ntlmRequestTarget        = 0x00000004
+	ntlmNegotiateNTLM        = 0x00000200
+	ntlmNegotiateAlwaysSign  = 0x00008000
+	ntlmNegotiateExtendedSec = 0x00080000
+	ntlmNegotiateTargetInfo  = 0x00800000
+	ntlmNegotiate128         = 0x20000000
+	ntlmNegotiate56          = 0x80000000
+
+	ntlmNegotiateFlags = ntlmNegotiateUnicode | ntlmNegotiateOEM | ntlmRequestTarget | ntlmNegotiateNTLM |
+		ntlmNegotiateAlwaysSign | ntlmNegotiateExtendedSec | ntlmNegotiateTargetInfo | ntlmNegotiate128 | ntlmNegotiate56
+
+	ntlmAvEOL       = 0
+	ntlmAvTimestamp = 7
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// NTLMOptions holds the credentials for NTLM authentication.
+type NTLMOptions struct {
+	Domain      string
+	Username    string
+	Password    string
+	Workstation string
+}
+
+// NegotiateProvider produces SPNEGO tokens for the Negotiate scheme
+// (RFC 4559), typically by wrapping a Kerberos library.
+type NegotiateProvider interface {
+	// InitSecContext returns the next token for the service principal spn
+	// ("HTTP/host"), given the server's token, which is nil on the first leg.
+	InitSecContext(spn string, serverToken []byte) ([]byte, error)
+}
+
+// challengeAuthTransport runs a connection-oriented challenge/response
+// handshake (NTLM or Negotiate), replaying the request with each new token
+// until the server stops challenging. The handshake legs reuse the same
+// keep-alive connection, which these schemes require.
+type challengeAuthTransport struct {
+	base   http.RoundTripper
+	scheme string
+	token  func(req *http.Request, challenge []byte) ([]byte, error)
+}
+
+const maxAuthLegs = 3
+
+func (t *challengeAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var challenge []byte
+	for leg := 0; ; leg++ {
+		token, err := t.token(req, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("%s authentication failed: %w", t.scheme, err)
+		}
+		attempt, err := replayableRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		attempt.Header.Set("Authorization", t.scheme+" "+base64.StdEncoding.EncodeToString(token))
+
+		resp, err := t.base.RoundTrip(attempt)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || leg+1 >= maxAuthLegs {
+			return resp, err
+		}
+		challenge = authChallenge(resp.Header, t.scheme)
+		if challenge == nil {
+			return resp, nil
+		}
+		// Drain so the connection is reused for the next leg.
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// replayableRequest clones req with a fresh copy of its body.
+func replayableRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// authChallenge returns the decoded token of a "<scheme> <token>"
+// WWW-Authenticate challenge, or nil when there is none.
+func authChallenge(header http.Header, scheme string) []byte {
+	for _, value := range header.Values("WWW-Authenticate") {
+		name, token, _ := strings.Cut(value, " ")
+		if !strings.EqualFold(name, scheme) || token == "" {
+			continue
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token)); err == nil {
+			return decoded
+		}
+	}
+	return nil
+}
+
+func wrapAuthTransport(options *RequestOptions, base http.RoundTripper) http.RoundTripper {
+	switch {
+	case options.NTLM != nil:
+		creds := *options.NTLM
+		return &challengeAuthTransport{base: base, scheme: "NTLM", token: func(_ *http.Request, challenge []byte) ([]byte, error) {
+			if challenge == nil {
+				return ntlmNegotiateMessage(), nil
+			}
+			return ntlmAuthenticateMessage(creds, challenge, time.Now())
+		}}
+	case options.Negotiate != nil:
+		provider := options.Negotiate
+		return &challengeAuthTransport{base: base, scheme: "Negotiate", token: func(req *http.Request, challenge []byte) ([]byte, error) {
+			return provider.InitSecContext("HTTP/"+req.URL.Hostname(), challenge)
+		}}
+	}
+	return base
+}
+
+func ntlmNegotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmNegotiateFlags)
+	return msg
+}
+
+type ntlmChallenge struct {
+	flags           uint32
+	serverChallenge []byte
+	targetInfo      []byte
+}
+
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 48 || !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("malformed NTLM challenge")
+	}
+	challenge := &ntlmChallenge{
+		flags:           binary.LittleEndian.Uint32(msg[20:]),
+		serverChallenge: msg[24:32],
+	}
+	length := int(binary.LittleEndian.Uint16(msg[40:]))
+	offset := int(binary.LittleEndian.Uint32(msg[44:]))
+	if offset+length > len(msg) {
+		return nil, errors.New("malformed NTLM target info")
+	}
+	challenge.targetInfo = msg[offset : offset+length]
+	return challenge, nil
+}
+
+// ntlmAuthenticateMessage computes the NTLMv2 AUTHENTICATE message for the
+// server's CHALLENGE message (MS-NLMP 3.3.2).
+func ntlmAuthenticateMessage(creds NTLMOptions, challengeMsg []byte, now time.Time) ([]byte, error) {
+	challenge, err := parseNTLMChallenge(challengeMsg)
+	if err != nil {
+		return nil, err
+	}
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+
+	timestamp := ntlmTimestamp(challenge.targetInfo, now)
+	ntResponse, lmResponse := ntlmV2Responses(creds, challenge.serverChallenge, clientChallenge, timestamp, challenge.targetInfo)
+
+	domain, user, workstation := utf16LE(creds.Domain), utf16LE(creds.Username), utf16LE(creds.Workstation)
+	payloads := [][]byte{lmResponse, ntResponse, domain, user, workstation, nil}
+
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := len(msg)
+	for i, payload := range payloads {
+		field := 12 + i*8
+		binary.LittleEndian.PutUint16(msg[field:], uint16(len(payload)))
+		binary.LittleEndian.PutUint16(msg[field+2:], uint16(len(payload)))
+		binary.LittleEndian.PutUint32(msg[field+4:], uint32(offset))
+		offset += len(payload)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], challenge.flags&^ntlmNegotiateOEM|ntlmNegotiateUnicode)
+	for _, payload := range payloads {
+		msg = append(msg, payload...)
+	}
+	return msg, nil
+}
+
+func ntlmV2Responses(creds NTLMOptions, serverChallenge, clientChallenge, timestamp, targetInfo []byte) ([]byte, []byte) {
+	passwordHash := md4Sum(utf16LE(creds.Password))
+	responseKey := hmacMD5(passwordHash[:], utf16LE(strings.ToUpper(creds.Username)+creds.Domain))
+
+	var temp []byte
+	temp = append(temp, 1, 1, 0, 0, 0, 0, 0, 0)
+	temp = append(temp, timestamp...)
+	temp = append(temp, clientChallenge...)
+	temp = append(temp, 0, 0, 0, 0)
+	temp = append(temp, targetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+
+	ntProof := hmacMD5(responseKey, append(append([]byte(nil), serverChallenge...), temp...))
+	lmProof := hmacMD5(responseKey, append(append([]byte(nil), serverChallenge...), clientChallenge...))
+	return append(ntProof, temp...), append(lmProof, clientChallenge...)
+}
+
+// ntlmTimestamp returns the server's MsvAvTimestamp, or now as a Windows
+// FILETIME when the server sent none.
+func ntlmTimestamp(targetInfo []byte, now time.Time) []byte {
+	for len(targetInfo) >= 4 {
+		id := binary.LittleEndian.Uint16(targetInfo)
+		length := int(binary.LittleEndian.Uint16(targetInfo[2:]))
+		if id == ntlmAvEOL || 4+length > len(targetInfo) {
+			break
+		}
+		if id == ntlmAvTimestamp && length == 8 {
+			return targetInfo[4:12]
+		}
+		targetInfo = targetInfo[4+length:]
+	}
+	// FILETIME counts 100ns intervals since 1601-01-01.
+	filetime := uint64(now.UnixNano()/100) + 116444736000000000
+	return binary.LittleEndian.AppendUint64(nil, filetime)
+}
+
+func utf16LE(s string) []byte {
+	var out []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		out = binary.LittleEndian.AppendUint16(out, r)
+	}
+	return out
+}
+
+func hmacMD5(key, data []byte) []byte {
+	mac := hmac.New(md5.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,153 @@
+package httpclientutils_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMD4Sum_RFC1320(t *testing.T) {
+	vectors := map[string]string{
+		"":                           "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"a":                          "bde52cb31de33e46245e05fbdbd6fb24",
+		"abc":                        "a448017aaf21d8525fc10ae87aa6729d",
+		"message digest":             "d9130a8164549fe818874806e1c7014b",
+		"abcdefghijklmnopqrstuvwxyz": "d79e1c308aa5bbcdeea8ed63df412da9",
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789":                   "043f8582f241db351ce627e153e7f0e4",
+		"12345678901234567890123456789012345678901234567890123456789012345678901234567890": "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for input, want := range vectors {
+		sum := httpclientutils.MD4Sum([]byte(input))
+		assert.Equal(t, want, hex.EncodeToString(sum[:]), "MD4(%q)", input)
+	}
+}
+
+func unhex(s string) []byte {
+	b, _ := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	return b
+}
+
+// Known answers from MS-NLMP section 4.2.4 (NTLMv2 authentication).
+func TestNTLMV2Responses_MSNLMP(t *testing.T) {
+	creds := httpclientutils.NTLMOptions{Domain: "Domain", Username: "User", Password: "Password"}
+	serverChallenge := unhex("0123456789abcdef")
+	clientChallenge := unhex("aaaaaaaaaaaaaaaa")
+	timestamp := make([]byte, 8)
+	targetInfo := unhex("02000c0044006f006d00610069006e00 01000c00530065007200760065007200 00000000")
+
+	ntResponse, lmResponse := httpclientutils.NTLMV2Responses(creds, serverChallenge, clientChallenge, timestamp, targetInfo)
+
+	assert.Equal(t, "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa", hex.EncodeToString(lmResponse))
+	assert.Equal(t, "68cd0ab851e51c96aabc927bebef6a1c", hex.EncodeToString(ntResponse[:16]))
+	assert.Equal(t, hex.EncodeToString(unhex("0101000000000000 0000000000000000 aaaaaaaaaaaaaaaa 00000000")), hex.EncodeToString(ntResponse[16:44]))
+	assert.Equal(t, hex.EncodeToString(append(targetInfo, 0, 0, 0, 0)), hex.EncodeToString(ntResponse[44:]))
+}
+
+// ntlmChallengeMessage builds a minimal CHALLENGE message with empty target info.
+func ntlmChallengeMessage() string {
+	msg := make([]byte, 48)
+	copy(msg, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint32(msg[20:], 0x00088201)
+	copy(msg[24:], "CHALLNGE")
+	binary.LittleEndian.PutUint32(msg[44:], 48)
+	return base64.StdEncoding.EncodeToString(msg)
+}
+
+func ntlmField(msg []byte, offset int) string {
+	length := binary.LittleEndian.Uint16(msg[offset:])
+	start := binary.LittleEndian.Uint32(msg[offset+4:])
+	raw := msg[start : start+uint32(length)]
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(raw[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+func TestNTLMAuth_HandshakeOnOneConnection(t *testing.T) {
+	var mu sync.Mutex
+	var remotes []string
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		remotes = append(remotes, r.RemoteAddr)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+		assert.True(t, ok)
+		msg, err := base64.StdEncoding.DecodeString(token)
+		assert.NoError(t, err)
+		switch binary.LittleEndian.Uint32(msg[8:]) {
+		case 1:
+			w.Header().Set("WWW-Authenticate", "NTLM "+ntlmChallengeMessage())
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			assert.Equal(t, "CORP", ntlmField(msg, 28))
+			assert.Equal(t, "alice", ntlmField(msg, 36))
+			// NTLMv2 responses are longer than the 24-byte v1 form.
+			assert.Greater(t, int(binary.LittleEndian.Uint16(msg[20:])), 24)
+			w.Write([]byte("welcome"))
+		}
+	}))
+	defer ts.Close()
+
+	status, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithBody("payload"),
+		httpclientutils.WithNTLMAuth("CORP", "alice", "secret"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "welcome", string(body))
+	assert.Len(t, remotes, 2)
+	assert.Equal(t, remotes[0], remotes[1])
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+}
+
+type fakeNegotiate struct{ spns []string }
+
+func (f *fakeNegotiate) InitSecContext(spn string, serverToken []byte) ([]byte, error) {
+	f.spns = append(f.spns, spn)
+	if serverToken == nil {
+		return []byte("initial"), nil
+	}
+	return append([]byte("reply-"), serverToken...), nil
+}
+
+func TestSPNEGO_ExchangesTokens(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Negotiate " + base64.StdEncoding.EncodeToString([]byte("initial")):
+			w.Header().Set("WWW-Authenticate", "Negotiate "+base64.StdEncoding.EncodeToString([]byte("server")))
+			w.WriteHeader(http.StatusUnauthorized)
+		case "Negotiate " + base64.StdEncoding.EncodeToString([]byte("reply-server")):
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	provider := &fakeNegotiate{}
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithSPNEGO(provider),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"HTTP/127.0.0.1", "HTTP/127.0.0.1"}, provider.spns)
+}
@@ -0,0 +1,310 @@
+package httpclientutils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2Config describes an OAuth2 client and provider endpoints.
+type OAuth2Config struct {
+	ClientID     string
+	ClientSecret string // optional; public CLI clients rely on PKCE alone
+	AuthURL      string
+	TokenURL     string
+	Scopes       []string
+}
+
+// OAuth2Token is an access token with its optional refresh token.
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the access token is set and not about to expire.
+func (t *OAuth2Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Until(t.Expiry) > tokenRefreshMargin)
+}
+
+// TokenStore persists tokens between runs.
+type TokenStore interface {
+	Load() (*OAuth2Token, error)
+	Save(token *OAuth2Token) error
+}
+
+// FileTokenStore stores the token as JSON in a file readable only by the
+// current user.
+type FileTokenStore string
+
+func (f FileTokenStore) Load() (*OAuth2Token, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	var token OAuth2Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	return &token, nil
+}
+
+func (f FileTokenStore) Save(token *OAuth2Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(string(f)), 0o700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+	// The temporary file is created with mode 0600, so an existing file
+	// with looser permissions is replaced rather than rewritten in place.
+	if err := writeFileAtomic(string(f), data); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+// AuthCodeFlow runs the OAuth2 authorization code flow with PKCE (RFC 7636)
+// for command-line tools: it listens on a loopback port, opens the browser
+// at the authorization URL, and exchanges the returned code for a token.
+// It is a SecretProvider yielding the access token, refreshing it with the
+// stored refresh token and only falling back to the browser when needed.
+type AuthCodeFlow struct {
+	Config OAuth2Config
+	Store  TokenStore // optional
+
+	// OpenBrowser opens url for the user; defaults to the platform opener.
+	OpenBrowser func(url string) error
+	// ListenAddr is the loopback callback address; defaults to 127.0.0.1:0.
+	ListenAddr string
+
+	mu    sync.Mutex
+	token *OAuth2Token
+}
+
+func (f *AuthCodeFlow) Secret(ctx context.Context) (string, error) {
+	token, err := f.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Token returns a valid token, refreshing or re-authorizing as needed.
+func (f *AuthCodeFlow) Token(ctx context.Context) (*OAuth2Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.token == nil && f.Store != nil {
+		token, err := f.Store.Load()
+		if err != nil {
+			return nil, err
+		}
+		f.token = token
+	}
+	if f.token.Valid() {
+		return f.token, nil
+	}
+
+	var token *OAuth2Token
+	var err error
+	if f.token != nil && f.token.RefreshToken != "" {
+		token, err = f.refresh(ctx, f.token.RefreshToken)
+	}
+	if token == nil {
+		if token, err = f.authorize(ctx); err != nil {
+			return nil, err
+		}
+	}
+	f.token = token
+	if f.Store != nil {
+		if err := f.Store.Save(token); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
+
+func (f *AuthCodeFlow) authorize(ctx context.Context) (*OAuth2Token, error) {
+	verifier, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	ln, err := net.Listen("tcp", orDefault(f.ListenAddr, "127.0.0.1:0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback listener: %w", err)
+	}
+	defer ln.Close()
+	redirectURI := "http://" + ln.Addr().String() + "/callback"
+
+	type callback struct {
+		code string
+		err  error
+	}
+	result := make(chan callback, 1)
+	deliver := func(cb callback) {
+		// Only the first callback counts; a reload or second hit must not
+		// block its handler forever.
+		select {
+		case result <- cb:
+		default:
+		}
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path != "/callback":
+			http.NotFound(w, r)
+			return
+		case query.Get("state") != state:
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		case query.Get("error") != "":
+			deliver(callback{err: fmt.Errorf("authorization denied: %s %s", query.Get("error"), query.Get("error_description"))})
+		default:
+			deliver(callback{code: query.Get("code")})
+		}
+		fmt.Fprintln(w, "Authorization complete. You can close this window.")
+	})}
+	go server.Serve(ln)
+	defer server.Close()
+
+	authURL, err := url.Parse(f.Config.AuthURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authorization URL: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", f.Config.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if len(f.Config.Scopes) > 0 {
+		query.Set("scope", strings.Join(f.Config.Scopes, " "))
+	}
+	authURL.RawQuery = query.Encode()
+
+	open := f.OpenBrowser
+	if open == nil {
+		open = openBrowser
+	}
+	if err := open(authURL.String()); err != nil {
+		return nil, fmt.Errorf("failed to open browser: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case cb := <-result:
+		if cb.err != nil {
+			return nil, cb.err
+		}
+		return f.exchange(ctx, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {cb.code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {verifier},
+		})
+	}
+}
+
+func (f *AuthCodeFlow) refresh(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
+	token, err := f.exchange(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// exchange posts form to the token endpoint.
+func (f *AuthCodeFlow) exchange(ctx context.Context, form url.Values) (*OAuth2Token, error) {
+	form.Set("client_id", f.Config.ClientID)
+	if f.Config.ClientSecret != "" {
+		form.Set("client_secret", f.Config.ClientSecret)
+	}
+	return requestToken(ctx, f.Config.TokenURL, form)
+}
+
+// requestToken posts form to an OAuth2 token endpoint and parses the token
+// response.
+func requestToken(ctx context.Context, tokenURL string, form url.Values) (*OAuth2Token, error) {
+	status, _, body, err := MakeHTTPRequest(
+		WithContext(ctx),
+		WithMethod(http.MethodPost),
+		WithURL(tokenURL),
+		WithBody(form.Encode()),
+		WithHeaders(map[string]string{"Content-Type": "application/x-www-form-urlencoded", "Accept": "application/json"}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+
+	var resp struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse token response (status %d): %w", status, err)
+	}
+	if status != http.StatusOK || resp.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed with status %d: %s %s", status, resp.Error, resp.ErrorDescription)
+	}
+
+	token := &OAuth2Token{AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken, TokenType: resp.TokenType}
+	if resp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func openBrowser(target string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target).Start()
+	default:
+		return exec.Command("xdg-open", target).Start()
+	}
+}
@@ -0,0 +1,116 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthCodeFlow_PKCEAndRefresh(t *testing.T) {
+	var challenge string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			assert.Equal(t, challenge, base64.RawURLEncoding.EncodeToString(sum[:]))
+			assert.Equal(t, "the-code", r.Form.Get("code"))
+			w.Write([]byte(`{"access_token":"first","refresh_token":"refresh-1","expires_in":1}`))
+		case "refresh_token":
+			assert.Equal(t, "refresh-1", r.Form.Get("refresh_token"))
+			w.Write([]byte(`{"access_token":"second","expires_in":3600}`))
+		}
+	}))
+	defer ts.Close()
+
+	browser := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		query := u.Query()
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		assert.Equal(t, "cli", query.Get("client_id"))
+		challenge = query.Get("code_challenge")
+		go http.Get(query.Get("redirect_uri") + "?code=the-code&state=" + query.Get("state"))
+		return nil
+	}
+
+	store := httpclientutils.FileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	flow := &httpclientutils.AuthCodeFlow{
+		Config:      httpclientutils.OAuth2Config{ClientID: "cli", AuthURL: ts.URL + "/authorize", TokenURL: ts.URL + "/token"},
+		Store:       store,
+		OpenBrowser: browser,
+	}
+
+	token, err := flow.Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	// The first token expires within the refresh margin, so the next call
+	// refreshes instead of opening the browser again.
+	flow.OpenBrowser = func(string) error { t.Fatal("unexpected browser launch"); return nil }
+	token, err = flow.Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", token)
+
+	saved, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "second", saved.AccessToken)
+	assert.Equal(t, "refresh-1", saved.RefreshToken)
+}
+
+func TestFileTokenStore_ReplacesLooseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"access_token":"old"}`), 0o644))
+	store := httpclientutils.FileTokenStore(path)
+
+	assert.NoError(t, store.Save(&httpclientutils.OAuth2Token{AccessToken: "new"}))
+	info, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	saved, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "new", saved.AccessToken)
+}
+
+func TestAuthCodeFlow_RepeatedCallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	// A browser reload hits the callback again before the flow reads the
+	// first result; both requests must still be answered.
+	client := &http.Client{Timeout: 2 * time.Second}
+	browser := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		callback := u.Query().Get("redirect_uri") + "?code=the-code&state=" + u.Query().Get("state")
+		for range 2 {
+			resp, err := client.Get(callback)
+			if !assert.NoError(t, err) {
+				return err
+			}
+			resp.Body.Close()
+		}
+		return nil
+	}
+
+	flow := &httpclientutils.AuthCodeFlow{
+		Config:      httpclientutils.OAuth2Config{ClientID: "cli", AuthURL: ts.URL + "/authorize", TokenURL: ts.URL + "/token"},
+		OpenBrowser: browser,
+	}
+	token, err := flow.Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+}
@@ -0,0 +1,50 @@
+package httpclientutils_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestODataQuery_Encode(t *testing.T) {
+	q := httpclientutils.ODataQuery{
+		Filter:  httpclientutils.ODataFilter("startswith(displayName, %v) and createdDateTime ge %v", "O'Reilly & Co", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Select:  []string{"id", "displayName"},
+		OrderBy: []string{"displayName desc"},
+		Top:     2,
+		Count:   true,
+	}
+	assert.Equal(t,
+		"$filter=startswith%28displayName%2C%20%27O%27%27Reilly%20%26%20Co%27%29%20and%20createdDateTime%20ge%202024-01-02T03%3A04%3A05Z"+
+			"&$select=id%2CdisplayName&$orderby=displayName%20desc&$top=2&$count=true",
+		q.Encode())
+	assert.Equal(t, "https://graph.example/v1.0/users?$top=2", httpclientutils.ODataQuery{Top: 2}.URL("https://graph.example/v1.0/users"))
+}
+
+func TestODataAll_FollowsNextLink(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "'Ada'", r.URL.Query().Get("$filter")[len("givenName eq "):])
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("$skiptoken") == "" {
+			fmt.Fprintf(w, `{"@odata.context": "x", "value": [{"id": "1"}, {"id": "2"}], "@odata.nextLink": "%s/users?$filter=givenName%%20eq%%20%%27Ada%%27&$skiptoken=abc"}`, ts.URL)
+			return
+		}
+		w.Write([]byte(`{"value": [{"id": "3"}]}`))
+	}))
+	defer ts.Close()
+
+	var users []struct {
+		ID string `json:"id"`
+	}
+	query := httpclientutils.ODataQuery{Filter: httpclientutils.ODataFilter("givenName eq %v", "Ada")}
+	err := httpclientutils.ODataAll(query.URL(ts.URL+"/users"), &users)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.Equal(t, "3", users[2].ID)
+}
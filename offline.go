@@ -0,0 +1,21 @@
+package httpclientutils
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrOffline is returned while offline mode is on for requests that cannot
+// be served from a cache.
+var ErrOffline = errors.New("offline: request not available from cache")
+
+var offline atomic.Bool
+
+// SetOffline switches offline mode on or off for all requests made by the
+// package. While it is on nothing is dialed: requests with a Cache are
+// served from it regardless of freshness, and everything else fails with
+// ErrOffline.
+func SetOffline(enabled bool) { offline.Store(enabled) }
+
+// Offline reports whether offline mode is on.
+func Offline() bool { return offline.Load() }
@@ -0,0 +1,39 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOffline_ServesOnlyCachedResponses(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+		w.Write([]byte("cached"))
+	}))
+	defer ts.Close()
+
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+	assert.NoError(t, err)
+
+	httpclientutils.SetOffline(true)
+	defer httpclientutils.SetOffline(false)
+
+	// Stale entries are still served; nothing reaches the network.
+	_, _, body, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithCache(cache))
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", string(body))
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+"/uncached"), httpclientutils.WithCache(cache))
+	assert.ErrorIs(t, err, httpclientutils.ErrOffline)
+	_, _, _, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL))
+	assert.ErrorIs(t, err, httpclientutils.ErrOffline)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,192 @@
+package httpclientutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OperationConfig configures WaitForOperation.
+type OperationConfig struct {
+	// Backoff spaces polls the server sends no Retry-After for; it
+	// defaults to waits from one second up to a minute.
+	Backoff BackoffPolicy
+	// State classifies a status response body as OperationRunning,
+	// OperationSucceeded or OperationFailed. It defaults to reading an
+	// Azure-style "status" member or a Google-style "done" and "error"
+	// pair; bodies carrying neither count as the finished resource.
+	State func(body []byte) string
+}
+
+// OperationError reports a long-running operation that ended in failure.
+type OperationError struct {
+	StatusURL string
+	Body      []byte
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %s failed: %s", e.StatusURL, e.Body)
+}
+
+// Operation states returned by OperationConfig.State.
+const (
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+)
+
+// WaitForOperation sends the request and, when it is answered with 202
+// Accepted, polls the status URL from its Operation-Location,
+// Azure-AsyncOperation or Location header until the operation completes,
+// honoring Retry-After between polls. On success it returns the terminal
+// resource: the resource at the initial Location or a "resourceLocation"
+// member when the status was polled elsewhere, else the final status
+// response. Polls reuse opts as GET requests without a body and stop when
+// the context set with WithContext is done. Other responses to the
+// initial request are returned unchanged.
+func WaitForOperation(config OperationConfig, opts ...Option) (int, http.Header, []byte, error) {
+	statusCode, header, body, err := MakeHTTPRequest(opts...)
+	if err != nil || statusCode != http.StatusAccepted {
+		return statusCode, header, body, err
+	}
+	options := newRequestOptions(opts)
+	base, err := url.Parse(options.URL)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to parse request URL: %w", err)
+	}
+	get := func(target string) (int, http.Header, []byte, error) {
+		return MakeHTTPRequest(append(append([]Option{}, opts...), WithMethod(http.MethodGet), WithURL(target), WithBody(nil))...)
+	}
+
+	statusURL, resultURL := operationURLs(base, header)
+	if statusURL == "" {
+		return statusCode, header, body, fmt.Errorf("operation accepted without a status URL")
+	}
+	state := config.State
+	if state == nil {
+		state = operationState
+	}
+	for attempt := 1; ; attempt++ {
+		wait := retryAfter(header)
+		if wait < 0 {
+			wait = NextDelay(attempt, config.Backoff)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-options.Context.Done():
+			timer.Stop()
+			return 0, nil, nil, options.Context.Err()
+		case <-timer.C:
+		}
+
+		statusCode, header, body, err = get(statusURL)
+		if err != nil {
+			return statusCode, header, body, err
+		}
+		if statusCode >= http.StatusBadRequest {
+			return statusCode, header, body, &RequestError{Err: fmt.Errorf("operation status request failed with status %d", statusCode), statusCode: statusCode, attempt: attempt}
+		}
+		if statusCode == http.StatusAccepted {
+			// Location-polled operations answer 202 until done.
+			if next, _ := operationURLs(base, header); next != "" {
+				statusURL = next
+			}
+			continue
+		}
+		switch state(body) {
+		case OperationRunning:
+			continue
+		case OperationFailed:
+			return statusCode, header, body, &OperationError{StatusURL: statusURL, Body: body}
+		}
+		if location := resourceLocation(body); location != "" {
+			resultURL = resolveLocation(base, location)
+		}
+		if resultURL == "" || resultURL == statusURL {
+			return statusCode, header, body, nil
+		}
+		return get(resultURL)
+	}
+}
+
+// operationURLs returns the URL to poll and, when status is reported
+// elsewhere than Location, the Location of the eventual resource.
+func operationURLs(base *url.URL, header http.Header) (status, result string) {
+	location := resolveLocation(base, header.Get("Location"))
+	for _, name := range []string{"Operation-Location", "Azure-AsyncOperation"} {
+		if value := header.Get(name); value != "" {
+			return resolveLocation(base, value), location
+		}
+	}
+	return location, ""
+}
+
+func resolveLocation(base *url.URL, location string) string {
+	if location == "" {
+		return ""
+	}
+	target, err := base.Parse(location)
+	if err != nil {
+		return ""
+	}
+	return target.String()
+}
+
+// retryAfter returns the wait a Retry-After header asks for, in seconds or
+// as an HTTP date, or -1 without one.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return -1
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return -1
+}
+
+// operationState reads Azure ("status": "Running") and Google ("done":
+// false, "error": {...}) operation bodies. Bodies with neither are taken
+// to be the finished resource.
+func operationState(body []byte) string {
+	var operation struct {
+		Status *string          `json:"status"`
+		Done   *bool            `json:"done"`
+		Error  *json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &operation) != nil {
+		return OperationSucceeded
+	}
+	switch {
+	case operation.Done != nil && !*operation.Done:
+		return OperationRunning
+	case operation.Done != nil && operation.Error != nil && !isJSONNull(*operation.Error):
+		return OperationFailed
+	case operation.Done != nil:
+		return OperationSucceeded
+	case operation.Status != nil:
+		switch strings.ToLower(*operation.Status) {
+		case "running", "inprogress", "in_progress", "notstarted", "not_started", "pending", "queued", "accepted", "processing":
+			return OperationRunning
+		case "failed", "canceled", "cancelled", "error":
+			return OperationFailed
+		}
+	}
+	// Other status values, such as a resource's own "active", are final.
+	return OperationSucceeded
+}
+
+func resourceLocation(body []byte) string {
+	var operation struct {
+		ResourceLocation string `json:"resourceLocation"`
+	}
+	json.Unmarshal(body, &operation)
+	return operation.ResourceLocation
+}
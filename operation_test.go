@@ -0,0 +1,73 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForOperation(t *testing.T) {
+	var polls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		switch r.URL.Path {
+		case "/vms":
+			w.Header().Set("Operation-Location", "/operations/1")
+			w.Header().Set("Location", "/vms/1")
+			w.WriteHeader(http.StatusAccepted)
+		case "/operations/1":
+			if atomic.AddInt32(&polls, 1) < 3 {
+				w.Write([]byte(`{"status": "Running"}`))
+				return
+			}
+			w.Write([]byte(`{"status": "Succeeded"}`))
+		case "/vms/1":
+			assert.Equal(t, http.MethodGet, r.Method)
+			w.Write([]byte(`{"id": "vm-1", "status": "active"}`))
+		case "/exports":
+			w.Header().Set("Location", "/exports/queue/5")
+			w.WriteHeader(http.StatusAccepted)
+		case "/exports/queue/5":
+			w.Header().Set("Location", "/exports/queue/5")
+			w.WriteHeader(http.StatusAccepted)
+		case "/broken":
+			w.Header().Set("Operation-Location", "/operations/2")
+			w.WriteHeader(http.StatusAccepted)
+		case "/operations/2":
+			w.Write([]byte(`{"done": true, "error": {"code": 13, "message": "disk full"}}`))
+		}
+	}))
+	defer ts.Close()
+	config := httpclientutils.OperationConfig{Backoff: httpclientutils.BackoffPolicy{Initial: time.Millisecond}}
+
+	status, _, body, err := httpclientutils.WaitForOperation(config,
+		httpclientutils.WithMethod(http.MethodPut),
+		httpclientutils.WithURL(ts.URL+"/vms"),
+		httpclientutils.WithBody(map[string]string{"size": "small"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id": "vm-1", "status": "active"}`, string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+
+	_, _, _, err = httpclientutils.WaitForOperation(config, httpclientutils.WithMethod(http.MethodPost), httpclientutils.WithURL(ts.URL+"/broken"))
+	var opErr *httpclientutils.OperationError
+	if assert.ErrorAs(t, err, &opErr) {
+		assert.Equal(t, ts.URL+"/operations/2", opErr.StatusURL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, _, err = httpclientutils.WaitForOperation(config,
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/exports"),
+		httpclientutils.WithContext(ctx),
+	)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
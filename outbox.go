@@ -0,0 +1,256 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutboxMessage is a request persisted for guaranteed delivery.
+type OutboxMessage struct {
+	ID          string            `json:"id"`
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Attempts    int               `json:"attempts"`
+	NextAttempt time.Time         `json:"next_attempt"`
+	LastError   string            `json:"last_error,omitempty"`
+}
+
+// OutboxStore persists outbox messages. Put inserts or replaces a message by
+// ID; List returns every pending message.
+type OutboxStore interface {
+	Put(msg OutboxMessage) error
+	Delete(id string) error
+	List() ([]OutboxMessage, error)
+}
+
+// OutboxConfig configures an Outbox dispatcher.
+type OutboxConfig struct {
+	MaxAttempts    int           // defaults to 10
+	InitialBackoff time.Duration // defaults to one second, doubling per attempt
+	MaxBackoff     time.Duration // defaults to one minute
+	PollInterval   time.Duration // how often the store is scanned; defaults to one second
+
+	// DeadLetter is called, after the message has been removed from the
+	// store, when a message fails permanently or runs out of attempts.
+	DeadLetter func(msg OutboxMessage, err error)
+
+	// Options are applied to every delivery. Credentials belong here, e.g.
+	// WithBearerFromSecret, so they are never written to the store.
+	Options []Option
+}
+
+// Outbox delivers requests at least once: WithOutbox writes the request to
+// the store and returns 202 Accepted, and Run sends stored messages with
+// retries. Only the method, URL, headers and body are persisted. Messages
+// left in the store by a previous process are picked up when Run starts.
+type Outbox struct {
+	store  OutboxStore
+	config OutboxConfig
+	wake   chan struct{}
+}
+
+// NewOutbox returns an Outbox backed by store.
+func NewOutbox(store OutboxStore, config OutboxConfig) *Outbox {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	return &Outbox{store: store, config: config, wake: make(chan struct{}, 1)}
+}
+
+func (o *Outbox) enqueue(options *RequestOptions) (int, http.Header, []byte, error) {
+	reader, err := prepareBody(options.Body, options.DisableEscapeHTML)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to prepare request body: %w", err)
+	}
+	var body []byte
+	if reader != nil {
+		if body, err = io.ReadAll(reader); err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to prepare request body: %w", err)
+		}
+	}
+	id, err := randomToken(16)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	headers := options.Headers
+	if contentType := bodyContentType(options); contentType != "" {
+		headers = make(map[string]string, len(options.Headers)+1)
+		maps.Copy(headers, options.Headers)
+		headers["Content-Type"] = contentType
+	}
+
+	now := time.Now()
+	msg := OutboxMessage{ID: id, Method: options.Method, URL: options.URL, Headers: headers, Body: body, CreatedAt: now, NextAttempt: now}
+	if err := o.store.Put(msg); err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to store outbox message: %w", err)
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return http.StatusAccepted, http.Header{"X-Outbox-Id": {id}}, nil, nil
+}
+
+// Run dispatches due messages until ctx is done.
+func (o *Outbox) Run(ctx context.Context) error {
+	ticker := time.NewTicker(o.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := o.dispatch(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+// dispatch sends every message that is due, oldest first.
+func (o *Outbox) dispatch(ctx context.Context) error {
+	messages, err := o.store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list outbox messages: %w", err)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].CreatedAt.Before(messages[j].CreatedAt) })
+
+	now := time.Now()
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if msg.NextAttempt.After(now) {
+			continue
+		}
+		if err := o.deliver(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Outbox) deliver(ctx context.Context, msg OutboxMessage) error {
+	opts := append([]Option{}, o.config.Options...)
+	opts = append(opts, WithContext(ctx), WithMethod(msg.Method), WithURL(msg.URL), WithBody(msg.Body))
+	for key, value := range msg.Headers {
+		opts = append(opts, withHeader(key, value))
+	}
+	statusCode, _, _, sendErr := MakeHTTPRequest(opts...)
+	if sendErr == nil && statusCode < 300 {
+		return o.store.Delete(msg.ID)
+	}
+	retry := retryableStatus(statusCode)
+	var requestErr *RequestError
+	if errors.As(sendErr, &requestErr) {
+		// Classified by cause, so policy refusals, invalid URLs and
+		// encoding errors are dead-lettered at once.
+		retry = requestErr.Retryable()
+	}
+	if sendErr == nil {
+		sendErr = fmt.Errorf("delivery rejected with status %d", statusCode)
+	}
+	if ctx.Err() != nil {
+		// Shutting down; leave the message for the next run.
+		return nil
+	}
+
+	msg.Attempts++
+	msg.LastError = sendErr.Error()
+	if !retry || msg.Attempts >= o.config.MaxAttempts {
+		if err := o.store.Delete(msg.ID); err != nil {
+			return fmt.Errorf("failed to delete outbox message: %w", err)
+		}
+		if o.config.DeadLetter != nil {
+			o.config.DeadLetter(msg, fmt.Errorf("failed to deliver outbox message after %d attempts: %w", msg.Attempts, sendErr))
+		}
+		return nil
+	}
+	msg.NextAttempt = time.Now().Add(exponentialBackoff(o.config.InitialBackoff, o.config.MaxBackoff, msg.Attempts))
+	if err := o.store.Put(msg); err != nil {
+		return fmt.Errorf("failed to store outbox message: %w", err)
+	}
+	return nil
+}
+
+// FileOutboxStore is an OutboxStore keeping one JSON file per message in a
+// directory. Writes are atomic, so a crash never leaves a partial message.
+type FileOutboxStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileOutboxStore returns a FileOutboxStore in dir, creating it if needed.
+func NewFileOutboxStore(dir string) (*FileOutboxStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	return &FileOutboxStore{dir: dir}, nil
+}
+
+func (s *FileOutboxStore) Put(msg OutboxMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFileAtomic(s.path(msg.ID), data)
+}
+
+func (s *FileOutboxStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileOutboxStore) List() ([]OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var messages []OutboxMessage
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var msg OutboxMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message %s: %w", entry.Name(), err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func (s *FileOutboxStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
@@ -0,0 +1,137 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutbox_DeliversAfterRestart(t *testing.T) {
+	received := make(chan string, 1)
+	var failures int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures < 1 {
+			failures++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r.Header.Get("X-Order") + ":" + string(body)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	store, err := httpclientutils.NewFileOutboxStore(dir)
+	assert.NoError(t, err)
+
+	// Enqueued by a process that exits before dispatching.
+	status, header, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithHeaders(map[string]string{"X-Order": "42"}),
+		httpclientutils.WithBody("paid"),
+		httpclientutils.WithOutbox(httpclientutils.NewOutbox(store, httpclientutils.OutboxConfig{})),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.NotEmpty(t, header.Get("X-Outbox-Id"))
+
+	restarted, err := httpclientutils.NewFileOutboxStore(dir)
+	assert.NoError(t, err)
+	outbox := httpclientutils.NewOutbox(restarted, httpclientutils.OutboxConfig{
+		InitialBackoff: time.Millisecond,
+		PollInterval:   5 * time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go outbox.Run(ctx)
+
+	select {
+	case got := <-received:
+		assert.Equal(t, "42:paid", got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not delivered")
+	}
+	assert.Eventually(t, func() bool {
+		pending, _ := restarted.List()
+		return len(pending) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestOutbox_DeadLettersPermanentFailures(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer ts.Close()
+
+	store, err := httpclientutils.NewFileOutboxStore(t.TempDir())
+	assert.NoError(t, err)
+	dead := make(chan httpclientutils.OutboxMessage, 1)
+	outbox := httpclientutils.NewOutbox(store, httpclientutils.OutboxConfig{
+		PollInterval: 5 * time.Millisecond,
+		DeadLetter:   func(msg httpclientutils.OutboxMessage, err error) { dead <- msg },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go outbox.Run(ctx)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithOutbox(outbox),
+	)
+	assert.NoError(t, err)
+
+	select {
+	case msg := <-dead:
+		assert.Equal(t, 1, msg.Attempts)
+		assert.Contains(t, msg.LastError, "422")
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not dead-lettered")
+	}
+	pending, _ := store.List()
+	assert.Empty(t, pending)
+}
+
+func TestOutbox_DeadLettersRefusedDeliveries(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer ts.Close()
+
+	store, err := httpclientutils.NewFileOutboxStore(t.TempDir())
+	assert.NoError(t, err)
+	dead := make(chan error, 1)
+	outbox := httpclientutils.NewOutbox(store, httpclientutils.OutboxConfig{
+		PollInterval:   5 * time.Millisecond,
+		InitialBackoff: time.Millisecond,
+		Options:        []httpclientutils.Option{httpclientutils.WithDeniedHosts("127.0.0.1")},
+		DeadLetter:     func(msg httpclientutils.OutboxMessage, err error) { assert.Equal(t, 1, msg.Attempts); dead <- err },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go outbox.Run(ctx)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithOutbox(outbox),
+	)
+	assert.NoError(t, err)
+
+	select {
+	case err := <-dead:
+		assert.ErrorIs(t, err, httpclientutils.ErrHostNotAllowed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not dead-lettered")
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,45 @@
+package httpclientutils
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is returned in place of a panic raised by code running on a
+// request's behalf, such as an unmarshal func, codec, signer or batch
+// combiner, so one misbehaving callback cannot crash the process.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic during request: %v", e.Value) }
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic turns a panic into a *PanicError, reported to onPanic and
+// stored in *err when err is not nil. It must be deferred directly.
+func recoverPanic(onPanic func(*PanicError), err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	panicErr := &PanicError{Value: value, Stack: debug.Stack()}
+	if onPanic != nil {
+		onPanic(panicErr)
+	}
+	if err != nil {
+		*err = panicErr
+	}
+}
+
+// safeExecute runs execute, converting panics into errors.
+func safeExecute(options *RequestOptions) (statusCode int, header http.Header, body []byte, err error) {
+	defer recoverPanic(options.OnPanic, &err)
+	return execute(options)
+}
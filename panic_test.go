@@ -0,0 +1,53 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOnPanic(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	var reported *httpclientutils.PanicError
+	var target struct{}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&target),
+		httpclientutils.WithUnmarshalFunc(func(string, []byte, interface{}) error { panic("bad codec") }),
+		httpclientutils.WithOnPanic(func(p *httpclientutils.PanicError) { reported = p }),
+	)
+
+	var panicErr *httpclientutils.PanicError
+	if assert.ErrorAs(t, err, &panicErr) {
+		assert.Equal(t, "bad codec", panicErr.Value)
+		assert.Contains(t, string(panicErr.Stack), "panic")
+	}
+	assert.Same(t, panicErr, reported)
+}
+
+func TestBatcher_RecoversCombinePanic(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	batcher := httpclientutils.NewBatcher(httpclientutils.BatchConfig{
+		Endpoint: ts.URL,
+		Window:   10 * time.Millisecond,
+		Combine:  func([][]byte) ([]byte, error) { panic("bad combiner") },
+	})
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithBody("a"),
+		httpclientutils.WithBatcher(batcher),
+	)
+	var panicErr *httpclientutils.PanicError
+	assert.ErrorAs(t, err, &panicErr)
+}
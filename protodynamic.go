@@ -0,0 +1,181 @@
+package httpclientutils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownProtoType is returned by a ProtoTypeResolver that has no
+// message type registered for a type URL.
+var ErrUnknownProtoType = errors.New("unknown protobuf message type")
+
+// ProtoWireType is the wire type of an encoded protobuf field.
+type ProtoWireType int
+
+const (
+	ProtoVarint  ProtoWireType = 0
+	ProtoFixed64 ProtoWireType = 1
+	ProtoBytes   ProtoWireType = 2
+	ProtoFixed32 ProtoWireType = 5
+)
+
+// ProtoField is one field of a protobuf message decoded without its
+// schema. Varint and fixed-width values are held in Value; length-delimited
+// ones (strings, bytes, nested messages, packed repeated fields) in Bytes.
+type ProtoField struct {
+	Number int
+	Type   ProtoWireType
+	Value  uint64
+	Bytes  []byte
+}
+
+// Message decodes a length-delimited field as a nested message.
+func (f ProtoField) Message() (ProtoMessage, error) {
+	if f.Type != ProtoBytes {
+		return ProtoMessage{}, fmt.Errorf("field %d is not length-delimited", f.Number)
+	}
+	return DecodeProtoMessage(f.Bytes)
+}
+
+// ProtoMessage is a protobuf message decoded without its schema, with
+// fields in wire order.
+type ProtoMessage struct {
+	Fields []ProtoField
+}
+
+// Field returns the last occurrence of field number, which is the value
+// protobuf semantics assign to a non-repeated field.
+func (m ProtoMessage) Field(number int) (ProtoField, bool) {
+	for i := len(m.Fields) - 1; i >= 0; i-- {
+		if m.Fields[i].Number == number {
+			return m.Fields[i], true
+		}
+	}
+	return ProtoField{}, false
+}
+
+// DecodeProtoMessage decodes the fields of a binary protobuf message.
+// Deprecated group fields are rejected.
+func DecodeProtoMessage(data []byte) (ProtoMessage, error) {
+	var message ProtoMessage
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ProtoMessage{}, errors.New("failed to decode protobuf: malformed field key")
+		}
+		data = data[n:]
+		field := ProtoField{Number: int(key >> 3), Type: ProtoWireType(key & 7)}
+		if field.Number == 0 {
+			return ProtoMessage{}, errors.New("failed to decode protobuf: invalid field number 0")
+		}
+		switch field.Type {
+		case ProtoVarint:
+			field.Value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ProtoMessage{}, fmt.Errorf("failed to decode protobuf: malformed varint in field %d", field.Number)
+			}
+			data = data[n:]
+		case ProtoFixed64:
+			if len(data) < 8 {
+				return ProtoMessage{}, fmt.Errorf("failed to decode protobuf: truncated field %d", field.Number)
+			}
+			field.Value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case ProtoFixed32:
+			if len(data) < 4 {
+				return ProtoMessage{}, fmt.Errorf("failed to decode protobuf: truncated field %d", field.Number)
+			}
+			field.Value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case ProtoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return ProtoMessage{}, fmt.Errorf("failed to decode protobuf: truncated field %d", field.Number)
+			}
+			field.Bytes, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return ProtoMessage{}, fmt.Errorf("failed to decode protobuf: unsupported wire type %d in field %d", field.Type, field.Number)
+		}
+		message.Fields = append(message.Fields, field)
+	}
+	return message, nil
+}
+
+// ProtoAny is a google.protobuf.Any: a message of the type named by
+// TypeURL, still encoded.
+type ProtoAny struct {
+	TypeURL string
+	Value   []byte
+}
+
+// TypeName returns the fully qualified message name from TypeURL, e.g.
+// "google.rpc.Status" for "type.googleapis.com/google.rpc.Status".
+func (a ProtoAny) TypeName() string {
+	return a.TypeURL[strings.LastIndex(a.TypeURL, "/")+1:]
+}
+
+// DecodeProtoAny decodes a binary google.protobuf.Any.
+func DecodeProtoAny(data []byte) (ProtoAny, error) {
+	message, err := DecodeProtoMessage(data)
+	if err != nil {
+		return ProtoAny{}, err
+	}
+	var wrapped ProtoAny
+	if field, ok := message.Field(1); ok && field.Type == ProtoBytes {
+		wrapped.TypeURL = string(field.Bytes)
+	}
+	if field, ok := message.Field(2); ok && field.Type == ProtoBytes {
+		wrapped.Value = field.Bytes
+	}
+	if wrapped.TypeURL == "" {
+		return ProtoAny{}, errors.New("failed to decode protobuf Any: missing type URL")
+	}
+	return wrapped, nil
+}
+
+// ProtoTypeResolver decodes the value of an Any into a message of the type
+// its type URL names, returning ErrUnknownProtoType for unregistered types.
+// With google.golang.org/protobuf it is typically a thin wrapper around
+// protoregistry.GlobalTypes.FindMessageByURL and proto.Unmarshal.
+type ProtoTypeResolver func(typeURL string, value []byte) (interface{}, error)
+
+// UnmarshalProtoAny returns an UnmarshalFunc decoding a response body that
+// holds a google.protobuf.Any. The WithResolveResponse target may be a
+// *ProtoAny, a *ProtoMessage for the decoded value, or an *interface{}
+// that receives the message resolve produces, falling back to a
+// ProtoMessage for types resolve does not know. resolve may be nil.
+func UnmarshalProtoAny(resolve ProtoTypeResolver) UnmarshalFunc {
+	return func(contentType string, body []byte, target interface{}) error {
+		wrapped, err := DecodeProtoAny(body)
+		if err != nil {
+			return err
+		}
+		switch target := target.(type) {
+		case *ProtoAny:
+			*target = wrapped
+			return nil
+		case *ProtoMessage:
+			*target, err = DecodeProtoMessage(wrapped.Value)
+			return err
+		case *interface{}:
+			if resolve != nil {
+				message, err := resolve(wrapped.TypeURL, wrapped.Value)
+				if err == nil {
+					*target = message
+					return nil
+				}
+				if !errors.Is(err, ErrUnknownProtoType) {
+					return fmt.Errorf("failed to decode %s: %w", wrapped.TypeName(), err)
+				}
+			}
+			message, err := DecodeProtoMessage(wrapped.Value)
+			if err != nil {
+				return err
+			}
+			*target = message
+			return nil
+		default:
+			return fmt.Errorf("unsupported protobuf Any target %T", target)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package httpclientutils_test
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func protoBytesField(number int, value []byte) []byte {
+	data := binary.AppendUvarint(nil, uint64(number)<<3|2)
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+func protoVarintField(number int, value uint64) []byte {
+	return binary.AppendUvarint(binary.AppendUvarint(nil, uint64(number)<<3), value)
+}
+
+func TestUnmarshalProtoAny(t *testing.T) {
+	// A google.rpc.Status{code: 5, message: "not found"} wrapped in an Any.
+	status := append(protoVarintField(1, 5), protoBytesField(2, []byte("not found"))...)
+	body := append(protoBytesField(1, []byte("type.googleapis.com/google.rpc.Status")), protoBytesField(2, status)...)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	type rpcStatus struct{ Code int }
+	resolve := func(typeURL string, value []byte) (interface{}, error) {
+		if typeURL != "type.googleapis.com/google.rpc.Status" {
+			return nil, httpclientutils.ErrUnknownProtoType
+		}
+		message, err := httpclientutils.DecodeProtoMessage(value)
+		if err != nil {
+			return nil, err
+		}
+		code, _ := message.Field(1)
+		return rpcStatus{Code: int(code.Value)}, nil
+	}
+
+	var resolved interface{}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&resolved),
+		httpclientutils.WithUnmarshalFunc(httpclientutils.UnmarshalProtoAny(resolve)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, rpcStatus{Code: 5}, resolved)
+
+	var dynamic interface{}
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&dynamic),
+		httpclientutils.WithUnmarshalFunc(httpclientutils.UnmarshalProtoAny(nil)),
+	)
+	assert.NoError(t, err)
+	if message, ok := dynamic.(httpclientutils.ProtoMessage); assert.True(t, ok) {
+		text, ok := message.Field(2)
+		assert.True(t, ok)
+		assert.Equal(t, "not found", string(text.Bytes))
+	}
+
+	var wrapped httpclientutils.ProtoAny
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&wrapped),
+		httpclientutils.WithUnmarshalFunc(httpclientutils.UnmarshalProtoAny(nil)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "google.rpc.Status", wrapped.TypeName())
+	assert.Equal(t, status, wrapped.Value)
+}
+
+func TestDecodeProtoMessage_Truncated(t *testing.T) {
+	_, err := httpclientutils.DecodeProtoMessage(protoBytesField(1, []byte("abc"))[:3])
+	assert.Error(t, err)
+}
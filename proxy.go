@@ -0,0 +1,36 @@
+package httpclientutils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// proxyFunc returns a Transport.Proxy routing every request through
+// proxyURL, surfacing a malformed URL as the request error.
+func proxyFunc(proxyURL string) func(*http.Request) (*url.URL, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err == nil && parsed.Host == "" {
+		err = fmt.Errorf("missing host in %q", proxyURL)
+	}
+	return func(*http.Request) (*url.URL, error) {
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		return parsed, nil
+	}
+}
+
+// applyProxyAuthorization sends Proxy-Authorization on plain HTTP requests,
+// which the proxy forwards rather than tunnels; HTTPS requests carry it on
+// the CONNECT instead so it never reaches the origin.
+func applyProxyAuthorization(req *http.Request, options *RequestOptions) {
+	if options.Proxy != "" && options.ProxyAuthorization != "" && req.URL.Scheme == "http" {
+		req.Header.Set("Proxy-Authorization", options.ProxyAuthorization)
+	}
+}
+
+func basicCredentials(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
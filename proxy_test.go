@@ -0,0 +1,46 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			assert.Equal(t, "Bearer proxy-token", r.Header.Get("Proxy-Authorization"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "origin.example", r.URL.Host)
+		user, pass, ok := (&http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}}).BasicAuth()
+		assert.True(t, ok)
+		w.Write([]byte("via proxy as " + user + ":" + pass))
+	}))
+	defer proxy.Close()
+
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://origin.example/path"),
+		httpclientutils.WithProxy(proxy.URL),
+		httpclientutils.WithProxyBasicAuth("crawler", "s3cret"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "via proxy as crawler:s3cret", string(body))
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("https://origin.example/path"),
+		httpclientutils.WithProxy(proxy.URL),
+		httpclientutils.WithProxyBearerToken("proxy-token"),
+	)
+	assert.Error(t, err)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://origin.example/path"),
+		httpclientutils.WithProxy("not a url"),
+	)
+	assert.ErrorContains(t, err, "invalid proxy URL")
+}
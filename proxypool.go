@@ -0,0 +1,193 @@
+package httpclientutils
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoProxy is returned when every proxy in a ProxyPool is ejected.
+var ErrNoProxy = errors.New("no healthy proxy available")
+
+// ProxyRotation selects how a ProxyPool picks a proxy for each request.
+type ProxyRotation int
+
+const (
+	// RotateRoundRobin cycles through the healthy proxies in order.
+	RotateRoundRobin ProxyRotation = iota
+	// RotateRandom picks a healthy proxy at random.
+	RotateRandom
+	// RotateStickyPerHost keeps using the same proxy for a target host
+	// until it is ejected.
+	RotateStickyPerHost
+)
+
+// ProxyPoolConfig declares the proxies a ProxyPool rotates across.
+type ProxyPoolConfig struct {
+	Proxies     []string
+	Rotation    ProxyRotation
+	MaxFailures int           // consecutive failures before ejection; defaults to 3
+	EjectFor    time.Duration // how long an ejected proxy sits out; defaults to a minute
+}
+
+// ProxyPool routes requests through a rotating set of proxies. Failures to
+// reach the proxy, refused CONNECTs and 407 responses count as proxy
+// failures, while errors from the target or this side do not; a proxy
+// failing MaxFailures times in a row is ejected for EjectFor, then retried.
+type ProxyPool struct {
+	config ProxyPoolConfig
+
+	mu      sync.Mutex
+	proxies []*pooledProxy
+	next    int
+	sticky  map[string]*pooledProxy
+}
+
+type pooledProxy struct {
+	url          string
+	failures     int
+	ejectedUntil time.Time
+}
+
+// maxStickyHosts bounds the hosts RotateStickyPerHost remembers a proxy
+// for.
+const maxStickyHosts = 1024
+
+// NewProxyPool returns a ProxyPool for config.
+func NewProxyPool(config ProxyPoolConfig) *ProxyPool {
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = 3
+	}
+	if config.EjectFor <= 0 {
+		config.EjectFor = time.Minute
+	}
+	pool := &ProxyPool{config: config, sticky: make(map[string]*pooledProxy)}
+	for _, proxy := range config.Proxies {
+		pool.proxies = append(pool.proxies, &pooledProxy{url: proxy})
+	}
+	return pool
+}
+
+// Healthy returns the proxies currently in rotation.
+func (p *ProxyPool) Healthy() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var healthy []string
+	for _, proxy := range p.healthyLocked(time.Now()) {
+		healthy = append(healthy, proxy.url)
+	}
+	return healthy
+}
+
+func (p *ProxyPool) healthyLocked(now time.Time) []*pooledProxy {
+	var healthy []*pooledProxy
+	for _, proxy := range p.proxies {
+		if !now.Before(proxy.ejectedUntil) {
+			healthy = append(healthy, proxy)
+		}
+	}
+	return healthy
+}
+
+func (p *ProxyPool) pick(host string) (*pooledProxy, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.config.Rotation == RotateStickyPerHost {
+		if proxy, ok := p.sticky[host]; ok && !now.Before(proxy.ejectedUntil) {
+			return proxy, nil
+		}
+	}
+	healthy := p.healthyLocked(now)
+	if len(healthy) == 0 {
+		return nil, ErrNoProxy
+	}
+	var proxy *pooledProxy
+	if p.config.Rotation == RotateRandom {
+		proxy = healthy[rand.IntN(len(healthy))]
+	} else {
+		proxy = healthy[p.next%len(healthy)]
+		p.next++
+	}
+	if p.config.Rotation == RotateStickyPerHost {
+		p.stickLocked(host, proxy, now)
+	}
+	return proxy, nil
+}
+
+// stickLocked pins host to proxy, first forgetting hosts pinned to ejected
+// proxies, then an arbitrary host, once maxStickyHosts are pinned.
+func (p *ProxyPool) stickLocked(host string, proxy *pooledProxy, now time.Time) {
+	if _, ok := p.sticky[host]; !ok && len(p.sticky) >= maxStickyHosts {
+		for pinned, pinnedProxy := range p.sticky {
+			if now.Before(pinnedProxy.ejectedUntil) {
+				delete(p.sticky, pinned)
+			}
+		}
+		for pinned := range p.sticky {
+			if len(p.sticky) < maxStickyHosts {
+				break
+			}
+			delete(p.sticky, pinned)
+		}
+	}
+	p.sticky[host] = proxy
+}
+
+func (p *ProxyPool) report(proxy *pooledProxy, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !failed {
+		proxy.failures = 0
+		return
+	}
+	proxy.failures++
+	if proxy.failures >= p.config.MaxFailures {
+		proxy.failures = 0
+		proxy.ejectedUntil = time.Now().Add(p.config.EjectFor)
+	}
+}
+
+// do sends the request through a proxy picked from the pool and records
+// whether the proxy failed.
+func (p *ProxyPool) do(options *RequestOptions, body io.Reader) (int, http.Header, []byte, error) {
+	var host string
+	if parsed, err := url.Parse(options.URL); err == nil {
+		host = parsed.Hostname()
+	}
+	proxy, err := p.pick(host)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	proxied := *options
+	proxied.Proxy = proxy.url
+	statusCode, header, responseBody, err := roundTrip(&proxied, body)
+	p.report(proxy, proxyFailed(options, statusCode, err))
+	return statusCode, header, responseBody, err
+}
+
+// proxyFailed reports whether a request failed because of its proxy:
+// dialing or handshaking with it failed, it refused the CONNECT tunnel, or
+// it asked for credentials.
+func proxyFailed(options *RequestOptions, statusCode int, err error) bool {
+	if statusCode == http.StatusProxyAuthRequired {
+		return true
+	}
+	if err == nil || options.Context.Err() != nil || errors.Is(err, ErrSSRFBlocked) {
+		return false
+	}
+	// net/http wraps failures to reach the proxy in a "proxyconnect" error,
+	// and reports a refused CONNECT by the status text alone.
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return true
+	}
+	return strings.HasSuffix(err.Error(), http.StatusText(http.StatusProxyAuthRequired))
+}
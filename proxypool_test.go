@@ -0,0 +1,146 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProxy(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + " " + r.URL.Host))
+	}))
+}
+
+func TestProxyPool_EjectsDeadProxy(t *testing.T) {
+	good := newTestProxy("good")
+	defer good.Close()
+	dead := newTestProxy("dead")
+	dead.Close()
+
+	pool := httpclientutils.NewProxyPool(httpclientutils.ProxyPoolConfig{
+		Proxies:     []string{dead.URL, good.URL},
+		MaxFailures: 1,
+	})
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://origin.example/"),
+		httpclientutils.WithProxyPool(pool),
+	)
+	assert.Error(t, err)
+	assert.Equal(t, []string{good.URL}, pool.Healthy())
+
+	for range 3 {
+		_, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL("http://origin.example/"),
+			httpclientutils.WithProxyPool(pool),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "good origin.example", string(body))
+	}
+}
+
+func TestProxyPool_StickyPerHost(t *testing.T) {
+	a := newTestProxy("a")
+	defer a.Close()
+	b := newTestProxy("b")
+	defer b.Close()
+
+	pool := httpclientutils.NewProxyPool(httpclientutils.ProxyPoolConfig{
+		Proxies:  []string{a.URL, b.URL},
+		Rotation: httpclientutils.RotateStickyPerHost,
+	})
+	get := func(host string) string {
+		_, _, body, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL("http://"+host+"/"),
+			httpclientutils.WithProxyPool(pool),
+		)
+		assert.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "a one.example", get("one.example"))
+	assert.Equal(t, "b two.example", get("two.example"))
+	assert.Equal(t, "a one.example", get("one.example"))
+	assert.Equal(t, "b two.example", get("two.example"))
+}
+
+func TestProxyPool_NoHealthyProxy(t *testing.T) {
+	pool := httpclientutils.NewProxyPool(httpclientutils.ProxyPoolConfig{})
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://origin.example/"),
+		httpclientutils.WithProxyPool(pool),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrNoProxy)
+}
+
+// newConnectProxy tunnels CONNECT requests to their target and hands
+// plain proxied requests to direct, if set.
+func newConnectProxy(t *testing.T, direct http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			if direct == nil {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			direct(w, r)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if !assert.NoError(t, err) {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		client, _, _ := http.NewResponseController(w).Hijack()
+		go func() {
+			io.Copy(target, client)
+			target.Close()
+		}()
+		io.Copy(client, target)
+		client.Close()
+	}))
+}
+
+func TestProxyPool_CountsOnlyProxyFailures(t *testing.T) {
+	proxy := newConnectProxy(t, nil)
+	defer proxy.Close()
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer untrusted.Close()
+
+	pool := httpclientutils.NewProxyPool(httpclientutils.ProxyPoolConfig{Proxies: []string{proxy.URL}, MaxFailures: 1})
+
+	// The origin's certificate is not trusted; the proxy did its job.
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(untrusted.URL),
+		httpclientutils.WithProxyPool(pool),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrTLS)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(untrusted.URL),
+		httpclientutils.WithProxyPool(pool),
+		httpclientutils.WithContext(ctx),
+	)
+	assert.Error(t, err)
+	assert.Equal(t, []string{proxy.URL}, pool.Healthy())
+
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer auth.Close()
+	pool = httpclientutils.NewProxyPool(httpclientutils.ProxyPoolConfig{Proxies: []string{auth.URL}, MaxFailures: 1})
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(untrusted.URL),
+		httpclientutils.WithProxyPool(pool),
+	)
+	assert.Error(t, err)
+	assert.Empty(t, pool.Healthy())
+}
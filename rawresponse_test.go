@@ -0,0 +1,65 @@
+package httpclientutils_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRawResponse(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Write([]byte("payload"))
+		w.Header().Set("X-Checksum", "abc")
+	}))
+	defer ts.Close()
+
+	var raw *http.Response
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/path"),
+		httpclientutils.WithTLSConfig(ts.Client().Transport.(*http.Transport).TLSClientConfig),
+		httpclientutils.WithRawResponse(&raw),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(body))
+	if !assert.NotNil(t, raw) {
+		return
+	}
+	assert.Equal(t, "/path", raw.Request.URL.Path)
+	assert.Equal(t, "HTTP/1.1", raw.Proto)
+	assert.NotNil(t, raw.TLS)
+	assert.Equal(t, "abc", raw.Trailer.Get("X-Checksum"))
+	again, err := io.ReadAll(raw.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(again))
+}
+
+func TestWithRawResponse_StreamedBodyIsEmpty(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("payload"))
+	}))
+	defer ts.Close()
+
+	var raw *http.Response
+	var streamed strings.Builder
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveToWriter(&streamed),
+		httpclientutils.WithRawResponse(&raw),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", streamed.String())
+	if !assert.NotNil(t, raw) {
+		return
+	}
+	assert.Equal(t, "text/plain", raw.Header.Get("Content-Type"))
+	rest, err := io.ReadAll(raw.Body)
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+}
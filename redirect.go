@@ -0,0 +1,162 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedirectHop records one redirect response and the request it led to.
+type RedirectHop struct {
+	StatusCode int
+	From       *url.URL
+	To         *url.URL
+	Location   string
+	Header     http.Header
+}
+
+// CrossHost reports whether the hop moved to a different host.
+func (h RedirectHop) CrossHost() bool { return h.From.Host != h.To.Host }
+
+// Downgrade reports whether the hop moved from https to http.
+func (h RedirectHop) Downgrade() bool { return h.From.Scheme == "https" && h.To.Scheme == "http" }
+
+// maxRedirects is net/http's default limit, shared by HTTP and HTML
+// redirects.
+const maxRedirects = 10
+
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+// checkRedirect records each redirect hop into redirects and re-applies the
+// destination policies to it.
+func checkRedirect(options *RequestOptions, redirects *[]RedirectHop) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if options.SeeOther == SeeOtherReturn && req.Response != nil && req.Response.StatusCode == http.StatusSeeOther {
+			return http.ErrUseLastResponse
+		}
+		hop := RedirectHop{From: via[len(via)-1].URL, To: req.URL}
+		if req.Response != nil {
+			hop.StatusCode, hop.Header, hop.Location = req.Response.StatusCode, req.Response.Header, req.Response.Header.Get("Location")
+		}
+		*redirects = append(*redirects, hop)
+
+		if len(*redirects) >= maxRedirects {
+			return errTooManyRedirects
+		}
+		return checkHost(options, req.URL)
+	}
+}
+
+// SeeOtherPolicy decides how a 303 See Other response, typically answering
+// a POST, is handled.
+type SeeOtherPolicy int
+
+const (
+	// SeeOtherFollow GETs the Location and resolves that response, as
+	// net/http does by default.
+	SeeOtherFollow SeeOtherPolicy = iota
+	// SeeOtherReturn returns the 303 response itself, leaving its Location
+	// header to the caller.
+	SeeOtherReturn
+)
+
+// followRedirects GETs the Location of a 201 Created response when
+// options ask for it, and follows <meta http-equiv="refresh"> and Refresh
+// header redirects from a successful HTML response, under the same host
+// policy and hop limit as HTTP redirects. Credentials and cookies are not
+// forwarded to another host, matching net/http.
+func followRedirects(client *http.Client, options *RequestOptions, resp *http.Response, body []byte, redirects *[]RedirectHop) (int, http.Header, []byte, error) {
+	for {
+		location, target := nextLocation(options, resp, body)
+		if target == nil {
+			return resp.StatusCode, resp.Header, body, nil
+		}
+		*redirects = append(*redirects, RedirectHop{StatusCode: resp.StatusCode, From: resp.Request.URL, To: target, Location: location, Header: resp.Header})
+		if len(*redirects) >= maxRedirects {
+			return resp.StatusCode, resp.Header, body, fmt.Errorf("failed to send request: %w", errTooManyRedirects)
+		}
+		if err := checkHost(options, target); err != nil {
+			return 0, nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(resp.Request.Context(), http.MethodGet, target.String(), nil)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header = resp.Request.Header.Clone()
+		req.Header.Del("Content-Type")
+		req.Header.Del("Content-Length")
+		if target.Host != resp.Request.URL.Host {
+			for _, name := range []string{"Authorization", "Cookie", "Www-Authenticate"} {
+				req.Header.Del(name)
+			}
+		}
+
+		next, err := client.Do(req)
+		if err != nil {
+			return sendError(options, err)
+		}
+		body, err = io.ReadAll(next.Body)
+		next.Body.Close()
+		if err != nil {
+			return next.StatusCode, next.Header, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		resp = next
+	}
+}
+
+// nextLocation returns the location resp leads on to, or nil.
+func nextLocation(options *RequestOptions, resp *http.Response, body []byte) (string, *url.URL) {
+	if location := resp.Header.Get("Location"); options.FollowCreated && resp.StatusCode == http.StatusCreated && location != "" {
+		if target, err := resp.Request.URL.Parse(location); err == nil {
+			return location, target
+		}
+	}
+	if options.FollowHTMLRedirects {
+		return htmlRedirect(resp, body)
+	}
+	return "", nil
+}
+
+// htmlRedirect returns the target of a refresh redirect in a 2xx HTML
+// response, or nil. Refreshes of the page itself are not redirects.
+func htmlRedirect(resp *http.Response, body []byte) (string, *url.URL) {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", nil
+	}
+	refresh := resp.Header.Get("Refresh")
+	if refresh == "" && isHTML(resp.Header.Get("Content-Type")) {
+		refresh = ParseHTML(body, "").Meta["refresh"]
+	}
+	location := refreshURL(refresh)
+	if location == "" {
+		return "", nil
+	}
+	target, err := resp.Request.URL.Parse(location)
+	if err != nil || *target == *resp.Request.URL {
+		return "", nil
+	}
+	return location, target
+}
+
+// refreshURL extracts the URL from a refresh value such as
+// "0; url='/next'".
+func refreshURL(refresh string) string {
+	_, rest, ok := strings.Cut(refresh, ";")
+	if !ok {
+		_, rest, ok = strings.Cut(refresh, ",")
+	}
+	if !ok {
+		return ""
+	}
+	rest = strings.TrimSpace(rest)
+	if len(rest) > 4 && strings.EqualFold(rest[:3], "url") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(rest[3:]), "="); found {
+			rest = strings.TrimSpace(value)
+		}
+	}
+	return strings.Trim(rest, `"'`)
+}
@@ -0,0 +1,139 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse_RecordsRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("landed"))
+	}))
+	defer target.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, target.URL+"/final", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	var resp httpclientutils.Response
+	status, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/start"),
+		httpclientutils.WithResponse(&resp),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "landed", string(body))
+	assert.Equal(t, body, resp.Body)
+
+	hops := resp.Redirects()
+	assert.Len(t, hops, 2)
+	assert.Equal(t, http.StatusMovedPermanently, hops[0].StatusCode)
+	assert.Equal(t, "/moved", hops[0].Location)
+	assert.False(t, hops[0].CrossHost())
+	assert.Equal(t, http.StatusFound, hops[1].StatusCode)
+	assert.Equal(t, target.URL+"/final", hops[1].To.String())
+	assert.True(t, hops[1].CrossHost())
+	assert.False(t, hops[1].Downgrade())
+}
+
+func TestWithFollowHTMLRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/start":
+			w.Write([]byte(`<html><head><meta http-equiv="Refresh" content="0; URL='/interstitial'"></head></html>`))
+		case "/interstitial":
+			http.Redirect(w, r, "/header", http.StatusFound)
+		case "/header":
+			w.Header().Set("Refresh", "0;url=/final")
+		case "/final":
+			w.Write([]byte("landed"))
+		case "/loop":
+			w.Write([]byte(`<meta http-equiv="refresh" content="0;url=/loop?n=` + r.URL.Query().Get("n") + `x">`))
+		}
+	}))
+	defer ts.Close()
+
+	var resp httpclientutils.Response
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/start"),
+		httpclientutils.WithFollowHTMLRedirects(),
+		httpclientutils.WithResponse(&resp),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "landed", string(body))
+	if assert.Len(t, resp.Redirects(), 3) {
+		assert.Equal(t, "/interstitial", resp.Redirects()[0].Location)
+		assert.Equal(t, http.StatusFound, resp.Redirects()[1].StatusCode)
+		assert.Equal(t, "/final", resp.Redirects()[2].To.Path)
+	}
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+"/loop"), httpclientutils.WithFollowHTMLRedirects())
+	assert.ErrorContains(t, err, "stopped after 10 redirects")
+
+	// Without the option the refresh page itself is returned.
+	_, _, body, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL + "/start"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Refresh")
+}
+
+func TestWithFollowCreated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/users":
+			w.Header().Set("Location", "/users/7")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			http.Redirect(w, r, "/orders/9", http.StatusSeeOther)
+		case r.Method == http.MethodGet:
+			assert.Empty(t, r.Header.Get("Content-Type"))
+			w.Write([]byte("resource " + r.URL.Path))
+		}
+	}))
+	defer ts.Close()
+
+	status, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/users"),
+		httpclientutils.WithBody(map[string]string{"name": "ada"}),
+		httpclientutils.WithFollowCreated(),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "resource /users/7", string(body))
+
+	status, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/users"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, status)
+
+	status, _, body, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/orders"),
+		httpclientutils.WithSeeOther(httpclientutils.SeeOtherFollow),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "resource /orders/9", string(body))
+
+	status, header, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPost),
+		httpclientutils.WithURL(ts.URL+"/orders"),
+		httpclientutils.WithSeeOther(httpclientutils.SeeOtherReturn),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.Equal(t, "/orders/9", header.Get("Location"))
+}
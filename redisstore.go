@@ -0,0 +1,167 @@
+package httpclientutils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const storeIOTimeout = 5 * time.Second
+
+// storeConn is a lazily dialed connection shared by the network stores.
+// Operations are serialized and the connection is dropped after any error.
+type storeConn struct {
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func (c *storeConn) do(fn func(rw *bufio.ReadWriter) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, storeIOTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", c.addr, err)
+		}
+		c.conn = conn
+		c.rw = bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	}
+
+	c.conn.SetDeadline(time.Now().Add(storeIOTimeout))
+	err := fn(c.rw)
+	if err == nil {
+		err = c.rw.Flush()
+	}
+	if err != nil {
+		c.conn.Close()
+		c.conn, c.rw = nil, nil
+	}
+	return err
+}
+
+// Close closes the underlying connection.
+func (c *storeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.rw = nil, nil
+	return err
+}
+
+// RedisStore is a CacheStore backed by a Redis server, letting several
+// processes share one HTTP cache.
+type RedisStore struct {
+	storeConn
+	prefix string
+}
+
+// NewRedisStore returns a RedisStore talking to addr; every key is
+// prefixed with prefix.
+func NewRedisStore(addr, prefix string) *RedisStore {
+	return &RedisStore{storeConn: storeConn{addr: addr}, prefix: prefix}
+}
+
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.do(func(rw *bufio.ReadWriter) error {
+		reply, err := redisCommand(rw, "GET", s.prefix+key)
+		value, _ = reply.([]byte)
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("redis GET failed: %w", err)
+	}
+	return value, value != nil, nil
+}
+
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", s.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	err := s.do(func(rw *bufio.ReadWriter) error {
+		_, err := redisCommand(rw, args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("redis SET failed: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Delete(key string) error {
+	err := s.do(func(rw *bufio.ReadWriter) error {
+		_, err := redisCommand(rw, "DEL", s.prefix+key)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("redis DEL failed: %w", err)
+	}
+	return nil
+}
+
+// redisCommand writes args as a RESP array and reads a single reply.
+func redisCommand(rw *bufio.ReadWriter, args ...string) (interface{}, error) {
+	fmt.Fprintf(rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+	return readRESP(rw.Reader)
+}
+
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply: %q", line)
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed line: %q", line)
+	}
+	return line[:len(line)-2], nil
+}
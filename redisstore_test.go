@@ -0,0 +1,102 @@
+package httpclientutils_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis serves GET, SET and DEL from an in-memory map.
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readRedisArray(r)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+						} else {
+							fmt.Fprint(conn, "$-1\r\n")
+						}
+					case "SET":
+						data[args[1]] = args[2]
+						fmt.Fprint(conn, "+OK\r\n")
+					case "DEL":
+						delete(data, args[1])
+						fmt.Fprint(conn, ":1\r\n")
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readRedisArray(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisStore_GetSetDelete(t *testing.T) {
+	store := httpclientutils.NewRedisStore(fakeRedis(t), "http:")
+	defer store.Close()
+
+	_, ok, err := store.Get("missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set("key", []byte("value\r\nwith newline"), 0))
+	value, ok, err := store.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value\r\nwith newline", string(value))
+
+	assert.NoError(t, store.Delete("key"))
+	_, ok, err = store.Get("key")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
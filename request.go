@@ -3,20 +3,25 @@ package httpclientutils
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/netip"
 	"strings"
+	"sync"
 	"time"
-
-	"github.com/clbanning/mxj/v2"
 )
 
 // RequestOptions holds the configuration for the HTTP request.
 type RequestOptions struct {
+	Context           context.Context
 	Method            string
 	URL               string
 	Body              interface{}
@@ -27,6 +32,142 @@ type RequestOptions struct {
 	ResolveResp       interface{}
 	XMLToJSON         interface{}
 	DisableEscapeHTML bool
+
+	Cache                *Cache
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+	CacheBypass          bool
+	CacheOnly            bool
+
+	Batcher *Batcher
+
+	Scheduler *Scheduler
+	Priority  Priority
+
+	MinRemainingDeadline time.Duration
+
+	Meta Meta
+
+	TenantPartitions *TenantPartitions
+	Tenant           string
+
+	PropagateTrace bool
+	TraceParent    string
+	TraceState     string
+	B3             string
+
+	Stats *Stats
+
+	EventBus *EventBus
+
+	AuditSink      AuditSink
+	CallerIdentity string
+
+	Scrubber Scrubber
+
+	SSRFPolicy *SSRFPolicy
+
+	AllowedHosts []string
+	DeniedHosts  []string
+
+	Response *Response
+
+	SecretBasicAuth *SecretBasicAuthOptions
+	BearerSecret    SecretProvider
+	SigV4           *SigV4Options
+
+	JWTAssertion *JWTAssertionOptions
+
+	NTLM      *NTLMOptions
+	Negotiate NegotiateProvider
+
+	MessageSignature *MessageSignatureOptions
+
+	Outbox *Outbox
+
+	ResolveWriter      io.Writer
+	ResolveWriterTypes []string
+
+	ResolveHTML         *HTMLDocument
+	FollowHTMLRedirects bool
+
+	RobotsUserAgent string
+	RobotsWarnOnly  bool
+
+	JSONAPITarget   interface{}
+	JSONAPIDocument *JSONAPIDocument
+
+	UnixSocket string
+
+	Kubernetes *KubernetesInCluster
+
+	DryRun *PreparedRequest
+
+	UnmarshalFunc UnmarshalFunc
+
+	XMLOptions *XMLOptions
+	XMLStream  *XMLStream
+
+	RequestTrailers []RequestTrailer
+
+	AddressFamily AddressFamily
+	FallbackDelay time.Duration
+
+	DoHResolver string
+	DoTResolver string
+
+	LocalAddr        net.IP
+	NetworkInterface string
+
+	Proxy              string
+	ProxyAuthorization string
+	ProxyPool          *ProxyPool
+
+	Attempt int
+
+	OnPanic func(*PanicError)
+
+	RawResponse **http.Response
+
+	CertExpiry *CertExpiryMonitor
+
+	HSTS       *HSTSPolicy
+	RequireTLS bool
+
+	RevocationCheck *RevocationCheck
+
+	TLSProfile TLSProfile
+
+	ECHConfigList []byte
+
+	TLSServerName         string
+	VerifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+	VerifyConnection      func(tls.ConnectionState) error
+
+	JSONKeys    KeyTransform
+	JSONNumbers NumberDecoder
+	TimeLayouts []string
+
+	Envelope *Envelope
+
+	ResumeBrokenBody int
+
+	FollowCreated bool
+	SeeOther      SeeOtherPolicy
+
+	DuplicateGuard *DuplicateGuard
+
+	TokenRefresher *TokenRefresher
+
+	CSRF *CSRF
+
+	CookieJar http.CookieJar
+
+	SLO *SLOTracker
+
+	// sessionAuth is the Authorization a Session sends, confined to the
+	// origin it logged in to.
+	sessionAuth *scopedAuthorization
 }
 
 // BasicAuthOptions holds the username and password for basic authentication.
@@ -35,11 +176,22 @@ type BasicAuthOptions struct {
 	Password string
 }
 
+// SecretBasicAuthOptions holds the username and password provider for basic
+// authentication with a password fetched at request time.
+type SecretBasicAuthOptions struct {
+	Username string
+	Password SecretProvider
+}
+
 // Option is a functional option for configuring RequestOptions.
 type Option func(*RequestOptions)
 
 // Functional option setters
 
+func WithContext(ctx context.Context) Option {
+	return func(opts *RequestOptions) { opts.Context = ctx }
+}
+
 func WithMethod(method string) Option { return func(opts *RequestOptions) { opts.Method = method } }
 
 func WithURL(url string) Option { return func(opts *RequestOptions) { opts.URL = url } }
@@ -61,63 +213,585 @@ func WithBasicAuth(username, password string) Option {
 func WithResolveResponse(resp interface{}) Option {
 	return func(opts *RequestOptions) { opts.ResolveResp = resp }
 }
+func WithXMLOptions(xmlOptions XMLOptions) Option {
+	return func(opts *RequestOptions) { opts.XMLOptions = &xmlOptions }
+}
+func WithXMLStream(element string, handle XMLElementFunc) Option {
+	return func(opts *RequestOptions) { opts.XMLStream = &XMLStream{Element: element, Handle: handle} }
+}
+func WithUnmarshalFunc(unmarshal UnmarshalFunc) Option {
+	return func(opts *RequestOptions) { opts.UnmarshalFunc = unmarshal }
+}
 func WithResolveXMLToJSON(resp interface{}) Option {
 	return func(opts *RequestOptions) { opts.XMLToJSON = resp }
 }
 func WithDisableEscapeHTML(disable bool) Option {
 	return func(opts *RequestOptions) { opts.DisableEscapeHTML = disable }
 }
+func WithCache(cache *Cache) Option { return func(opts *RequestOptions) { opts.Cache = cache } }
+
+func WithStaleWhileRevalidate(window time.Duration) Option {
+	return func(opts *RequestOptions) { opts.StaleWhileRevalidate = window }
+}
+func WithStaleIfError(window time.Duration) Option {
+	return func(opts *RequestOptions) { opts.StaleIfError = window }
+}
+func WithCacheBypass() Option { return func(opts *RequestOptions) { opts.CacheBypass = true } }
+func WithCacheOnly() Option   { return func(opts *RequestOptions) { opts.CacheOnly = true } }
+func WithBatcher(batcher *Batcher) Option {
+	return func(opts *RequestOptions) { opts.Batcher = batcher }
+}
+func WithScheduler(scheduler *Scheduler) Option {
+	return func(opts *RequestOptions) { opts.Scheduler = scheduler }
+}
+func WithPriority(priority Priority) Option {
+	return func(opts *RequestOptions) { opts.Priority = priority }
+}
+func WithMinRemainingDeadline(d time.Duration) Option {
+	return func(opts *RequestOptions) { opts.MinRemainingDeadline = d }
+}
+func WithMeta(key, value string) Option {
+	return func(opts *RequestOptions) {
+		if opts.Meta == nil {
+			opts.Meta = make(Meta)
+		}
+		opts.Meta[key] = value
+	}
+}
+func WithTenantPartitions(partitions *TenantPartitions) Option {
+	return func(opts *RequestOptions) { opts.TenantPartitions = partitions }
+}
+func WithTenant(id string) Option { return func(opts *RequestOptions) { opts.Tenant = id } }
+
+func WithTracePropagation() Option { return func(opts *RequestOptions) { opts.PropagateTrace = true } }
+
+func WithTraceParent(traceparent, tracestate string) Option {
+	return func(opts *RequestOptions) { opts.TraceParent, opts.TraceState = traceparent, tracestate }
+}
+func WithB3(b3 string) Option { return func(opts *RequestOptions) { opts.B3 = b3 } }
+
+func WithStats(stats *Stats) Option { return func(opts *RequestOptions) { opts.Stats = stats } }
+
+func WithEventBus(bus *EventBus) Option { return func(opts *RequestOptions) { opts.EventBus = bus } }
+
+func WithAuditLog(sink AuditSink) Option { return func(opts *RequestOptions) { opts.AuditSink = sink } }
+
+func WithCallerIdentity(identity string) Option {
+	return func(opts *RequestOptions) { opts.CallerIdentity = identity }
+}
+func WithScrubber(scrubbers ...Scrubber) Option {
+	return func(opts *RequestOptions) { opts.Scrubber = ChainScrubber(scrubbers) }
+}
+func WithSSRFProtection(allowed ...netip.Prefix) Option {
+	return func(opts *RequestOptions) { opts.SSRFPolicy = &SSRFPolicy{Allowed: allowed} }
+}
+func WithAllowedHosts(patterns ...string) Option {
+	return func(opts *RequestOptions) { opts.AllowedHosts = append(opts.AllowedHosts, patterns...) }
+}
+func WithDeniedHosts(patterns ...string) Option {
+	return func(opts *RequestOptions) { opts.DeniedHosts = append(opts.DeniedHosts, patterns...) }
+}
+func WithResponse(resp *Response) Option { return func(opts *RequestOptions) { opts.Response = resp } }
+
+func WithBasicAuthFromSecret(username string, password SecretProvider) Option {
+	return func(opts *RequestOptions) {
+		opts.SecretBasicAuth = &SecretBasicAuthOptions{Username: username, Password: password}
+	}
+}
+func WithBearerFromSecret(token SecretProvider) Option {
+	return func(opts *RequestOptions) { opts.BearerSecret = token }
+}
+func WithSigV4(sigv4 SigV4Options) Option { return func(opts *RequestOptions) { opts.SigV4 = &sigv4 } }
+
+func WithJWTAssertionAuth(key crypto.Signer, claims JWTClaims) Option {
+	return func(opts *RequestOptions) { opts.JWTAssertion = &JWTAssertionOptions{Key: key, Claims: claims} }
+}
+func WithNTLMAuth(domain, username, password string) Option {
+	return func(opts *RequestOptions) {
+		opts.NTLM = &NTLMOptions{Domain: domain, Username: username, Password: password}
+	}
+}
+func WithSPNEGO(provider NegotiateProvider) Option {
+	return func(opts *RequestOptions) { opts.Negotiate = provider }
+}
+func WithMessageSignature(signature MessageSignatureOptions) Option {
+	return func(opts *RequestOptions) { opts.MessageSignature = &signature }
+}
+func WithOutbox(outbox *Outbox) Option { return func(opts *RequestOptions) { opts.Outbox = outbox } }
+func WithResolveToWriter(w io.Writer, allowedTypes ...string) Option {
+	return func(opts *RequestOptions) { opts.ResolveWriter, opts.ResolveWriterTypes = w, allowedTypes }
+}
+func WithResolveHTML(doc *HTMLDocument) Option {
+	return func(opts *RequestOptions) { opts.ResolveHTML = doc }
+}
+func WithFollowHTMLRedirects() Option {
+	return func(opts *RequestOptions) { opts.FollowHTMLRedirects = true }
+}
+func WithRespectRobotsTxt(userAgent string) Option {
+	return func(opts *RequestOptions) { opts.RobotsUserAgent = userAgent }
+}
+func WithRobotsTxtWarnOnly() Option { return func(opts *RequestOptions) { opts.RobotsWarnOnly = true } }
+func WithResolveJSONAPI(target interface{}, doc *JSONAPIDocument) Option {
+	return func(opts *RequestOptions) { opts.JSONAPITarget, opts.JSONAPIDocument = target, doc }
+}
+func WithUnixSocket(path string) Option { return func(opts *RequestOptions) { opts.UnixSocket = path } }
+func WithAddressFamily(family AddressFamily) Option {
+	return func(opts *RequestOptions) { opts.AddressFamily = family }
+}
+func WithFallbackDelay(delay time.Duration) Option {
+	return func(opts *RequestOptions) { opts.FallbackDelay = delay }
+}
+func WithDoHResolver(serverURL string) Option {
+	return func(opts *RequestOptions) { opts.DoHResolver = serverURL }
+}
+func WithDoTResolver(addr string) Option {
+	return func(opts *RequestOptions) { opts.DoTResolver = addr }
+}
+func WithLocalAddr(ip net.IP) Option {
+	return func(opts *RequestOptions) { opts.LocalAddr = ip }
+}
+func WithNetworkInterface(name string) Option {
+	return func(opts *RequestOptions) { opts.NetworkInterface = name }
+}
+func WithProxy(proxyURL string) Option {
+	return func(opts *RequestOptions) { opts.Proxy = proxyURL }
+}
+func WithProxyBasicAuth(username, password string) Option {
+	return func(opts *RequestOptions) { opts.ProxyAuthorization = basicCredentials(username, password) }
+}
+func WithProxyBearerToken(token string) Option {
+	return func(opts *RequestOptions) { opts.ProxyAuthorization = "Bearer " + token }
+}
+func WithProxyPool(pool *ProxyPool) Option {
+	return func(opts *RequestOptions) { opts.ProxyPool = pool }
+}
+func WithAttempt(attempt int) Option {
+	return func(opts *RequestOptions) { opts.Attempt = attempt }
+}
+func WithOnPanic(hook func(*PanicError)) Option {
+	return func(opts *RequestOptions) { opts.OnPanic = hook }
+}
+func WithRawResponse(resp **http.Response) Option {
+	return func(opts *RequestOptions) { opts.RawResponse = resp }
+}
+func WithCertExpiryMonitor(monitor *CertExpiryMonitor) Option {
+	return func(opts *RequestOptions) { opts.CertExpiry = monitor }
+}
+func WithHSTS(policy *HSTSPolicy) Option {
+	return func(opts *RequestOptions) { opts.HSTS = policy }
+}
+func WithRequireTLS() Option {
+	return func(opts *RequestOptions) { opts.RequireTLS = true }
+}
+func WithRevocationCheck(check RevocationCheck) Option {
+	return func(opts *RequestOptions) { opts.RevocationCheck = &check }
+}
+func WithTLSProfile(profile TLSProfile) Option {
+	return func(opts *RequestOptions) { opts.TLSProfile = profile }
+}
+func WithECH(configList []byte) Option {
+	return func(opts *RequestOptions) { opts.ECHConfigList = configList }
+}
+func WithTLSServerName(name string) Option {
+	return func(opts *RequestOptions) { opts.TLSServerName = name }
+}
+func WithVerifyPeerCertificate(verify func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error) Option {
+	return func(opts *RequestOptions) { opts.VerifyPeerCertificate = verify }
+}
+func WithVerifyConnection(verify func(tls.ConnectionState) error) Option {
+	return func(opts *RequestOptions) { opts.VerifyConnection = verify }
+}
+func WithJSONKeys(transform KeyTransform) Option {
+	return func(opts *RequestOptions) { opts.JSONKeys = transform }
+}
+func WithJSONNumbers(decode NumberDecoder) Option {
+	return func(opts *RequestOptions) { opts.JSONNumbers = decode }
+}
+func WithTimeLayouts(layouts ...string) Option {
+	return func(opts *RequestOptions) { opts.TimeLayouts = layouts }
+}
+func WithEnvelope(dataField, errorField string) Option {
+	return func(opts *RequestOptions) { opts.Envelope = &Envelope{Data: dataField, Error: errorField} }
+}
+func WithResumeBrokenBody(attempts int) Option {
+	return func(opts *RequestOptions) { opts.ResumeBrokenBody = attempts }
+}
+func WithFollowCreated() Option {
+	return func(opts *RequestOptions) { opts.FollowCreated = true }
+}
+func WithSeeOther(policy SeeOtherPolicy) Option {
+	return func(opts *RequestOptions) { opts.SeeOther = policy }
+}
+func WithDuplicateGuard(guard *DuplicateGuard) Option {
+	return func(opts *RequestOptions) { opts.DuplicateGuard = guard }
+}
+func WithTokenRefresh(refresher *TokenRefresher) Option {
+	return func(opts *RequestOptions) { opts.TokenRefresher = refresher }
+}
+func WithCSRF(csrf *CSRF) Option { return func(opts *RequestOptions) { opts.CSRF = csrf } }
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(opts *RequestOptions) { opts.CookieJar = jar }
+}
+func WithSLO(tracker *SLOTracker) Option { return func(opts *RequestOptions) { opts.SLO = tracker } }
+func WithKubernetesInCluster() Option {
+	return func(opts *RequestOptions) { opts.Kubernetes = &KubernetesInCluster{} }
+}
+func WithKubernetes(config KubernetesInCluster) Option {
+	return func(opts *RequestOptions) { opts.Kubernetes = &config }
+}
+func WithRequestTrailer(name string, value func() string) Option {
+	return func(opts *RequestOptions) {
+		opts.RequestTrailers = append(opts.RequestTrailers, RequestTrailer{Name: name, Value: value})
+	}
+}
+func WithDryRun(prepared *PreparedRequest) Option {
+	return func(opts *RequestOptions) { opts.DryRun = prepared }
+}
 
 // MakeHTTPRequest sends an HTTP request with the provided options.
 func MakeHTTPRequest(opts ...Option) (int, http.Header, []byte, error) {
-	options := &RequestOptions{Method: http.MethodGet, Headers: make(map[string]string)}
+	return makeHTTPRequest(newRequestOptions(opts))
+}
+
+// makeHTTPRequest sends the request described by options, which it owns.
+func makeHTTPRequest(options *RequestOptions) (int, http.Header, []byte, error) {
+	if len(options.Meta) > 0 {
+		options.Context = context.WithValue(options.Context, metaContextKey{}, options.Meta)
+	}
+	if options.Response != nil {
+		*options.Response = Response{}
+	}
+
+	start := time.Now()
+	options.Stats.request()
+	options.EventBus.publish(RequestStarted{Method: options.Method, URL: scrubText(options, options.URL), Meta: options.Meta, Time: start})
+
+	statusCode, header, responseBody, err := safeExecute(options)
+
+	options.Stats.result(statusCode, err)
+	options.SLO.record(options, statusCode, err, time.Since(start))
+	if err != nil {
+		options.EventBus.publish(RequestFailed{Method: options.Method, URL: scrubText(options, options.URL), Meta: options.Meta, Err: scrubError(options, err), Duration: time.Since(start)})
+	} else {
+		options.EventBus.publish(ResponseReceived{Method: options.Method, URL: scrubText(options, options.URL), Meta: options.Meta, StatusCode: statusCode, Duration: time.Since(start)})
+	}
+	if options.Response != nil {
+		options.Response.StatusCode, options.Response.Header, options.Response.Body = statusCode, header, responseBody
+		options.Response.url = options.URL
+	}
+	if err != nil {
+		err = &RequestError{Err: err, statusCode: statusCode, attempt: options.Attempt}
+	}
+	if err != nil && len(options.Meta) > 0 {
+		err = &MetaError{Meta: options.Meta, Err: err}
+	}
+	return statusCode, header, responseBody, err
+}
+
+// newRequestOptions applies opts over the defaults.
+func newRequestOptions(opts []Option) *RequestOptions {
+	options := &RequestOptions{Context: context.Background(), Method: http.MethodGet, Headers: make(map[string]string)}
 	for _, opt := range opts {
 		opt(options)
 	}
+	return options
+}
+
+// detachedOptions returns a copy of options for a request sent on the
+// caller's behalf but not awaited by it, such as a background cache
+// refresh, with every output the caller owns cleared.
+func detachedOptions(options *RequestOptions) *RequestOptions {
+	detached := *options
+	detached.Response = nil
+	detached.RawResponse = nil
+	detached.DryRun = nil
+	detached.ResolveResp = nil
+	detached.ResolveWriter = nil
+	detached.ResolveHTML = nil
+	detached.XMLStream = nil
+	detached.JSONAPITarget = nil
+	detached.JSONAPIDocument = nil
+	return &detached
+}
+
+// execute runs the request pipeline for fully configured options.
+func execute(options *RequestOptions) (int, http.Header, []byte, error) {
+	var (
+		statusCode   int
+		header       http.Header
+		responseBody []byte
+		err          error
+	)
+	if options.Kubernetes != nil {
+		if err := options.Kubernetes.apply(options); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	if options.CSRF != nil {
+		if err := options.CSRF.apply(options); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	if options.DuplicateGuard != nil {
+		if err := options.DuplicateGuard.check(options); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	switch {
+	case options.DryRun != nil:
+		// Nothing comes back to batch, cache or resolve.
+		return send(options)
+	case options.Outbox != nil:
+		return options.Outbox.enqueue(options)
+	case options.Batcher != nil && options.Batcher.accepts(options):
+		statusCode, header, responseBody, err = options.Batcher.do(options)
+	case options.Cache != nil && options.ResolveWriter == nil && options.XMLStream == nil:
+		statusCode, header, responseBody, err = options.Cache.do(options)
+	default:
+		statusCode, header, responseBody, err = send(options)
+	}
+	if options.CSRF != nil {
+		options.CSRF.observe(options, statusCode)
+	}
+	if err != nil {
+		return statusCode, header, responseBody, err
+	}
+
+	if options.ResolveResp != nil && options.UnmarshalFunc != nil {
+		if err := options.UnmarshalFunc(header.Get("Content-Type"), responseBody, options.ResolveResp); err != nil {
+			return statusCode, header, responseBody, fmt.Errorf("failed to resolve response: %w", err)
+		}
+	} else if options.ResolveResp != nil {
+		if err := resolveResponse(header.Get("Content-Type"), responseBody, options.ResolveResp, options.XMLToJSON, options); err != nil {
+			return statusCode, header, responseBody, fmt.Errorf("failed to resolve response: %w", err)
+		}
+	}
+	if options.JSONAPITarget != nil || options.JSONAPIDocument != nil {
+		if err := resolveJSONAPI(header.Get("Content-Type"), responseBody, options); err != nil {
+			return statusCode, header, responseBody, fmt.Errorf("failed to resolve response: %w", err)
+		}
+	}
+	if options.ResolveHTML != nil {
+		if !isHTML(header.Get("Content-Type")) {
+			return statusCode, header, responseBody, fmt.Errorf("failed to resolve response: %w", ErrNotHTML)
+		}
+		*options.ResolveHTML = *ParseHTML(responseBody, options.URL)
+	}
+
+	return statusCode, header, responseBody, nil
+}
+
+// send performs the network round trip described by options.
+func send(options *RequestOptions) (int, http.Header, []byte, error) {
+	if Offline() && options.DryRun == nil {
+		return 0, nil, nil, ErrOffline
+	}
+	if err := checkDeadline(options); err != nil {
+		return 0, nil, nil, err
+	}
+	if options.DryRun == nil {
+		if err := checkRobots(options); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	if scheduler := schedulerFor(options); scheduler != nil {
+		if err := scheduler.acquire(options.Context, options.Priority); err != nil {
+			return 0, nil, nil, err
+		}
+		defer scheduler.release()
+		if err := checkDeadline(options); err != nil {
+			return 0, nil, nil, err
+		}
+	}
 
 	body, err := prepareBody(options.Body, options.DisableEscapeHTML)
 	if err != nil {
 		return 0, nil, nil, fmt.Errorf("failed to prepare request body: %w", err)
 	}
 
+	audit, body, err := startAudit(options, body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to prepare request body: %w", err)
+	}
+	statusCode, header, responseBody, err := roundTrip(options, body)
+	audit.finish(statusCode, header, responseBody, err)
+	return statusCode, header, responseBody, err
+}
+
+// wrapTransport layers the TLS policy, session credentials, token refresh
+// and challenge auth the request asks for over transport.
+func wrapTransport(options *RequestOptions, transport http.RoundTripper) http.RoundTripper {
+	return wrapTLSPolicy(options, wrapSessionAuth(options, wrapTokenRefresh(options, wrapAuthTransport(options, transport))))
+}
+
+// roundTrip sends body to the target and reads the full response.
+func roundTrip(options *RequestOptions, body io.Reader) (int, http.Header, []byte, error) {
+	if options.ProxyPool != nil && options.Proxy == "" && options.DryRun == nil {
+		return options.ProxyPool.do(options, body)
+	}
+	var redirects []RedirectHop
+	transport := transportFor(options)
+	if !pooledTransport(options) {
+		// The transport is private to this request; without this its idle
+		// keep-alive connections, and their goroutines, would never close.
+		if t, ok := transport.(interface{ CloseIdleConnections() }); ok {
+			defer t.CloseIdleConnections()
+		}
+	}
+	if options.DryRun != nil {
+		transport = &dryRunTransport{prepared: options.DryRun}
+	}
 	client := &http.Client{
-		Transport: &http.Transport{TLSClientConfig: options.TLSConfig},
-		Timeout:   options.Timeout,
+		Transport:     wrapTransport(options, transport),
+		CheckRedirect: checkRedirect(options, &redirects),
+		Jar:           options.CookieJar,
+		Timeout:       options.Timeout,
+	}
+	if options.Response != nil {
+		defer func() { options.Response.redirects = redirects }()
+	}
+
+	ctx, done := options.Stats.trace(options.Context)
+	defer done()
+	if options.Response != nil {
+		// Dials race under Happy Eyeballs, so failures arrive concurrently.
+		var mu sync.Mutex
+		var failed []string
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			ConnectDone: func(_, addr string, err error) {
+				if err != nil {
+					mu.Lock()
+					failed = append(failed, addr)
+					mu.Unlock()
+				}
+			},
+			GotConn: func(info httptrace.GotConnInfo) {
+				conn := newConnInfo(info)
+				mu.Lock()
+				conn.FailedAddrs = append([]string(nil), failed...)
+				mu.Unlock()
+				options.Response.Conn = conn
+			},
+		})
+	}
+	if options.CertExpiry != nil {
+		ctx = httptrace.WithClientTrace(ctx, options.CertExpiry.trace(options))
 	}
 
-	req, err := http.NewRequest(options.Method, options.URL, body)
+	req, err := http.NewRequestWithContext(ctx, options.Method, options.URL, body)
 	if err != nil {
 		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if err := checkHost(options, req.URL); err != nil {
+		return 0, nil, nil, err
+	}
 
-	for key, value := range options.Headers {
-		req.Header.Set(key, value)
+	if err := applyRequestHeaders(req, options); err != nil {
+		return 0, nil, nil, err
 	}
-	if options.BasicAuth != nil {
-		req.SetBasicAuth(options.BasicAuth.Username, options.BasicAuth.Password)
+	if len(options.RequestTrailers) > 0 {
+		declareTrailers(req, options.RequestTrailers)
 	}
 
+	sent := time.Now()
 	resp, err := client.Do(req)
+	if errors.Is(err, errDryRun) {
+		return 0, nil, nil, nil
+	}
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			return http.StatusRequestTimeout, nil, nil, fmt.Errorf("request timed out: %w", err)
-		}
-		return 0, nil, nil, fmt.Errorf("failed to send request: %w", err)
+		return sendError(options, err)
 	}
 	defer resp.Body.Close()
+	buffered := false
+	if options.RawResponse != nil {
+		*options.RawResponse = resp
+		// A body that was streamed, or failed to read, is closed by the
+		// time the caller sees it; leave an empty one rather than that.
+		defer func() {
+			if !buffered {
+				resp.Body = http.NoBody
+			}
+		}()
+	}
+	if options.Response != nil && resp.TLS != nil {
+		options.Response.TLS = newTLSInfo(resp.TLS)
+	}
 
+	if options.ResolveWriter != nil {
+		return resp.StatusCode, resp.Header, nil, streamResponse(resp, options)
+	}
+	if options.XMLStream != nil && resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp.StatusCode, resp.Header, nil, options.XMLStream.run(resp.Body)
+	}
 	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil && options.ResumeBrokenBody > 0 {
+		var deadline time.Time
+		if options.Timeout > 0 {
+			deadline = sent.Add(options.Timeout)
+		}
+		responseBody, err = resumeBody(client, req, resp, responseBody, err, options.ResumeBrokenBody, deadline)
+	}
 	if err != nil {
 		return resp.StatusCode, resp.Header, nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if options.Response != nil && len(resp.Trailer) > 0 {
+		options.Response.Trailer = resp.Trailer
+	}
+	if options.RawResponse != nil {
+		// The deferred Close still applies to the original body.
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+		buffered = true
+	}
+
+	if options.FollowHTMLRedirects || options.FollowCreated {
+		return followRedirects(client, options, resp, responseBody, &redirects)
+	}
+	return resp.StatusCode, resp.Header, responseBody, nil
+}
 
-	if options.ResolveResp != nil {
-		if err := resolveResponse(resp.Header.Get("Content-Type"), responseBody, options.ResolveResp, options.XMLToJSON); err != nil {
-			return resp.StatusCode, resp.Header, responseBody, fmt.Errorf("failed to resolve response: %w", err)
+// applyRequestHeaders sets headers, credentials and signatures on req.
+// Signing runs last so it covers every other header.
+func applyRequestHeaders(req *http.Request, options *RequestOptions) error {
+	if contentType := bodyContentType(options); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, value := range options.Headers {
+		req.Header.Set(key, value)
+	}
+	if options.RobotsUserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", options.RobotsUserAgent)
+	}
+	if options.BasicAuth != nil {
+		req.SetBasicAuth(options.BasicAuth.Username, options.BasicAuth.Password)
+	}
+	applyTraceHeaders(req, options)
+	applyProxyAuthorization(req, options)
+	if err := applySecrets(req, options); err != nil {
+		return err
+	}
+	if err := applyJWTAssertion(req, options); err != nil {
+		return err
+	}
+	if options.SigV4 != nil {
+		if err := SignSigV4(req, *options.SigV4, time.Now()); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
 		}
 	}
+	if options.MessageSignature != nil {
+		if err := SignMessage(req, *options.MessageSignature, time.Now()); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+	return nil
+}
 
-	return resp.StatusCode, resp.Header, responseBody, nil
+func schedulerFor(options *RequestOptions) *Scheduler {
+	if options.TenantPartitions != nil {
+		if scheduler := options.TenantPartitions.scheduler(options.Tenant); scheduler != nil {
+			return scheduler
+		}
+	}
+	return options.Scheduler
 }
 
 func prepareBody(body interface{}, disableEscapeHTML bool) (io.Reader, error) {
@@ -129,6 +803,14 @@ func prepareBody(body interface{}, disableEscapeHTML bool) (io.Reader, error) {
 		return strings.NewReader(v), nil
 	case []byte:
 		return bytes.NewReader(v), nil
+	case io.Reader:
+		return v, nil
+	case *EncodedBody:
+		data, err := v.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
 	default:
 		var buf bytes.Buffer
 		enc := json.NewEncoder(&buf)
@@ -140,16 +822,48 @@ func prepareBody(body interface{}, disableEscapeHTML bool) (io.Reader, error) {
 	}
 }
 
-func resolveResponse(contentType string, body []byte, resolveResp, xmlToJson interface{}) error {
+// replayableBody reports whether body can be prepared more than once; a
+// streamed io.Reader is consumed by the first send.
+func replayableBody(body interface{}) bool {
+	switch body.(type) {
+	case *EncodedBody, string, []byte:
+		return true
+	case io.Reader:
+		return false
+	}
+	return true
+}
+
+// UnmarshalFunc decodes a response body into the WithResolveResponse
+// target in place of the built-in JSON and XML resolver.
+type UnmarshalFunc func(contentType string, body []byte, target interface{}) error
+
+func resolveResponse(contentType string, body []byte, resolveResp, xmlToJson interface{}, options *RequestOptions) error {
 	contentType = strings.Split(contentType, ";")[0]
 
 	switch {
-	case strings.Contains(contentType, "application/json"):
-		if err := json.Unmarshal(body, resolveResp); err != nil {
+	case strings.Contains(contentType, "application/json") || strings.HasSuffix(contentType, "+json"):
+		var err error
+		if options.Envelope != nil {
+			if body, err = options.Envelope.unwrap(body); err != nil || body == nil {
+				return err
+			}
+		}
+		if options.JSONKeys != nil {
+			if body, err = transformJSONKeys(body, options.JSONKeys); err != nil {
+				return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+			}
+		}
+		if len(options.TimeLayouts) > 0 {
+			if body, err = coerceTimes(body, resolveResp, options.TimeLayouts); err != nil {
+				return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+			}
+		}
+		if err := unmarshalTarget(body, resolveResp, options.JSONNumbers); err != nil {
 			return fmt.Errorf("failed to unmarshal JSON response: %w", err)
 		}
 	case strings.Contains(contentType, "application/xml"):
-		m, err := mxj.NewMapXml(body)
+		m, err := options.XMLOptions.convert(body)
 		if err != nil {
 			return fmt.Errorf("failed to parse XML response: %w", err)
 		}
@@ -163,7 +877,7 @@ func resolveResponse(contentType string, body []byte, resolveResp, xmlToJson int
 			}
 		}
 		if resolveResp != nil {
-			return json.Unmarshal(jsonData, resolveResp)
+			return unmarshalTarget(jsonData, resolveResp, options.JSONNumbers)
 		}
 	default:
 		return fmt.Errorf("unsupported content type: %s", contentType)
@@ -6,6 +6,7 @@ import (
 	"github.com/InheritxSolution/httpclientutils"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	_ "time"
 
@@ -134,3 +135,28 @@ func TestMakeHTTPRequest_ResolveJSONResponse(t *testing.T) {
 	assert.Equal(t, http.StatusOK, status)
 	assert.Equal(t, mockResponse, result)
 }
+
+func TestMakeHTTPRequest_UnmarshalFunc(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/x-vendor")
+		w.Write([]byte("name=widget;qty=3"))
+	}))
+	defer ts.Close()
+
+	result := map[string]string{}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&result),
+		httpclientutils.WithUnmarshalFunc(func(contentType string, body []byte, target interface{}) error {
+			assert.Equal(t, "text/x-vendor", contentType)
+			fields := *target.(*map[string]string)
+			for _, pair := range strings.Split(string(body), ";") {
+				key, value, _ := strings.Cut(pair, "=")
+				fields[key] = value
+			}
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "widget", "qty": "3"}, result)
+}
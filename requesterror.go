@@ -0,0 +1,62 @@
+package httpclientutils
+
+import (
+	"errors"
+	"net"
+)
+
+// RequestError wraps every error returned by MakeHTTPRequest with what
+// retry and alerting logic needs to decide on it, so callers need not match
+// error messages.
+type RequestError struct {
+	Err        error
+	statusCode int
+	attempt    int
+}
+
+func (e *RequestError) Error() string { return e.Err.Error() }
+
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// StatusCode returns the status of the response the request failed on, or
+// zero when no response was received.
+func (e *RequestError) StatusCode() int { return e.statusCode }
+
+// Attempt returns the attempt number set with WithAttempt, starting at one.
+func (e *RequestError) Attempt() int { return max(e.attempt, 1) }
+
+// Temporary reports whether the condition behind the failure is expected to
+// clear on its own: timeouts, resets, refused or failing dials, temporary
+// DNS failures, load shedding, offline mode and 408, 429 or 5xx responses.
+func (e *RequestError) Temporary() bool {
+	return errors.Is(e.Err, ErrOffline) || e.Retryable()
+}
+
+// Retryable reports whether sending the same request again may succeed.
+// Canceled requests, TLS and name-not-found failures and errors raised
+// before sending, e.g. policy denials or body encoding, are not retryable.
+func (e *RequestError) Retryable() bool {
+	var (
+		transportErr *TransportError
+		dnsErr       *net.DNSError
+		opErr        *net.OpError
+		shedErr      *ShedError
+	)
+	if errors.Is(e.Err, ErrSSRFBlocked) {
+		// Refused by the dialer's policy, which surfaces as a dial error.
+		return false
+	}
+	if errors.As(e.Err, &transportErr) {
+		switch transportErr.Kind() {
+		case KindTimeout, KindConnReset:
+			return true
+		case KindDNS:
+			return errors.As(e.Err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+		}
+		return false
+	}
+	if e.statusCode != 0 {
+		return retryableStatus(e.statusCode)
+	}
+	return errors.As(e.Err, &opErr) || errors.As(e.Err, &shedErr)
+}
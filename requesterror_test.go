@@ -0,0 +1,59 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not json"))
+	}))
+	defer ts.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var target struct{}
+	tests := []struct {
+		name      string
+		opts      []httpclientutils.Option
+		status    int
+		retryable bool
+		temporary bool
+	}{
+		{"timeout", []httpclientutils.Option{httpclientutils.WithURL(ts.URL + "/slow"), httpclientutils.WithTimeout(20 * time.Millisecond)},
+			http.StatusRequestTimeout, true, true},
+		{"refused", []httpclientutils.Option{httpclientutils.WithURL(closed.URL)}, 0, true, true},
+		{"canceled", []httpclientutils.Option{httpclientutils.WithURL(ts.URL), httpclientutils.WithContext(canceled)}, 0, false, false},
+		{"decode", []httpclientutils.Option{httpclientutils.WithURL(ts.URL), httpclientutils.WithResolveResponse(&target)},
+			http.StatusServiceUnavailable, true, true},
+		{"invalid body", []httpclientutils.Option{httpclientutils.WithURL(ts.URL), httpclientutils.WithMethod(http.MethodPost), httpclientutils.WithBody(func() {})},
+			0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := httpclientutils.MakeHTTPRequest(append(tt.opts, httpclientutils.WithAttempt(2))...)
+			var requestErr *httpclientutils.RequestError
+			if !assert.True(t, errors.As(err, &requestErr)) {
+				return
+			}
+			assert.Equal(t, tt.status, requestErr.StatusCode())
+			assert.Equal(t, tt.retryable, requestErr.Retryable())
+			assert.Equal(t, tt.temporary, requestErr.Temporary())
+			assert.Equal(t, 2, requestErr.Attempt())
+		})
+	}
+}
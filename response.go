@@ -0,0 +1,118 @@
+package httpclientutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// Response describes the outcome of a request in more detail than the
+// values returned by MakeHTTPRequest. Pass one to WithResponse to have it
+// filled in.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Trailer holds the response trailers, which are only known once the
+	// body has been read.
+	Trailer http.Header
+	// Conn describes the connection the response arrived on; it is nil for
+	// responses served without a round trip, e.g. from a cache.
+	Conn *ConnInfo
+	// TLS describes the negotiated TLS session; it is nil for plain HTTP
+	// and for responses served without a round trip.
+	TLS *TLSInfo
+
+	url       string
+	redirects []RedirectHop
+}
+
+// ConnInfo describes the connection used for a request.
+type ConnInfo struct {
+	Reused     bool          // the connection had served an earlier request
+	WasIdle    bool          // it was taken from the idle pool
+	IdleTime   time.Duration // how long it sat idle, when WasIdle
+	RemoteAddr string
+	LocalAddr  string
+	// FailedAddrs lists resolved addresses that failed to connect before
+	// RemoteAddr was reached, in the order they were tried.
+	FailedAddrs []string
+}
+
+func newConnInfo(info httptrace.GotConnInfo) *ConnInfo {
+	conn := &ConnInfo{Reused: info.Reused, WasIdle: info.WasIdle, IdleTime: info.IdleTime}
+	if info.Conn != nil {
+		conn.RemoteAddr, conn.LocalAddr = info.Conn.RemoteAddr().String(), info.Conn.LocalAddr().String()
+	}
+	return conn
+}
+
+// Redirects returns every redirect hop followed to obtain the response, in
+// order.
+func (r *Response) Redirects() []RedirectHop { return r.redirects }
+
+// URL returns the URL the response was served from, after any redirects.
+func (r *Response) URL() string {
+	if len(r.redirects) > 0 {
+		return r.redirects[len(r.redirects)-1].To.String()
+	}
+	return r.url
+}
+
+// Extract returns the value at a JSON path such as "data.items[0].id" in
+// the JSON body. Objects and arrays are returned as maps and slices, and
+// numbers as json.Number.
+func (r *Response) Extract(path string) (interface{}, error) {
+	doc, err := decodeJSONDocument(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return lookupJSONPath(doc, path)
+}
+
+// ExtractString returns the string at path. Numbers and booleans are
+// formatted; other values are an error.
+func (r *Response) ExtractString(path string) (string, error) {
+	value, err := r.Extract(path)
+	if err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("value at %s is %T, not a string", path, value)
+	}
+}
+
+// ExtractInt returns the integer at path.
+func (r *Response) ExtractInt(path string) (int64, error) {
+	value, err := r.Extract(path)
+	if err != nil {
+		return 0, err
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("value at %s is %T, not a number", path, value)
+	}
+	return number.Int64()
+}
+
+// ExtractBool returns the boolean at path.
+func (r *Response) ExtractBool(path string) (bool, error) {
+	value, err := r.Extract(path)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("value at %s is %T, not a boolean", path, value)
+	}
+	return b, nil
+}
@@ -0,0 +1,112 @@
+package httpclientutils_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse_Extract(t *testing.T) {
+	resp := &httpclientutils.Response{Body: []byte(`{
+		"data": {"items": [{"id": "a1", "count": 3}, {"id": "b2", "count": 9007199254740993, "active": true}]},
+		"meta": {"next-page": "p2"}
+	}`)}
+
+	id, err := resp.ExtractString("data.items[0].id")
+	assert.NoError(t, err)
+	assert.Equal(t, "a1", id)
+
+	count, err := resp.ExtractInt("$.data.items[-1].count")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), count)
+
+	active, err := resp.ExtractBool("data.items[1].active")
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	next, err := resp.ExtractString("meta['next-page']")
+	assert.NoError(t, err)
+	assert.Equal(t, "p2", next)
+
+	items, err := resp.Extract("data.items")
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	_, err = resp.ExtractString("data.items[5].id")
+	assert.ErrorIs(t, err, httpclientutils.ErrPathNotFound)
+	_, err = resp.ExtractString("data.items")
+	assert.ErrorContains(t, err, "not a string")
+	_, err = resp.Extract("data.items[x]")
+	assert.ErrorContains(t, err, "invalid json path")
+}
+
+func TestResponse_ConnInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	partitions := httpclientutils.NewTenantPartitions(10, 0)
+	defer partitions.CloseIdleConnections()
+	stats := httpclientutils.NewStats()
+	get := func() *httpclientutils.Response {
+		var resp httpclientutils.Response
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithTenantPartitions(partitions),
+			httpclientutils.WithStats(stats),
+			httpclientutils.WithResponse(&resp),
+		)
+		assert.NoError(t, err)
+		return &resp
+	}
+
+	first := get()
+	if assert.NotNil(t, first.Conn) {
+		assert.False(t, first.Conn.Reused)
+		assert.Equal(t, ts.Listener.Addr().String(), first.Conn.RemoteAddr)
+	}
+	second := get()
+	if assert.NotNil(t, second.Conn) {
+		assert.True(t, second.Conn.Reused)
+		assert.True(t, second.Conn.WasIdle)
+		assert.Equal(t, first.Conn.LocalAddr, second.Conn.LocalAddr)
+	}
+
+	snapshot := stats.Snapshot()
+	assert.Equal(t, int64(0), snapshot.ConnsActive)
+	assert.Equal(t, int64(1), snapshot.ConnsFromIdle)
+	assert.Equal(t, int64(1), snapshot.ConnsReused)
+}
+
+func TestResponse_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var resp httpclientutils.Response
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(ts.Client().Transport.(*http.Transport).TLSClientConfig),
+		httpclientutils.WithResponse(&resp),
+	)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, resp.TLS) {
+		return
+	}
+	assert.GreaterOrEqual(t, resp.TLS.Version, uint16(tls.VersionTLS12))
+	assert.Contains(t, resp.TLS.VersionName(), "TLS 1.")
+	assert.NotEmpty(t, resp.TLS.CipherSuiteName())
+	if assert.NotEmpty(t, resp.TLS.PeerCertificates) {
+		assert.Equal(t, ts.Certificate().NotAfter, resp.TLS.Expiry())
+	}
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	_, _, _, err = httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(plain.URL), httpclientutils.WithResponse(&resp))
+	assert.NoError(t, err)
+	assert.Nil(t, resp.TLS)
+}
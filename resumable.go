@@ -0,0 +1,210 @@
+package httpclientutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StatusResumeIncomplete is the status Google resumable upload sessions
+// answer with while data is missing.
+const StatusResumeIncomplete = 308
+
+// googleChunkAlignment is the granularity of non-final chunks.
+const googleChunkAlignment = 256 << 10
+
+// GoogleResumableUpload uploads through a Google resumable upload session
+// (Cloud Storage, Drive, YouTube). A session is started at InitURL unless
+// SessionURI is already set, in which case the upload resumes from the
+// offset the server has persisted; the reader must then start at the
+// beginning of the content.
+type GoogleResumableUpload struct {
+	InitURL     string      // e.g. .../upload/storage/v1/b/BUCKET/o?uploadType=resumable&name=OBJECT
+	Metadata    interface{} // JSON body of the initiation request; may be nil
+	ContentType string      // sent as X-Upload-Content-Type
+	ChunkSize   int64       // rounded down to a multiple of 256 KiB; defaults to 8 MiB
+	MaxAttempts int         // per chunk; defaults to 3
+	Options     []Option    // applied to every request, e.g. auth
+
+	// SessionURI is the session's upload URL, set once the session has
+	// started. Persist it to resume after a restart.
+	SessionURI string
+	// Response is the body of the final response, typically the created
+	// resource.
+	Response []byte
+}
+
+// Upload sends everything read from r and returns the size of the
+// content, or 0 when a resumed session had already completed.
+func (u *GoogleResumableUpload) Upload(ctx context.Context, r io.Reader) (int64, error) {
+	var base int64
+	if u.SessionURI == "" {
+		if err := u.start(ctx); err != nil {
+			return 0, err
+		}
+	} else {
+		persisted, done, err := u.persisted(ctx)
+		if err != nil || done {
+			return persisted, err
+		}
+		if _, err := io.CopyN(io.Discard, r, persisted); err != nil {
+			return 0, fmt.Errorf("failed to skip persisted bytes: %w", err)
+		}
+		base = persisted
+	}
+
+	chunkSize := u.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	chunkSize = max(chunkSize/googleChunkAlignment, 1) * googleChunkAlignment
+
+	failed := false
+	upload := &ChunkedUpload{
+		ChunkSize:   chunkSize,
+		MaxAttempts: u.MaxAttempts,
+		Upload: func(ctx context.Context, chunk Chunk) error {
+			err := u.sendChunk(ctx, base, chunk, failed)
+			failed = err != nil
+			return err
+		},
+	}
+	size, err := upload.Run(ctx, r)
+	return base + size, err
+}
+
+func (u *GoogleResumableUpload) start(ctx context.Context) error {
+	opts := append([]Option{}, u.Options...)
+	opts = append(opts, WithContext(ctx), WithMethod(http.MethodPost), WithURL(u.InitURL))
+	if u.Metadata != nil {
+		opts = append(opts, WithBody(u.Metadata), withHeader("Content-Type", "application/json; charset=UTF-8"))
+	}
+	if u.ContentType != "" {
+		opts = append(opts, withHeader("X-Upload-Content-Type", u.ContentType))
+	}
+	statusCode, header, body, err := MakeHTTPRequest(opts...)
+	if err != nil {
+		return fmt.Errorf("failed to start resumable upload: %w", err)
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusCreated {
+		return fmt.Errorf("failed to start resumable upload: unexpected status %d: %s", statusCode, body)
+	}
+	if u.SessionURI = header.Get("Location"); u.SessionURI == "" {
+		return errors.New("failed to start resumable upload: no session URI in response")
+	}
+	return nil
+}
+
+// sendChunk PUTs chunk, first asking the session how much of it arrived
+// when the previous attempt failed. A 308 may acknowledge only part of the
+// data, in which case the rest is sent again.
+func (u *GoogleResumableUpload) sendChunk(ctx context.Context, base int64, chunk Chunk, retry bool) error {
+	start, data := base+chunk.Offset, chunk.Data
+	end := start + int64(len(data))
+	if retry {
+		persisted, done, err := u.persisted(ctx)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if skip := persisted - start; skip > 0 {
+			start, data = persisted, data[min(skip, int64(len(data))):]
+		}
+	}
+
+	total := "*"
+	if chunk.Last {
+		total = strconv.FormatInt(end, 10)
+	}
+	for {
+		contentRange := fmt.Sprintf("bytes %d-%d/%s", start, end-1, total)
+		if len(data) == 0 {
+			contentRange = "bytes */" + total
+		}
+
+		opts := append([]Option{}, u.Options...)
+		statusCode, header, body, err := MakeHTTPRequest(append(opts,
+			WithContext(ctx),
+			WithMethod(http.MethodPut),
+			WithURL(u.SessionURI),
+			WithBody(data),
+			withHeader("Content-Range", contentRange),
+		)...)
+		switch {
+		case err != nil:
+			return err
+		case statusCode == StatusResumeIncomplete:
+			persisted, err := persistedRange(header)
+			if err != nil {
+				return err
+			}
+			persisted = min(persisted, end)
+			if persisted == end && !chunk.Last {
+				return nil
+			}
+			if persisted < start {
+				return &PermanentError{Err: fmt.Errorf("session lost data: persisted %d bytes, chunk starts at %d", persisted, start)}
+			}
+			if persisted == start && len(data) == 0 {
+				return fmt.Errorf("unexpected status %d: upload not finalized", statusCode)
+			}
+			// Resend whatever the server did not keep.
+			start, data = persisted, data[persisted-start:]
+		case (statusCode == http.StatusOK || statusCode == http.StatusCreated) && chunk.Last:
+			u.Response = body
+			return nil
+		case retryableStatus(statusCode):
+			return fmt.Errorf("unexpected status %d: %s", statusCode, body)
+		default:
+			return &PermanentError{Err: fmt.Errorf("unexpected status %d: %s", statusCode, body)}
+		}
+	}
+}
+
+// persisted asks the session how many bytes it holds, and whether the
+// upload has already completed.
+func (u *GoogleResumableUpload) persisted(ctx context.Context) (int64, bool, error) {
+	opts := append([]Option{}, u.Options...)
+	statusCode, header, body, err := MakeHTTPRequest(append(opts,
+		WithContext(ctx),
+		WithMethod(http.MethodPut),
+		WithURL(u.SessionURI),
+		withHeader("Content-Range", "bytes */*"),
+	)...)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to query upload status: %w", err)
+	}
+	switch statusCode {
+	case http.StatusOK, http.StatusCreated:
+		u.Response = body
+		return 0, true, nil
+	case StatusResumeIncomplete:
+		n, err := persistedRange(header)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to query upload status: %w", err)
+		}
+		return n, false, nil
+	default:
+		return 0, false, fmt.Errorf("failed to query upload status: unexpected status %d: %s", statusCode, body)
+	}
+}
+
+// persistedRange returns the number of bytes a 308 response's Range header
+// (bytes=0-N) reports as persisted; the header is absent when nothing is.
+func persistedRange(header http.Header) (int64, error) {
+	last := strings.TrimPrefix(header.Get("Range"), "bytes=0-")
+	if last == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad Range %q", header.Get("Range"))
+	}
+	return n + 1, nil
+}
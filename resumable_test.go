@@ -0,0 +1,133 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResumableSession stores uploaded bytes and, once, persists only part
+// of a chunk before failing or before acknowledging it with a 308.
+type fakeResumableSession struct {
+	mu           sync.Mutex
+	data         []byte
+	failOnce     bool
+	shortAckOnce bool
+	ranges       []string
+}
+
+var contentRangePattern = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+|\*)$`)
+
+func (s *fakeResumableSession) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodPost {
+		w.Header().Set("Location", "http://"+r.Host+"/session/1")
+		return
+	}
+
+	contentRange := r.Header.Get("Content-Range")
+	s.ranges = append(s.ranges, contentRange)
+	body, _ := io.ReadAll(r.Body)
+	if contentRange == "bytes */*" {
+		if len(s.data) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.data)-1))
+		}
+		w.WriteHeader(httpclientutils.StatusResumeIncomplete)
+		return
+	}
+	match := contentRangePattern.FindStringSubmatch(contentRange)
+	start, _ := strconv.Atoi(match[1])
+	if start != len(s.data) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if s.failOnce && start > 0 {
+		s.failOnce = false
+		s.data = append(s.data, body[:len(body)/2]...)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if s.shortAckOnce && match[3] == "*" {
+		s.shortAckOnce = false
+		body = body[:len(body)/2]
+	}
+	s.data = append(s.data, body...)
+	if match[3] == "*" {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.data)-1))
+		w.WriteHeader(httpclientutils.StatusResumeIncomplete)
+		return
+	}
+	fmt.Fprintf(w, `{"size":"%d"}`, len(s.data))
+}
+
+func TestGoogleResumableUpload_ResumesPartialChunk(t *testing.T) {
+	session := &fakeResumableSession{failOnce: true}
+	ts := httptest.NewServer(session)
+	defer ts.Close()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 40<<10) // 640 KiB
+	upload := &httpclientutils.GoogleResumableUpload{
+		InitURL:     ts.URL + "/upload?uploadType=resumable&name=obj",
+		Metadata:    map[string]string{"name": "obj"},
+		ContentType: "application/octet-stream",
+		ChunkSize:   300 << 10, // rounded down to 256 KiB
+	}
+	size, err := upload.Upload(context.Background(), bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, ts.URL+"/session/1", upload.SessionURI)
+	assert.Equal(t, content, session.data)
+	assert.JSONEq(t, fmt.Sprintf(`{"size":"%d"}`, len(content)), string(upload.Response))
+	assert.Equal(t, []string{
+		"bytes 0-262143/*",
+		"bytes 262144-524287/*",
+		"bytes */*",
+		"bytes 393216-524287/*",
+		"bytes 524288-655359/655360",
+	}, session.ranges)
+}
+
+func TestGoogleResumableUpload_ResumesExistingSession(t *testing.T) {
+	content := []byte("already half uploaded")
+	session := &fakeResumableSession{data: append([]byte{}, content[:8]...)}
+	ts := httptest.NewServer(session)
+	defer ts.Close()
+
+	upload := &httpclientutils.GoogleResumableUpload{SessionURI: ts.URL + "/session/1"}
+	_, err := upload.Upload(context.Background(), bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, content, session.data)
+}
+
+func TestGoogleResumableUpload_ResendsUnacknowledgedBytes(t *testing.T) {
+	session := &fakeResumableSession{shortAckOnce: true}
+	ts := httptest.NewServer(session)
+	defer ts.Close()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 40<<10) // 640 KiB
+	upload := &httpclientutils.GoogleResumableUpload{
+		InitURL:   ts.URL + "/upload?uploadType=resumable&name=obj",
+		ChunkSize: 256 << 10,
+	}
+	size, err := upload.Upload(context.Background(), bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, content, session.data)
+	assert.Equal(t, []string{
+		"bytes 0-262143/*",
+		"bytes 131072-262143/*",
+		"bytes 262144-524287/*",
+		"bytes 524288-655359/655360",
+	}, session.ranges)
+}
@@ -0,0 +1,96 @@
+package httpclientutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// resumeBody finishes reading a response body whose read failed after
+// received bytes, re-issuing req with a Range header for the rest up to
+// attempts times and no later than deadline, if set. Only a connection
+// lost mid-body is resumed, never a timeout or cancellation. Only complete
+// 200 responses to GET requests carrying an
+// ETag or Last-Modified validator are resumed, and If-Range guards
+// against stitching together two versions of the resource; a response
+// the transport decompressed is not resumed, since its offsets do not
+// match the bytes on the wire.
+func resumeBody(client *http.Client, req *http.Request, resp *http.Response, received []byte, readErr error, attempts int, deadline time.Time) ([]byte, error) {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if req.Method != http.MethodGet || resp.StatusCode != http.StatusOK || resp.Uncompressed || validator == "" {
+		return received, readErr
+	}
+
+	ctx := req.Context()
+	if !deadline.IsZero() {
+		// The request timeout covers the resumes too.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	for range attempts {
+		if !resumableReadError(readErr) || ctx.Err() != nil {
+			break
+		}
+		retry := req.Clone(ctx)
+		retry.Header.Set("Range", "bytes="+strconv.Itoa(len(received))+"-")
+		retry.Header.Set("If-Range", validator)
+		next, err := client.Do(retry)
+		if err != nil {
+			readErr = err
+			continue
+		}
+		switch {
+		case next.StatusCode == http.StatusOK:
+			// The resource changed; If-Range sent it whole.
+			received = received[:0]
+		case next.StatusCode != http.StatusPartialContent || contentRangeStart(next.Header.Get("Content-Range")) != len(received):
+			next.Body.Close()
+			return received, fmt.Errorf("%w; resume got status %d", readErr, next.StatusCode)
+		}
+		rest, err := io.ReadAll(next.Body)
+		next.Body.Close()
+		received = append(received, rest...)
+		if err == nil {
+			return received, nil
+		}
+		readErr = err
+	}
+	return received, readErr
+}
+
+// resumableReadError reports whether a body read failed because the
+// connection was lost, rather than by a timeout or cancellation.
+func resumableReadError(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE)
+}
+
+// contentRangeStart returns the first byte position of a Content-Range
+// header such as "bytes 100-199/200", or -1.
+func contentRangeStart(value string) int {
+	value, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, _ := strings.Cut(value, "-")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return -1
+	}
+	return n
+}
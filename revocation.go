@@ -0,0 +1,422 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCertificateRevoked is returned when OCSP or a CRL reports the
+	// server certificate as revoked.
+	ErrCertificateRevoked = errors.New("certificate revoked")
+	// ErrRevocationUnknown is returned by hard-fail revocation checks when
+	// no OCSP response or CRL could establish the certificate status.
+	ErrRevocationUnknown = errors.New("certificate revocation status unknown")
+)
+
+// RevocationMode decides what happens when revocation status cannot be
+// determined. Revoked certificates fail in either mode.
+type RevocationMode int
+
+const (
+	// RevocationSoftFail allows the connection when no status is available.
+	RevocationSoftFail RevocationMode = iota
+	// RevocationHardFail refuses the connection when no status is available.
+	RevocationHardFail
+)
+
+// RevocationCheck configures server certificate revocation checking. A
+// stapled OCSP response is used when the server sends one; otherwise the
+// leaf's OCSP responders and then CRL distribution points are queried.
+type RevocationCheck struct {
+	Mode RevocationMode
+	// Timeout bounds each OCSP or CRL fetch; it defaults to two seconds.
+	Timeout time.Duration
+	// StapledOnly skips fetching, relying on stapled responses alone.
+	StapledOnly bool
+}
+
+type revocationStatus int
+
+const (
+	statusUnknown revocationStatus = iota
+	statusGood
+	statusRevoked
+)
+
+// verifyConnection checks the leaf of the verified chain, fetching through
+// client when nothing is stapled.
+func (c RevocationCheck) verifyConnection(cs tls.ConnectionState, client *http.Client) error {
+	if len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) < 2 {
+		return c.unknown()
+	}
+	leaf, issuer := cs.VerifiedChains[0][0], cs.VerifiedChains[0][1]
+
+	if len(cs.OCSPResponse) > 0 {
+		if status, _, err := parseOCSPStatus(cs.OCSPResponse, leaf, issuer); err == nil && status != statusUnknown {
+			return c.result(status)
+		}
+	}
+	if c.StapledOnly {
+		return c.unknown()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout())
+	defer cancel()
+	for _, server := range leaf.OCSPServer {
+		if status, err := fetchOCSPStatus(ctx, client, server, leaf, issuer); err == nil && status != statusUnknown {
+			return c.result(status)
+		}
+	}
+	for _, point := range leaf.CRLDistributionPoints {
+		if status, err := fetchCRLStatus(ctx, client, point, leaf, issuer); err == nil && status != statusUnknown {
+			return c.result(status)
+		}
+	}
+	return c.unknown()
+}
+
+func (c RevocationCheck) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 2 * time.Second
+	}
+	return c.Timeout
+}
+
+func (c RevocationCheck) result(status revocationStatus) error {
+	if status == statusRevoked {
+		return ErrCertificateRevoked
+	}
+	return nil
+}
+
+func (c RevocationCheck) unknown() error {
+	if c.Mode == RevocationHardFail {
+		return ErrRevocationUnknown
+	}
+	return nil
+}
+
+// revocationClient returns the client OCSP and CRL fetches for config go
+// through: the same proxy, resolver, SSRF policy and local address as the
+// connection being checked, but none of its TLS settings.
+func revocationClient(config transportConfig) *http.Client {
+	fetch := config
+	fetch.tls, fetch.unixSocket = nil, ""
+	fetch.checkRevocation, fetch.revocation = false, RevocationCheck{}
+	fetch.tlsProfile, fetch.ech, fetch.serverName = TLSProfileDefault, "", ""
+	transport := newTransport(fetch)
+	// Responses are cached, so fetches are rare; nothing is kept idle.
+	transport.DisableKeepAlives = true
+	return &http.Client{Transport: transport, Timeout: config.revocation.timeout()}
+}
+
+// revocationDefaultTTL is how long a response without a NextUpdate time is
+// cached.
+const revocationDefaultTTL = 5 * time.Minute
+
+// revocationCache holds OCSP statuses and verified CRLs until their
+// NextUpdate, shared by every request made with WithRevocationCheck.
+var revocationCache = &revocationResponses{entries: make(map[string]revocationEntry)}
+
+type revocationResponses struct {
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+type revocationEntry struct {
+	expires time.Time
+	status  revocationStatus // of an OCSP entry
+	crl     *x509.RevocationList
+}
+
+func (r *revocationResponses) get(key string) (revocationEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return revocationEntry{}, false
+	}
+	return entry, true
+}
+
+func (r *revocationResponses) put(key string, entry revocationEntry, nextUpdate time.Time) {
+	now := time.Now()
+	entry.expires = nextUpdate
+	if nextUpdate.IsZero() {
+		entry.expires = now.Add(revocationDefaultTTL)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, cached := range r.entries {
+		if now.After(cached.expires) {
+			delete(r.entries, key)
+		}
+	}
+	r.entries[key] = entry
+}
+
+func revocationFetch(ctx context.Context, client *http.Client, method, url, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+func fetchCRLStatus(ctx context.Context, client *http.Client, url string, leaf, issuer *x509.Certificate) (revocationStatus, error) {
+	key := "crl " + url
+	entry, ok := revocationCache.get(key)
+	list := entry.crl
+	if !ok {
+		der, err := revocationFetch(ctx, client, http.MethodGet, url, "", nil)
+		if err != nil {
+			return statusUnknown, err
+		}
+		if list, err = x509.ParseRevocationList(der); err != nil {
+			return statusUnknown, err
+		}
+	}
+	if err := list.CheckSignatureFrom(issuer); err != nil {
+		return statusUnknown, err
+	}
+	if !list.NextUpdate.IsZero() && time.Now().After(list.NextUpdate) {
+		return statusUnknown, errors.New("stale CRL")
+	}
+	if !ok {
+		revocationCache.put(key, revocationEntry{crl: list}, list.NextUpdate)
+	}
+	for _, entry := range list.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return statusRevoked, nil
+		}
+	}
+	return statusGood, nil
+}
+
+// The OCSP structures below follow RFC 6960.
+
+var (
+	oidOCSPBasic   = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSHA1        = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidOCSPSigning = x509.ExtKeyUsageOCSPSigning
+)
+
+var ocspSignatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+	"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+	"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+	"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+	"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+	"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+	"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+	"1.3.101.112":           x509.PureEd25519,
+}
+
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		RequestList []struct {
+			Cert ocspCertID
+		}
+	}
+}
+
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	} `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Version     int `asn1:"optional,default:0,explicit,tag:0"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []ocspSingleResponse
+	Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID  ocspCertID
+	Good    asn1.Flag `asn1:"tag:0,optional"`
+	Revoked struct {
+		RevocationTime time.Time       `asn1:"generalized"`
+		Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+	} `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	Extensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+func fetchOCSPStatus(ctx context.Context, client *http.Client, server string, leaf, issuer *x509.Certificate) (revocationStatus, error) {
+	id, err := newOCSPCertID(leaf, issuer, oidSHA1)
+	if err != nil {
+		return statusUnknown, err
+	}
+	var req ocspRequest
+	req.TBSRequest.RequestList = append(req.TBSRequest.RequestList, struct{ Cert ocspCertID }{id})
+	der, err := asn1.Marshal(req)
+	if err != nil {
+		return statusUnknown, err
+	}
+	key := "ocsp " + server + " " + string(der)
+	if entry, ok := revocationCache.get(key); ok {
+		return entry.status, nil
+	}
+	body, err := revocationFetch(ctx, client, http.MethodPost, server, "application/ocsp-request", der)
+	if err != nil {
+		return statusUnknown, err
+	}
+	status, nextUpdate, err := parseOCSPStatus(body, leaf, issuer)
+	if err == nil && status != statusUnknown {
+		revocationCache.put(key, revocationEntry{status: status}, nextUpdate)
+	}
+	return status, err
+}
+
+func newOCSPCertID(leaf, issuer *x509.Certificate, hashOID asn1.ObjectIdentifier) (ocspCertID, error) {
+	var hash crypto.Hash
+	switch {
+	case hashOID.Equal(oidSHA1):
+		hash = crypto.SHA1
+	case hashOID.Equal(oidSHA256):
+		hash = crypto.SHA256
+	default:
+		return ocspCertID{}, fmt.Errorf("unsupported OCSP hash %s", hashOID)
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return ocspCertID{}, err
+	}
+	digest := func(data []byte) []byte {
+		if hash == crypto.SHA1 {
+			sum := sha1.Sum(data)
+			return sum[:]
+		}
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+	return ocspCertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+		NameHash:      digest(issuer.RawSubject),
+		IssuerKeyHash: digest(spki.PublicKey.RightAlign()),
+		SerialNumber:  leaf.SerialNumber,
+	}, nil
+}
+
+// parseOCSPStatus verifies an OCSP response signed by issuer, or by a
+// responder certificate issuer delegated, and returns the leaf's status and
+// the response's NextUpdate time.
+func parseOCSPStatus(der []byte, leaf, issuer *x509.Certificate) (revocationStatus, time.Time, error) {
+	var resp ocspResponse
+	if _, err := asn1.Unmarshal(der, &resp); err != nil {
+		return statusUnknown, time.Time{}, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+	if resp.Status != 0 || !resp.ResponseBytes.ResponseType.Equal(oidOCSPBasic) {
+		return statusUnknown, time.Time{}, fmt.Errorf("OCSP responder returned status %d", resp.Status)
+	}
+	var basic ocspBasicResponse
+	if _, err := asn1.Unmarshal(resp.ResponseBytes.Response, &basic); err != nil {
+		return statusUnknown, time.Time{}, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+	var data ocspResponseData
+	if _, err := asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data); err != nil {
+		return statusUnknown, time.Time{}, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+
+	signer := issuer
+	if len(basic.Certificates) > 0 {
+		responder, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return statusUnknown, time.Time{}, fmt.Errorf("failed to parse OCSP responder certificate: %w", err)
+		}
+		if !bytes.Equal(responder.Raw, issuer.Raw) {
+			if err := responder.CheckSignatureFrom(issuer); err != nil || !hasExtKeyUsage(responder, oidOCSPSigning) {
+				return statusUnknown, time.Time{}, errors.New("OCSP responder not authorized by issuer")
+			}
+			signer = responder
+		}
+	}
+	algorithm, ok := ocspSignatureAlgorithms[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return statusUnknown, time.Time{}, fmt.Errorf("unsupported OCSP signature algorithm %s", basic.SignatureAlgorithm.Algorithm)
+	}
+	if err := signer.CheckSignature(algorithm, basic.TBSResponseData.FullBytes, basic.Signature.RightAlign()); err != nil {
+		return statusUnknown, time.Time{}, fmt.Errorf("invalid OCSP signature: %w", err)
+	}
+
+	now := time.Now()
+	for _, single := range data.Responses {
+		want, err := newOCSPCertID(leaf, issuer, single.CertID.HashAlgorithm.Algorithm)
+		if err != nil || single.CertID.SerialNumber.Cmp(want.SerialNumber) != 0 ||
+			!bytes.Equal(single.CertID.IssuerKeyHash, want.IssuerKeyHash) {
+			continue
+		}
+		if single.ThisUpdate.After(now.Add(5*time.Minute)) || !single.NextUpdate.IsZero() && now.After(single.NextUpdate) {
+			return statusUnknown, time.Time{}, errors.New("stale OCSP response")
+		}
+		switch {
+		case bool(single.Good):
+			return statusGood, single.NextUpdate, nil
+		case bool(single.Unknown):
+			return statusUnknown, single.NextUpdate, nil
+		default:
+			return statusRevoked, single.NextUpdate, nil
+		}
+	}
+	return statusUnknown, time.Time{}, errors.New("OCSP response does not cover the certificate")
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package httpclientutils_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPKI struct {
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	leaf   *x509.Certificate
+	server tls.Certificate
+	client *tls.Config
+}
+
+func newTestPKI(t *testing.T, crlURL string) *testPKI {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	ca, _ := x509.ParseCertificate(caDER)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if crlURL != "" {
+		leafTemplate.CRLDistributionPoints = []string{crlURL}
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	leaf, _ := x509.ParseCertificate(leafDER)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &testPKI{
+		ca:     ca,
+		caKey:  caKey,
+		leaf:   leaf,
+		server: tls.Certificate{Certificate: [][]byte{leafDER, caDER}, PrivateKey: leafKey},
+		client: &tls.Config{RootCAs: pool},
+	}
+}
+
+func (p *testPKI) crl(t *testing.T, revoked ...*big.Int) []byte {
+	list := &x509.RevocationList{Number: big.NewInt(1), ThisUpdate: time.Now().Add(-time.Minute), NextUpdate: time.Now().Add(time.Hour)}
+	for _, serial := range revoked {
+		list.RevokedCertificateEntries = append(list.RevokedCertificateEntries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, list, p.ca, p.caKey)
+	assert.NoError(t, err)
+	return der
+}
+
+// revokedOCSP builds a CA-signed OCSP response reporting the leaf revoked.
+func (p *testPKI) revokedOCSP(t *testing.T) []byte {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	_, err := asn1.Unmarshal(p.ca.RawSubjectPublicKeyInfo, &spki)
+	assert.NoError(t, err)
+	nameHash := sha1.Sum(p.ca.RawSubject)
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+
+	type certID struct {
+		HashAlgorithm pkix.AlgorithmIdentifier
+		NameHash      []byte
+		IssuerKeyHash []byte
+		SerialNumber  *big.Int
+	}
+	type revokedInfo struct {
+		RevocationTime time.Time `asn1:"generalized"`
+	}
+	type singleResponse struct {
+		CertID     certID
+		Revoked    revokedInfo `asn1:"tag:1"`
+		ThisUpdate time.Time   `asn1:"generalized"`
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	responderID, _ := asn1.Marshal(keyHash[:])
+	tbs, err := asn1.Marshal(struct {
+		ResponderID asn1.RawValue
+		ProducedAt  time.Time `asn1:"generalized"`
+		Responses   []singleResponse
+	}{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: responderID},
+		ProducedAt:  now,
+		Responses: []singleResponse{{
+			CertID: certID{
+				HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, Parameters: asn1.NullRawValue},
+				NameHash:      nameHash[:],
+				IssuerKeyHash: keyHash[:],
+				SerialNumber:  p.leaf.SerialNumber,
+			},
+			Revoked:    revokedInfo{RevocationTime: now.Add(-time.Minute)},
+			ThisUpdate: now,
+		}},
+	})
+	assert.NoError(t, err)
+
+	digest := sha256.Sum256(tbs)
+	signature, err := ecdsa.SignASN1(rand.Reader, p.caKey, digest[:])
+	assert.NoError(t, err)
+	basic, err := asn1.Marshal(struct {
+		TBSResponseData    asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	})
+	assert.NoError(t, err)
+
+	type responseBytes struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	}
+	response, err := asn1.Marshal(struct {
+		Status        asn1.Enumerated
+		ResponseBytes responseBytes `asn1:"explicit,tag:0"`
+	}{ResponseBytes: responseBytes{ResponseType: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}, Response: basic}})
+	assert.NoError(t, err)
+	return response
+}
+
+func startTLSServer(cert tls.Certificate) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	return ts
+}
+
+func TestWithRevocationCheck_CRL(t *testing.T) {
+	request := func(revoked bool) error {
+		var pki *testPKI
+		crlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if revoked {
+				w.Write(pki.crl(t, pki.leaf.SerialNumber))
+			} else {
+				w.Write(pki.crl(t))
+			}
+		}))
+		defer crlServer.Close()
+		pki = newTestPKI(t, crlServer.URL)
+		ts := startTLSServer(pki.server)
+		defer ts.Close()
+
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithTLSConfig(pki.client),
+			httpclientutils.WithRevocationCheck(httpclientutils.RevocationCheck{Mode: httpclientutils.RevocationHardFail}),
+		)
+		return err
+	}
+
+	assert.NoError(t, request(false))
+	assert.ErrorIs(t, request(true), httpclientutils.ErrCertificateRevoked)
+}
+
+func TestWithRevocationCheck_CRLFetchedThroughProxyAndCached(t *testing.T) {
+	var pki *testPKI
+	var fetches int32
+	proxy := newConnectProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "crl.invalid", r.URL.Host)
+		atomic.AddInt32(&fetches, 1)
+		w.Write(pki.crl(t))
+	})
+	defer proxy.Close()
+	pki = newTestPKI(t, "http://crl.invalid/"+t.Name()+".crl")
+	ts := startTLSServer(pki.server)
+	defer ts.Close()
+
+	for range 2 {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithProxy(proxy.URL),
+			httpclientutils.WithTLSConfig(pki.client),
+			httpclientutils.WithRevocationCheck(httpclientutils.RevocationCheck{Mode: httpclientutils.RevocationHardFail}),
+		)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestWithRevocationCheck_StapledOCSP(t *testing.T) {
+	pki := newTestPKI(t, "")
+	pki.server.OCSPStaple = pki.revokedOCSP(t)
+	ts := startTLSServer(pki.server)
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(pki.client),
+		httpclientutils.WithRevocationCheck(httpclientutils.RevocationCheck{StapledOnly: true}),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrCertificateRevoked)
+}
+
+func TestWithRevocationCheck_UnknownStatus(t *testing.T) {
+	pki := newTestPKI(t, "")
+	ts := startTLSServer(pki.server)
+	defer ts.Close()
+
+	for mode, want := range map[httpclientutils.RevocationMode]error{
+		httpclientutils.RevocationSoftFail: nil,
+		httpclientutils.RevocationHardFail: httpclientutils.ErrRevocationUnknown,
+	} {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithTLSConfig(pki.client),
+			httpclientutils.WithRevocationCheck(httpclientutils.RevocationCheck{Mode: mode}),
+		)
+		if want == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, want)
+		}
+	}
+}
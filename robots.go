@@ -0,0 +1,275 @@
+package httpclientutils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots is returned for requests that robots.txt disallows
+// under WithRespectRobotsTxt.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// robotsTTL is how long a fetched robots.txt is used (RFC 9309 section
+// 2.4); failures are retried sooner.
+const (
+	robotsTTL      = 24 * time.Hour
+	robotsRetryTTL = time.Minute
+
+	// robotsFetchTimeout bounds a robots.txt fetch for requests without a
+	// timeout of their own.
+	robotsFetchTimeout = 30 * time.Second
+)
+
+// robotsCache holds the robots.txt of each origin, shared by every request
+// made with WithRespectRobotsTxt.
+var robotsCache = &robotsRegistry{origins: make(map[string]*robotsOrigin)}
+
+type robotsRegistry struct {
+	mu      sync.Mutex
+	origins map[string]*robotsOrigin
+}
+
+type robotsOrigin struct {
+	mu          sync.Mutex
+	rules       *robotsRules
+	expires     time.Time
+	fetching    chan struct{} // closed when the fetch in progress completes
+	lastRequest time.Time
+}
+
+// checkRobots enforces robots.txt and Crawl-delay for options.URL.
+func checkRobots(options *RequestOptions) error {
+	if options.RobotsUserAgent == "" {
+		return nil
+	}
+	u, err := url.Parse(options.URL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	origin := robotsCache.origin(u.Scheme + "://" + u.Host)
+
+	origin.mu.Lock()
+	defer origin.mu.Unlock()
+	for origin.rules == nil || time.Now().After(origin.expires) {
+		if origin.fetching == nil {
+			// Fetched apart from any one caller, so a caller giving up does
+			// not cache its cancellation as a failure for everyone.
+			fetching := make(chan struct{})
+			origin.fetching = fetching
+			fetch := robotsFetchOptions(options, u)
+			go func() {
+				rules, expires := fetchRobots(fetch)
+				origin.mu.Lock()
+				origin.rules, origin.expires, origin.fetching = rules, expires, nil
+				origin.mu.Unlock()
+				close(fetching)
+			}()
+		}
+		fetching := origin.fetching
+		origin.mu.Unlock()
+		select {
+		case <-options.Context.Done():
+			origin.mu.Lock()
+			return options.Context.Err()
+		case <-fetching:
+		}
+		origin.mu.Lock()
+	}
+	group := origin.rules.group(options.RobotsUserAgent)
+
+	if u.Path != "/robots.txt" && !group.allowed(u.EscapedPath()+queryPart(u)) {
+		if !options.RobotsWarnOnly {
+			return fmt.Errorf("%w: %s", ErrDisallowedByRobots, u.Path)
+		}
+		options.EventBus.publish(RobotsDisallowed{URL: scrubText(options, options.URL), Meta: options.Meta, UserAgent: options.RobotsUserAgent})
+	}
+
+	if wait := time.Until(origin.lastRequest.Add(group.crawlDelay)); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-options.Context.Done():
+			timer.Stop()
+			return options.Context.Err()
+		case <-timer.C:
+		}
+	}
+	origin.lastRequest = time.Now()
+	return nil
+}
+
+func queryPart(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	return "?" + u.RawQuery
+}
+
+func (r *robotsRegistry) origin(key string) *robotsOrigin {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	origin, ok := r.origins[key]
+	if !ok {
+		origin = &robotsOrigin{}
+		r.origins[key] = origin
+	}
+	return origin
+}
+
+// robotsFetchOptions returns the request for the robots.txt of u, sent
+// over the same network path as options but detached from its cancellation
+// and bounded by its timeout or robotsFetchTimeout.
+func robotsFetchOptions(options *RequestOptions, u *url.URL) *RequestOptions {
+	fetch := detachedOptions(options)
+	fetch.Context = context.WithoutCancel(options.Context)
+	fetch.Method = http.MethodGet
+	fetch.URL = u.Scheme + "://" + u.Host + "/robots.txt"
+	fetch.Headers = map[string]string{"User-Agent": options.RobotsUserAgent}
+	fetch.Body = nil
+	fetch.Stats = nil
+	if fetch.Timeout <= 0 {
+		fetch.Timeout = robotsFetchTimeout
+	}
+	return fetch
+}
+
+// fetchRobots downloads robots.txt. Following RFC 9309, a 4xx means
+// everything is allowed, while a 5xx or a network error means everything
+// is disallowed until the retry.
+func fetchRobots(fetch *RequestOptions) (*robotsRules, time.Time) {
+	statusCode, _, body, err := roundTrip(fetch, nil)
+	switch {
+	case err != nil || statusCode >= http.StatusInternalServerError:
+		return &robotsRules{disallowAll: true}, time.Now().Add(robotsRetryTTL)
+	case statusCode >= http.StatusBadRequest:
+		return &robotsRules{}, time.Now().Add(robotsTTL)
+	}
+	return parseRobots(body), time.Now().Add(robotsTTL)
+}
+
+type robotsRules struct {
+	disallowAll bool
+	groups      []*robotsGroup
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+func parseRobots(body []byte) *robotsRules {
+	rules := &robotsRules{}
+	var current *robotsGroup
+	inAgents := false
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group.
+			if !inAgents {
+				current = &robotsGroup{}
+				rules.groups = append(rules.groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+			continue
+		case "allow", "disallow":
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && current != nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+		inAgents = false
+	}
+	return rules
+}
+
+// group returns the rules for userAgent: the group naming its product
+// token, else the "*" group.
+func (r *robotsRules) group(userAgent string) *robotsGroup {
+	if r.disallowAll {
+		return &robotsGroup{rules: []robotsRule{{pattern: "/"}}}
+	}
+	token := strings.ToLower(userAgent)
+	if name, _, found := strings.Cut(token, "/"); found {
+		token = name
+	}
+	var fallback *robotsGroup
+	for _, group := range r.groups {
+		for _, agent := range group.agents {
+			if agent == token {
+				return group
+			}
+			if agent == "*" && fallback == nil {
+				fallback = group
+			}
+		}
+	}
+	if fallback == nil {
+		return &robotsGroup{}
+	}
+	return fallback
+}
+
+// allowed applies the most specific matching rule, preferring allow on a
+// tie (RFC 9309 section 2.2.2).
+func (g *robotsGroup) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range g.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || n == best && rule.allow {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches path against a pattern where "*" matches any
+// sequence and a trailing "$" anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if anchored && rest != "" {
+		// The last literal must end the path.
+		last := parts[len(parts)-1]
+		return len(parts) > 1 && strings.HasSuffix(path, last)
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleRobots = `# sample
+User-agent: *
+Disallow: /
+
+User-agent: examplebot
+User-agent: otherbot
+Disallow: /private
+Allow: /private/public$
+Disallow: /*.pdf$
+Crawl-delay: 0.05
+`
+
+func TestWithRespectRobotsTxt(t *testing.T) {
+	var robotsFetches int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&robotsFetches, 1)
+			w.Write([]byte(sampleRobots))
+			return
+		}
+		assert.Equal(t, "ExampleBot/1.0", r.Header.Get("User-Agent"))
+	}))
+	defer ts.Close()
+
+	get := func(path string, opts ...httpclientutils.Option) error {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(append([]httpclientutils.Option{
+			httpclientutils.WithURL(ts.URL + path),
+			httpclientutils.WithRespectRobotsTxt("ExampleBot/1.0"),
+		}, opts...)...)
+		return err
+	}
+
+	assert.NoError(t, get("/articles"))
+	assert.ErrorIs(t, get("/private/data"), httpclientutils.ErrDisallowedByRobots)
+	assert.NoError(t, get("/private/public"))
+	assert.ErrorIs(t, get("/files/report.pdf"), httpclientutils.ErrDisallowedByRobots)
+	assert.NoError(t, get("/files/report.pdf?page=2"))
+
+	start := time.Now()
+	assert.NoError(t, get("/a"))
+	assert.NoError(t, get("/b"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "Crawl-delay is honored")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&robotsFetches))
+
+	bus := httpclientutils.NewEventBus()
+	events, unsubscribe := bus.Subscribe(8)
+	defer unsubscribe()
+	assert.NoError(t, get("/private/data", httpclientutils.WithRobotsTxtWarnOnly(), httpclientutils.WithEventBus(bus)))
+	var warned bool
+	for len(events) > 0 {
+		if _, ok := (<-events).(httpclientutils.RobotsDisallowed); ok {
+			warned = true
+		}
+	}
+	assert.True(t, warned)
+}
+
+func TestWithRespectRobotsTxt_FallbackGroupAndMissingFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte(sampleRobots))
+		}
+	}))
+	defer ts.Close()
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+"/articles"), httpclientutils.WithRespectRobotsTxt("unknownbot"))
+	assert.ErrorIs(t, err, httpclientutils.ErrDisallowedByRobots)
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	status, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(missing.URL+"/anything"), httpclientutils.WithRespectRobotsTxt("unknownbot"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestWithRespectRobotsTxt_FetchFollowsRequestPath(t *testing.T) {
+	var paths []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Host+r.URL.Path)
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		}
+	}))
+	defer proxy.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://robots-proxied.invalid/page"),
+		httpclientutils.WithProxy(proxy.URL),
+		httpclientutils.WithRespectRobotsTxt("ExampleBot/1.0"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"robots-proxied.invalid/robots.txt", "robots-proxied.invalid/page"}, paths)
+}
+
+func TestWithRespectRobotsTxt_CancelledCallerDoesNotPoisonCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		}
+	}))
+	defer ts.Close()
+
+	get := func(opts ...httpclientutils.Option) error {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(append([]httpclientutils.Option{
+			httpclientutils.WithURL(ts.URL + "/page"),
+			httpclientutils.WithRespectRobotsTxt("ExampleBot/1.0"),
+		}, opts...)...)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, get(httpclientutils.WithContext(ctx)), context.DeadlineExceeded)
+	assert.NoError(t, get())
+}
@@ -0,0 +1,230 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RPCProtocol selects the wire framing used by RPCClient.
+type RPCProtocol int
+
+const (
+	// ProtocolConnect is the Connect protocol's unary framing: the bare
+	// message as the body and errors as JSON.
+	ProtocolConnect RPCProtocol = iota
+	// ProtocolGRPCWeb is gRPC-Web: length-prefixed frames with the status
+	// in a trailer frame.
+	ProtocolGRPCWeb
+)
+
+// RPCCodec marshals RPC messages. Name is the codec's suffix in content
+// types ("proto", "json"); a protobuf codec is typically a thin wrapper
+// around proto.Marshal and proto.Unmarshal.
+type RPCCodec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes messages with encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string                               { return "json" }
+func (JSONCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// RPCError is an error status returned by an RPC. Code is the Connect
+// name of the status, e.g. "not_found".
+type RPCError struct {
+	Code    string
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	if e.Message == "" {
+		return "rpc error: " + e.Code
+	}
+	return fmt.Sprintf("rpc error: %s: %s", e.Code, e.Message)
+}
+
+// rpcCodes are the Connect names of the gRPC status codes, by number.
+var rpcCodes = []string{
+	"ok", "canceled", "unknown", "invalid_argument", "deadline_exceeded", "not_found",
+	"already_exists", "permission_denied", "resource_exhausted", "failed_precondition",
+	"aborted", "out_of_range", "unimplemented", "internal", "unavailable", "data_loss",
+	"unauthenticated",
+}
+
+// RPCClient calls unary Connect or gRPC-Web procedures over ordinary HTTP
+// requests.
+type RPCClient struct {
+	BaseURL  string
+	Protocol RPCProtocol
+	Codec    RPCCodec // defaults to JSONCodec
+	Options  []Option // applied to every call
+}
+
+// CallUnary invokes procedure (e.g. "/acme.user.v1.UserService/GetUser")
+// with req and decodes the reply into resp. Error statuses are returned as
+// *RPCError.
+func (c *RPCClient) CallUnary(ctx context.Context, procedure string, req, resp interface{}) error {
+	codec := c.Codec
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	message, err := codec.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	opts := append([]Option{}, c.Options...)
+	opts = append(opts, WithContext(ctx), WithMethod(http.MethodPost), WithURL(strings.TrimSuffix(c.BaseURL, "/")+procedure))
+	if c.Protocol == ProtocolGRPCWeb {
+		opts = append(opts,
+			WithBody(grpcWebFrame(0, message)),
+			withHeader("Content-Type", "application/grpc-web+"+codec.Name()),
+			withHeader("X-Grpc-Web", "1"),
+		)
+		if deadline, ok := ctx.Deadline(); ok {
+			opts = append(opts, withHeader("Grpc-Timeout", fmt.Sprintf("%dm", max(time.Until(deadline).Milliseconds(), 1))))
+		}
+	} else {
+		opts = append(opts,
+			WithBody(message),
+			withHeader("Content-Type", "application/"+codec.Name()),
+			withHeader("Connect-Protocol-Version", "1"),
+		)
+		if deadline, ok := ctx.Deadline(); ok {
+			opts = append(opts, withHeader("Connect-Timeout-Ms", fmt.Sprint(max(time.Until(deadline).Milliseconds(), 1))))
+		}
+	}
+
+	statusCode, header, body, err := MakeHTTPRequest(opts...)
+	if err != nil {
+		return err
+	}
+	if c.Protocol == ProtocolGRPCWeb {
+		message, err = decodeGRPCWeb(statusCode, header, body)
+	} else {
+		message, err = decodeConnectUnary(statusCode, body)
+	}
+	if err != nil {
+		return err
+	}
+	if resp != nil {
+		if err := codec.Unmarshal(message, resp); err != nil {
+			return fmt.Errorf("failed to resolve response: %w", err)
+		}
+	}
+	return nil
+}
+
+func decodeConnectUnary(statusCode int, body []byte) ([]byte, error) {
+	if statusCode == http.StatusOK {
+		return body, nil
+	}
+	var status struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil || status.Code == "" {
+		return nil, &RPCError{Code: connectCodeForHTTP(statusCode), Message: strings.TrimSpace(string(body))}
+	}
+	return nil, &RPCError{Code: status.Code, Message: status.Message}
+}
+
+// connectCodeForHTTP maps HTTP statuses of non-Connect error responses,
+// e.g. from a proxy, as the Connect protocol specifies.
+func connectCodeForHTTP(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "internal"
+	case http.StatusUnauthorized:
+		return "unauthenticated"
+	case http.StatusForbidden:
+		return "permission_denied"
+	case http.StatusNotFound:
+		return "unimplemented"
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return "unavailable"
+	default:
+		return "unknown"
+	}
+}
+
+func grpcWebFrame(flags byte, data []byte) []byte {
+	frame := make([]byte, 5+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[5:], data)
+	return frame
+}
+
+// decodeGRPCWeb returns the message of a unary gRPC-Web response, taking
+// the status from the trailer frame or, for trailers-only responses, the
+// headers.
+func decodeGRPCWeb(statusCode int, header http.Header, body []byte) ([]byte, error) {
+	if statusCode != http.StatusOK {
+		return nil, &RPCError{Code: connectCodeForHTTP(statusCode), Message: strings.TrimSpace(string(body))}
+	}
+	var message []byte
+	trailer := textproto.MIMEHeader(header)
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return nil, errors.New("failed to read gRPC-Web response: truncated frame")
+		}
+		flags, size := body[0], binary.BigEndian.Uint32(body[1:5])
+		if uint64(len(body)-5) < uint64(size) {
+			return nil, errors.New("failed to read gRPC-Web response: truncated frame")
+		}
+		data := body[5 : 5+size]
+		body = body[5+size:]
+		switch {
+		case flags&0x01 != 0:
+			return nil, errors.New("failed to read gRPC-Web response: compressed frames are not supported")
+		case flags&0x80 != 0:
+			trailer = parseGRPCWebTrailer(data)
+		default:
+			message = data
+		}
+	}
+
+	code, err := strconv.Atoi(trailer.Get("Grpc-Status"))
+	if err != nil {
+		return nil, errors.New("failed to read gRPC-Web response: missing grpc-status")
+	}
+	if code != 0 {
+		name := "unknown"
+		if code >= 0 && code < len(rpcCodes) {
+			name = rpcCodes[code]
+		}
+		// grpc-message is percent-encoded.
+		message, err := url.PathUnescape(trailer.Get("Grpc-Message"))
+		if err != nil {
+			message = trailer.Get("Grpc-Message")
+		}
+		return nil, &RPCError{Code: name, Message: message}
+	}
+	return message, nil
+}
+
+func parseGRPCWebTrailer(data []byte) textproto.MIMEHeader {
+	trailer := make(textproto.MIMEHeader)
+	for _, line := range bytes.Split(data, []byte("\r\n")) {
+		name, value, ok := strings.Cut(string(line), ":")
+		if ok {
+			trailer.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	return trailer
+}
@@ -0,0 +1,107 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+type greetResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+func TestRPCClient_Connect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/greet.v1.GreetService/Greet", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "1", r.Header.Get("Connect-Protocol-Version"))
+		assert.NotEmpty(t, r.Header.Get("Connect-Timeout-Ms"))
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == `{"name":""}` {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"invalid_argument","message":"name is required"}`))
+			return
+		}
+		w.Write([]byte(`{"greeting":"Hello, Ada"}`))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client := &httpclientutils.RPCClient{BaseURL: ts.URL}
+
+	var resp greetResponse
+	assert.NoError(t, client.CallUnary(ctx, "/greet.v1.GreetService/Greet", greetRequest{Name: "Ada"}, &resp))
+	assert.Equal(t, "Hello, Ada", resp.Greeting)
+
+	err := client.CallUnary(ctx, "/greet.v1.GreetService/Greet", greetRequest{}, &resp)
+	var rpcErr *httpclientutils.RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, "invalid_argument", rpcErr.Code)
+		assert.Equal(t, "name is required", rpcErr.Message)
+	}
+}
+
+func grpcWebFrame(flags byte, data string) []byte {
+	frame := make([]byte, 5, 5+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+func TestRPCClient_GRPCWeb(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/grpc-web+json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "1", r.Header.Get("X-Grpc-Web"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, grpcWebFrame(0, `{"name":"Ada"}`), body)
+
+		w.Header().Set("Content-Type", "application/grpc-web+json")
+		switch r.URL.Path {
+		case "/greet.v1.GreetService/Missing":
+			w.Header().Set("Grpc-Status", "12")
+			w.Header().Set("Grpc-Message", "no%20such%20method")
+			return
+		case "/greet.v1.GreetService/Negative":
+			w.Header().Set("Grpc-Status", "-1")
+			return
+		case "/greet.v1.GreetService/OutOfRange":
+			w.Header().Set("Grpc-Status", "99")
+			return
+		}
+		w.Write(grpcWebFrame(0, `{"greeting":"Hello, Ada"}`))
+		w.Write(grpcWebFrame(0x80, "grpc-status: 0\r\ngrpc-message: \r\n"))
+	}))
+	defer ts.Close()
+
+	client := &httpclientutils.RPCClient{BaseURL: ts.URL, Protocol: httpclientutils.ProtocolGRPCWeb}
+	var resp greetResponse
+	assert.NoError(t, client.CallUnary(context.Background(), "/greet.v1.GreetService/Greet", greetRequest{Name: "Ada"}, &resp))
+	assert.Equal(t, "Hello, Ada", resp.Greeting)
+
+	err := client.CallUnary(context.Background(), "/greet.v1.GreetService/Missing", greetRequest{Name: "Ada"}, &resp)
+	var rpcErr *httpclientutils.RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, "unimplemented", rpcErr.Code)
+		assert.Equal(t, "no such method", rpcErr.Message)
+	}
+
+	for _, method := range []string{"Negative", "OutOfRange"} {
+		err := client.CallUnary(context.Background(), "/greet.v1.GreetService/"+method, greetRequest{Name: "Ada"}, &resp)
+		if assert.ErrorAs(t, err, &rpcErr, method) {
+			assert.Equal(t, "unknown", rpcErr.Code)
+		}
+	}
+}
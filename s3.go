@@ -0,0 +1,263 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 multipart limits: parts other than the last must be at least 5 MiB.
+const (
+	minS3PartSize     = 5 << 20
+	defaultS3PartSize = 8 << 20
+)
+
+// S3Client talks to S3-compatible object storage (AWS S3, MinIO, R2) with
+// SigV4-signed requests. It covers the object basics small tools need and
+// is not a replacement for the AWS SDK.
+type S3Client struct {
+	Endpoint    string // e.g. "https://s3.us-east-1.amazonaws.com" or a MinIO URL
+	Region      string // "auto" for R2
+	Credentials AWSCredentials
+
+	// VirtualHosted addresses buckets as "<bucket>.<endpoint host>" instead
+	// of the path-style "<endpoint>/<bucket>" most compatible stores expect.
+	VirtualHosted bool
+
+	// PartSize is the multipart part size for PutObject; bodies up to one
+	// part are sent in a single request. Defaults to 8 MiB.
+	PartSize int
+
+	// Options apply to every request, e.g. WithTimeout or WithStats.
+	Options []Option
+}
+
+// NewS3Client returns an S3Client using path-style addressing.
+func NewS3Client(endpoint, region string, credentials AWSCredentials) *S3Client {
+	return &S3Client{Endpoint: strings.TrimSuffix(endpoint, "/"), Region: region, Credentials: credentials}
+}
+
+// S3Error is an error response from the object store.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// S3Object is an entry returned by ListObjects.
+type S3Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	ETag         string    `xml:"ETag"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// GetObject downloads an object.
+func (c *S3Client) GetObject(ctx context.Context, bucket, key string) ([]byte, http.Header, error) {
+	_, header, body, err := c.do(ctx, http.MethodGet, bucket, key, nil, nil, nil)
+	return body, header, err
+}
+
+// DeleteObject deletes an object.
+func (c *S3Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	_, _, _, err := c.do(ctx, http.MethodDelete, bucket, key, nil, nil, nil)
+	return err
+}
+
+// PutObject uploads body, reading at most one part into memory at a time.
+// Bodies larger than PartSize are sent as a multipart upload, which is
+// aborted if any part fails.
+func (c *S3Client) PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string) error {
+	partSize := c.PartSize
+	if partSize <= 0 {
+		partSize = defaultS3PartSize
+	}
+	partSize = max(partSize, minS3PartSize)
+
+	first, err := readPart(body, partSize)
+	if err != nil {
+		return fmt.Errorf("failed to read object body: %w", err)
+	}
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	if len(first) < partSize {
+		_, _, _, err := c.do(ctx, http.MethodPut, bucket, key, nil, headers, first)
+		return err
+	}
+	return c.multipartUpload(ctx, bucket, key, headers, first, body, partSize)
+}
+
+func (c *S3Client) multipartUpload(ctx context.Context, bucket, key string, headers map[string]string, first []byte, body io.Reader, partSize int) (err error) {
+	_, _, response, err := c.do(ctx, http.MethodPost, bucket, key, url.Values{"uploads": {""}}, headers, nil)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(response, &initiated); err != nil {
+		return fmt.Errorf("failed to resolve response: %w", err)
+	}
+	upload := url.Values{"uploadId": {initiated.UploadID}}
+	defer func() {
+		if err != nil {
+			c.do(context.WithoutCancel(ctx), http.MethodDelete, bucket, key, upload, nil, nil)
+		}
+	}()
+
+	type completedPart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var parts []completedPart
+	for part := first; len(part) > 0; {
+		number := len(parts) + 1
+		query := url.Values{"uploadId": {initiated.UploadID}, "partNumber": {strconv.Itoa(number)}}
+		_, header, _, err := c.do(ctx, http.MethodPut, bucket, key, query, nil, part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: header.Get("ETag")})
+		if part, err = readPart(body, partSize); err != nil {
+			return fmt.Errorf("failed to read object body: %w", err)
+		}
+	}
+
+	complete, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	_, _, response, err = c.do(ctx, http.MethodPost, bucket, key, upload, map[string]string{"Content-Type": "application/xml"}, complete)
+	if err != nil {
+		return err
+	}
+	// CompleteMultipartUpload can fail with a 200 and an error document.
+	if s3Err := parseS3Error(http.StatusOK, response); s3Err != nil {
+		return s3Err
+	}
+	return nil
+}
+
+// readPart reads up to size bytes, returning fewer only at the end of r.
+func readPart(r io.Reader, size int) ([]byte, error) {
+	buf := make([]byte, size)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:n], err
+}
+
+// ListObjects lists every object under prefix, following continuation
+// tokens (ListObjectsV2).
+func (c *S3Client) ListObjects(ctx context.Context, bucket, prefix string) ([]S3Object, error) {
+	var objects []S3Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		_, _, body, err := c.do(ctx, http.MethodGet, bucket, "", query, nil, nil)
+		if err != nil {
+			return objects, err
+		}
+		var page struct {
+			Contents              []S3Object `xml:"Contents"`
+			IsTruncated           bool       `xml:"IsTruncated"`
+			NextContinuationToken string     `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return objects, fmt.Errorf("failed to resolve response: %w", err)
+		}
+		objects = append(objects, page.Contents...)
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// do sends a signed request and turns error responses into *S3Error.
+func (c *S3Client) do(ctx context.Context, method, bucket, key string, query url.Values, headers map[string]string, body []byte) (int, http.Header, []byte, error) {
+	opts := append([]Option{}, c.Options...)
+	opts = append(opts,
+		WithContext(ctx),
+		WithMethod(method),
+		WithURL(c.objectURL(bucket, key, query)),
+		WithSigV4(SigV4Options{Credentials: c.Credentials, Region: c.Region, Service: "s3"}),
+	)
+	for name, value := range headers {
+		opts = append(opts, withHeader(name, value))
+	}
+	if body != nil {
+		opts = append(opts, WithBody(body))
+	}
+	statusCode, header, response, err := MakeHTTPRequest(opts...)
+	if err != nil {
+		return statusCode, header, response, err
+	}
+	if statusCode >= http.StatusMultipleChoices {
+		if s3Err := parseS3Error(statusCode, response); s3Err != nil {
+			return statusCode, header, response, s3Err
+		}
+		return statusCode, header, response, &S3Error{StatusCode: statusCode, Message: http.StatusText(statusCode)}
+	}
+	return statusCode, header, response, nil
+}
+
+func parseS3Error(statusCode int, body []byte) *S3Error {
+	if !bytes.Contains(body, []byte("<Error>")) {
+		return nil
+	}
+	s3Err := &S3Error{}
+	if err := xml.Unmarshal(body, s3Err); err != nil {
+		return nil
+	}
+	s3Err.StatusCode = statusCode
+	return s3Err
+}
+
+// objectURL builds the request URL, escaping each key segment once as
+// S3's SigV4 canonical path requires.
+func (c *S3Client) objectURL(bucket, key string, query url.Values) string {
+	path := ""
+	if key != "" {
+		segments := strings.Split(key, "/")
+		for i, segment := range segments {
+			segments[i] = awsEscape(segment)
+		}
+		path = "/" + strings.Join(segments, "/")
+	}
+
+	target := c.Endpoint + "/" + bucket + path
+	if c.VirtualHosted {
+		if u, err := url.Parse(c.Endpoint); err == nil {
+			u.Host = bucket + "." + u.Host
+			target = strings.TrimSuffix(u.String(), "/") + orDefault(path, "/")
+		}
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target
+}
@@ -0,0 +1,119 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal path-style object store.
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+	parts   map[string]map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.True(f.t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+	assert.NotEmpty(f.t, r.Header.Get("X-Amz-Content-Sha256"))
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, query.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		// One object per page to exercise continuation.
+		start := 0
+		if token := query.Get("continuation-token"); token != "" {
+			fmt.Sscan(token, &start)
+		}
+		fmt.Fprintf(w, `<ListBucketResult><Contents><Key>%s</Key><Size>%d</Size></Contents><IsTruncated>%t</IsTruncated><NextContinuationToken>%d</NextContinuationToken></ListBucketResult>`,
+			keys[start], len(f.objects[keys[start]]), start+1 < len(keys), start+1)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.parts[key] = map[string][]byte{}
+		w.Write([]byte(`<InitiateMultipartUploadResult><UploadId>up-1</UploadId></InitiateMultipartUploadResult>`))
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		f.parts[key][query.Get("partNumber")] = body
+		w.Header().Set("ETag", `"etag-`+query.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		var complete struct {
+			Parts []struct {
+				PartNumber string
+				ETag       string
+			} `xml:"Part"`
+		}
+		assert.NoError(f.t, xml.Unmarshal(body, &complete))
+		var object []byte
+		for _, part := range complete.Parts {
+			assert.Equal(f.t, `"etag-`+part.PartNumber+`"`, part.ETag)
+			object = append(object, f.parts[key][part.PartNumber]...)
+		}
+		f.objects[key] = object
+		w.Write([]byte(`<CompleteMultipartUploadResult/>`))
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+	case r.Method == http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		w.Write(object)
+	}
+}
+
+func TestS3Client_PutGetList(t *testing.T) {
+	store := &fakeS3{t: t, objects: map[string][]byte{}, parts: map[string]map[string][]byte{}}
+	ts := httptest.NewServer(store)
+	defer ts.Close()
+
+	client := httpclientutils.NewS3Client(ts.URL, "us-east-1", httpclientutils.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.PartSize = 5 << 20
+	ctx := context.Background()
+
+	assert.NoError(t, client.PutObject(ctx, "bucket", "docs/small file.txt", strings.NewReader("hello"), "text/plain"))
+	body, _, err := client.GetObject(ctx, "bucket", "docs/small file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	large := bytes.Repeat([]byte("0123456789abcdef"), (5<<20)/16*2+4) // just over two minimum parts
+	assert.NoError(t, client.PutObject(ctx, "bucket", "docs/large.bin", bytes.NewReader(large), ""))
+	assert.Len(t, store.parts["docs/large.bin"], 3)
+	assert.Equal(t, large, store.objects["docs/large.bin"])
+
+	objects, err := client.ListObjects(ctx, "bucket", "docs/")
+	assert.NoError(t, err)
+	if assert.Len(t, objects, 2) {
+		assert.Equal(t, "docs/large.bin", objects[0].Key)
+		assert.Equal(t, int64(5), objects[1].Size)
+	}
+
+	_, _, err = client.GetObject(ctx, "bucket", "missing")
+	var s3Err *httpclientutils.S3Error
+	if assert.ErrorAs(t, err, &s3Err) {
+		assert.Equal(t, http.StatusNotFound, s3Err.StatusCode)
+		assert.Equal(t, "NoSuchKey", s3Err.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package httpclientutils
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ResponseHandler receives the result of a scheduled request.
+type ResponseHandler func(statusCode int, header http.Header, body []byte, err error)
+
+// DoAt waits until t and then sends the request. Cancelling the context set
+// with WithContext before t abandons the request and returns its error.
+func DoAt(t time.Time, opts ...Option) (int, http.Header, []byte, error) {
+	ctx := optionsContext(opts)
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return 0, nil, nil, ctx.Err()
+	case <-timer.C:
+	}
+	return MakeHTTPRequest(opts...)
+}
+
+// DoEvery sends the request every interval, passing each result to handler,
+// until the context set with WithContext is done; it then returns the
+// context error. Ticks that fall due while a request is still running are
+// skipped rather than queued.
+func DoEvery(interval time.Duration, handler ResponseHandler, opts ...Option) error {
+	ctx := optionsContext(opts)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		statusCode, header, body, err := MakeHTTPRequest(opts...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		handler(statusCode, header, body, err)
+	}
+}
+
+// optionsContext returns the context opts would configure.
+func optionsContext(opts []Option) context.Context {
+	return newRequestOptions(opts).Context
+}
@@ -0,0 +1,53 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoAt_WaitsUntilTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	start := time.Now()
+	status, _, _, err := httpclientutils.DoAt(start.Add(50*time.Millisecond), httpclientutils.WithURL(ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestDoAt_CancelledBeforeTime(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { atomic.AddInt32(&hits, 1) }))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, _, err := httpclientutils.DoAt(time.Now().Add(time.Hour), httpclientutils.WithURL(ts.URL), httpclientutils.WithContext(ctx))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+}
+
+func TestDoEvery_PollsUntilCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("tick")) }))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	err := httpclientutils.DoEvery(5*time.Millisecond, func(statusCode int, header http.Header, body []byte, err error) {
+		assert.NoError(t, err)
+		assert.Equal(t, "tick", string(body))
+		if atomic.AddInt32(&calls, 1) == 3 {
+			cancel()
+		}
+	}, httpclientutils.WithURL(ts.URL), httpclientutils.WithContext(ctx))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
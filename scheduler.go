@@ -0,0 +1,171 @@
+package httpclientutils
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Priority orders requests waiting for a Scheduler slot.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// ShedError is returned when a request is dropped by a Scheduler, either on
+// arrival or because a higher priority request preempted its queue slot.
+type ShedError struct {
+	Priority Priority
+}
+
+func (e *ShedError) Error() string {
+	return fmt.Sprintf("request shed: %s priority request dropped by scheduler", e.Priority)
+}
+
+// Scheduler limits the number of in-flight requests. Once the limit is hit,
+// requests wait in a queue ordered by priority; when the queue is full, the
+// lowest priority request is shed.
+type Scheduler struct {
+	maxInFlight int
+	maxQueue    int
+
+	mu       sync.Mutex
+	inFlight int
+	queue    waitQueue
+	seq      uint64
+}
+
+// NewScheduler returns a Scheduler allowing maxInFlight concurrent requests
+// and up to maxQueue waiting ones. A maxInFlight of zero or less defaults
+// to 1, so requests are serialized rather than queued forever. A maxQueue
+// of zero means unbounded.
+func NewScheduler(maxInFlight, maxQueue int) *Scheduler {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	return &Scheduler{maxInFlight: maxInFlight, maxQueue: maxQueue}
+}
+
+func (s *Scheduler) acquire(ctx context.Context, priority Priority) error {
+	s.mu.Lock()
+	if s.inFlight < s.maxInFlight && s.queue.Len() == 0 {
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+
+	if s.maxQueue > 0 && s.queue.Len() >= s.maxQueue {
+		lowest := s.queue.lowest()
+		if s.queue[lowest].priority >= priority {
+			s.mu.Unlock()
+			return &ShedError{Priority: priority}
+		}
+		shed := heap.Remove(&s.queue, lowest).(*waiter)
+		shed.ready <- &ShedError{Priority: shed.priority}
+	}
+
+	s.seq++
+	w := &waiter{priority: priority, seq: s.seq, ready: make(chan error, 1)}
+	heap.Push(&s.queue, w)
+	s.mu.Unlock()
+
+	select {
+	case err := <-w.ready:
+		return err
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.index >= 0 {
+			heap.Remove(&s.queue, w.index)
+			return ctx.Err()
+		}
+		// The slot was handed over concurrently; pass it on.
+		if err := <-w.ready; err == nil {
+			s.releaseLocked()
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *Scheduler) releaseLocked() {
+	if s.queue.Len() > 0 {
+		// Hand the slot straight to the next waiter.
+		heap.Pop(&s.queue).(*waiter).ready <- nil
+		return
+	}
+	s.inFlight--
+}
+
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan error
+	index    int
+}
+
+// waitQueue is a heap of waiters, highest priority first, FIFO within a
+// priority.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// lowest returns the index of the waiter that would be served last.
+func (q waitQueue) lowest() int {
+	lowest := 0
+	for i := range q {
+		if q.Less(lowest, i) {
+			lowest = i
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,73 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_HighPriorityPreemptsLow(t *testing.T) {
+	entered := make(chan struct{}, 4)
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+	}))
+	defer ts.Close()
+	defer close(unblock)
+
+	scheduler := httpclientutils.NewScheduler(1, 1)
+	do := func(priority httpclientutils.Priority) chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, _, _, err := httpclientutils.MakeHTTPRequest(
+				httpclientutils.WithURL(ts.URL),
+				httpclientutils.WithScheduler(scheduler),
+				httpclientutils.WithPriority(priority),
+			)
+			done <- err
+		}()
+		return done
+	}
+
+	first := do(httpclientutils.PriorityNormal)
+	<-entered
+
+	low := do(httpclientutils.PriorityLow)
+	time.Sleep(50 * time.Millisecond)
+	high := do(httpclientutils.PriorityHigh)
+
+	var shed *httpclientutils.ShedError
+	assert.ErrorAs(t, <-low, &shed)
+	assert.Equal(t, httpclientutils.PriorityLow, shed.Priority)
+
+	assert.ErrorAs(t, <-do(httpclientutils.PriorityLow), &shed)
+
+	unblock <- struct{}{}
+	assert.NoError(t, <-first)
+	<-entered
+	unblock <- struct{}{}
+	assert.NoError(t, <-high)
+}
+
+func TestNewScheduler_DefaultsMaxInFlight(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	for _, maxInFlight := range []int{0, -1} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		status, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithScheduler(httpclientutils.NewScheduler(maxInFlight, 0)),
+			httpclientutils.WithContext(ctx),
+		)
+		cancel()
+		assert.NoError(t, err, maxInFlight)
+		assert.Equal(t, http.StatusOK, status)
+	}
+}
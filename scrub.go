@@ -0,0 +1,317 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces scrubbed values.
+const Redacted = "[REDACTED]"
+
+// Scrubber redacts sensitive data before it is logged or recorded. The
+// request pipeline never records bodies in the clear, so scrubbers see only
+// text (URLs and errors) and headers.
+type Scrubber interface {
+	ScrubText(text string) string
+	ScrubHeader(header http.Header) http.Header
+}
+
+// ChainScrubber applies each Scrubber in order.
+type ChainScrubber []Scrubber
+
+func (c ChainScrubber) ScrubText(text string) string {
+	for _, s := range c {
+		text = s.ScrubText(text)
+	}
+	return text
+}
+
+func (c ChainScrubber) ScrubHeader(header http.Header) http.Header {
+	for _, s := range c {
+		header = s.ScrubHeader(header)
+	}
+	return header
+}
+
+// PatternScrubber redacts text matching a pattern, and leaves headers
+// untouched. ScrubBody applies the same redaction to a body the caller logs
+// itself.
+type PatternScrubber struct {
+	Pattern *regexp.Regexp
+	// Match, if set, must also accept a candidate before it is redacted.
+	Match func(candidate string) bool
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// EmailScrubber redacts email addresses.
+func EmailScrubber() *PatternScrubber { return &PatternScrubber{Pattern: emailPattern} }
+
+// CardNumberScrubber redacts digit sequences that pass the Luhn check.
+func CardNumberScrubber() *PatternScrubber {
+	return &PatternScrubber{Pattern: cardPattern, Match: luhnValid}
+}
+
+func (s *PatternScrubber) ScrubText(text string) string {
+	return s.Pattern.ReplaceAllStringFunc(text, func(candidate string) string {
+		if s.Match != nil && !s.Match(candidate) {
+			return candidate
+		}
+		return Redacted
+	})
+}
+
+func (s *PatternScrubber) ScrubHeader(header http.Header) http.Header { return header }
+
+func (s *PatternScrubber) ScrubBody(contentType string, body []byte) []byte {
+	return []byte(s.ScrubText(string(body)))
+}
+
+func luhnValid(candidate string) bool {
+	sum, n := 0, 0
+	for i := len(candidate) - 1; i >= 0; i-- {
+		c := candidate[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// HeaderScrubber redacts the values of the named headers, and in text
+// the rest of any "Name: value" line, as found in dumped requests and
+// error messages.
+type HeaderScrubber []string
+
+func (s HeaderScrubber) ScrubText(text string) string {
+	for _, name := range s {
+		text = redactHeaderLines(text, name)
+	}
+	return text
+}
+
+// redactHeaderLines replaces the value after each "name:" in text, up to
+// the end of its line.
+func redactHeaderLines(text, name string) string {
+	lower, name := strings.ToLower(text), strings.ToLower(name)
+	var out strings.Builder
+	copied := 0
+	for from := 0; ; {
+		i := strings.Index(lower[from:], name)
+		if i < 0 || name == "" {
+			break
+		}
+		i += from
+		end := i + len(name)
+		from = end
+		if i > 0 && isTokenByte(lower[i-1]) {
+			continue
+		}
+		colon := end
+		for colon < len(text) && (text[colon] == ' ' || text[colon] == '\t') {
+			colon++
+		}
+		if colon >= len(text) || text[colon] != ':' {
+			continue
+		}
+		valueEnd := colon + 1
+		for valueEnd < len(text) && text[valueEnd] != '\r' && text[valueEnd] != '\n' {
+			valueEnd++
+		}
+		out.WriteString(text[copied : colon+1])
+		out.WriteString(" " + Redacted)
+		copied, from = valueEnd, valueEnd
+	}
+	out.WriteString(text[copied:])
+	return out.String()
+}
+
+func isTokenByte(c byte) bool {
+	return c == '-' || c == '_' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
+
+func (s HeaderScrubber) ScrubHeader(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+	scrubbed := header.Clone()
+	for _, name := range s {
+		if values := scrubbed.Values(name); len(values) > 0 {
+			scrubbed[http.CanonicalHeaderKey(name)] = []string{Redacted}
+		}
+	}
+	return scrubbed
+}
+
+// JSONPathScrubber redacts values at dot-separated paths in JSON bodies,
+// such as "user.email" or "cards.*.number", where "*" matches any key or
+// array element. Text that is not a JSON document, such as an error
+// quoting part of a body, has the scalar value of every member named by a
+// path's last key redacted wherever it appears.
+type JSONPathScrubber []string
+
+func (s JSONPathScrubber) ScrubText(text string) string {
+	if json.Valid([]byte(text)) {
+		return string(s.ScrubBody("application/json", []byte(text)))
+	}
+	for _, path := range s {
+		key := path[strings.LastIndex(path, ".")+1:]
+		if key != "*" && key != "" {
+			text = redactJSONMember(text, key)
+		}
+	}
+	return text
+}
+
+// redactJSONMember replaces the scalar values of "key" members in text.
+func redactJSONMember(text, key string) string {
+	quoted, _ := json.Marshal(key)
+	var out strings.Builder
+	copied := 0
+	for from := 0; ; {
+		i := strings.Index(text[from:], string(quoted))
+		if i < 0 {
+			break
+		}
+		start := i + from + len(quoted)
+		from = start
+		for start < len(text) && strings.ContainsRune(" \t\r\n", rune(text[start])) {
+			start++
+		}
+		if start >= len(text) || text[start] != ':' {
+			continue
+		}
+		start++
+		for start < len(text) && strings.ContainsRune(" \t\r\n", rune(text[start])) {
+			start++
+		}
+		end := start
+		switch {
+		case end >= len(text) || text[end] == '{' || text[end] == '[':
+			continue
+		case text[end] == '"':
+			for end++; end < len(text) && text[end] != '"'; end++ {
+				if text[end] == '\\' {
+					end++
+				}
+			}
+			end = min(end+1, len(text))
+		default:
+			for end < len(text) && !strings.ContainsRune(",}] \t\r\n", rune(text[end])) {
+				end++
+			}
+		}
+		out.WriteString(text[copied:start])
+		out.WriteString(`"` + Redacted + `"`)
+		copied, from = end, end
+	}
+	out.WriteString(text[copied:])
+	return out.String()
+}
+
+func (s JSONPathScrubber) ScrubHeader(header http.Header) http.Header { return header }
+
+// ScrubBody redacts the paths in a JSON body, for bodies the caller logs
+// itself; bodies of other content types are returned unchanged.
+func (s JSONPathScrubber) ScrubBody(contentType string, body []byte) []byte {
+	if !strings.Contains(contentType, "json") {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return body
+	}
+	for _, path := range s {
+		doc = redactPath(doc, strings.Split(path, "."))
+	}
+	scrubbed, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return scrubbed
+}
+
+func redactPath(node interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return Redacted
+	}
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path[0] == "*" || path[0] == key {
+				v[key] = redactPath(child, path[1:])
+			}
+		}
+	case []interface{}:
+		if path[0] == "*" {
+			for i, child := range v {
+				v[i] = redactPath(child, path[1:])
+			}
+		}
+	}
+	return node
+}
+
+// credentialHeaders are redacted from recorded headers whether or not a
+// Scrubber is configured.
+var credentialHeaders = HeaderScrubber{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// scrubHeader returns a copy of header fit for recording: credentials are
+// always redacted, then options.Scrubber is applied.
+func scrubHeader(options *RequestOptions, header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	header = credentialHeaders.ScrubHeader(header)
+	if options.Scrubber != nil {
+		header = options.Scrubber.ScrubHeader(header)
+	}
+	return header
+}
+
+// scrubbedError carries the scrubbed message of an error while still
+// unwrapping to it, so errors.Is and errors.As keep working.
+type scrubbedError struct {
+	err  error
+	text string
+}
+
+func (e *scrubbedError) Error() string { return e.text }
+
+func (e *scrubbedError) Unwrap() error { return e.err }
+
+// scrubError applies options.Scrubber to the message of err.
+func scrubError(options *RequestOptions, err error) error {
+	if err == nil || options.Scrubber == nil {
+		return err
+	}
+	text := options.Scrubber.ScrubText(err.Error())
+	if text == err.Error() {
+		return err
+	}
+	return &scrubbedError{err: err, text: text}
+}
+
+// scrubText applies options.Scrubber to text when one is configured.
+func scrubText(options *RequestOptions, text string) string {
+	if options.Scrubber == nil {
+		return text
+	}
+	return options.Scrubber.ScrubText(text)
+}
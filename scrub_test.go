@@ -0,0 +1,105 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubbers_Text(t *testing.T) {
+	scrubber := httpclientutils.ChainScrubber{httpclientutils.EmailScrubber(), httpclientutils.CardNumberScrubber()}
+	assert.Equal(t,
+		"contact [REDACTED] paid with [REDACTED], order 1234567890123",
+		scrubber.ScrubText("contact jane.doe@example.com paid with 4111 1111 1111 1111, order 1234567890123"),
+	)
+}
+
+func TestScrubbers_HeaderAndJSONPath(t *testing.T) {
+	header := http.Header{"Authorization": {"Bearer token"}, "Accept": {"application/json"}}
+	scrubbed := httpclientutils.HeaderScrubber{"authorization"}.ScrubHeader(header)
+	assert.Equal(t, "[REDACTED]", scrubbed.Get("Authorization"))
+	assert.Equal(t, "application/json", scrubbed.Get("Accept"))
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+
+	body := httpclientutils.JSONPathScrubber{"user.email", "cards.*.number"}.ScrubBody(
+		"application/json",
+		[]byte(`{"user":{"email":"a@b.co","id":7},"cards":[{"number":"4111","brand":"visa"}]}`),
+	)
+	assert.JSONEq(t, `{"user":{"email":"[REDACTED]","id":7},"cards":[{"number":"[REDACTED]","brand":"visa"}]}`, string(body))
+}
+
+func TestScrubbers_AppliedToAuditLog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/users?email=jane@example.com"),
+		httpclientutils.WithAuditLog(httpclientutils.NewJSONAuditSink(&buf)),
+		httpclientutils.WithScrubber(httpclientutils.EmailScrubber()),
+	)
+	assert.NoError(t, err)
+
+	var record httpclientutils.AuditRecord
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, ts.URL+"/users?email=[REDACTED]", record.URL)
+}
+
+func TestScrubbers_TextForms(t *testing.T) {
+	assert.Equal(t,
+		"GET / HTTP/1.1\r\nX-Api-Token: [REDACTED]\r\nAccept: */*",
+		httpclientutils.HeaderScrubber{"x-api-token"}.ScrubText("GET / HTTP/1.1\r\nX-Api-Token: s3cret\r\nAccept: */*"),
+	)
+	assert.Equal(t,
+		`status 400: {"email":"[REDACTED]","id":7,"card":{"number":"[REDACTED]"}`,
+		httpclientutils.JSONPathScrubber{"user.email", "card.number"}.ScrubText(`status 400: {"email":"a@b.co","id":7,"card":{"number":4111}`),
+	)
+}
+
+func TestScrubbers_AppliedToAuditHeadersAndEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("X-Request-Id", "42")
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithHeaders(map[string]string{"Authorization": "Bearer t0ken", "X-Tenant-Secret": "hunter2", "Accept": "text/plain"}),
+		httpclientutils.WithAuditLog(httpclientutils.NewJSONAuditSink(&buf)),
+		httpclientutils.WithScrubber(httpclientutils.HeaderScrubber{"X-Tenant-Secret"}),
+	)
+	assert.NoError(t, err)
+	var record httpclientutils.AuditRecord
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "[REDACTED]", record.RequestHeader.Get("Authorization"))
+	assert.Equal(t, "[REDACTED]", record.RequestHeader.Get("X-Tenant-Secret"))
+	assert.Equal(t, "text/plain", record.RequestHeader.Get("Accept"))
+	assert.Equal(t, "[REDACTED]", record.ResponseHeader.Get("Set-Cookie"))
+	assert.Equal(t, "42", record.ResponseHeader.Get("X-Request-Id"))
+	assert.NotContains(t, buf.String(), "hunter2")
+
+	bus := httpclientutils.NewEventBus()
+	events, cancel := bus.Subscribe(8)
+	defer cancel()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(closed.URL+"/users?email=jane@example.com"),
+		httpclientutils.WithEventBus(bus),
+		httpclientutils.WithScrubber(httpclientutils.EmailScrubber()),
+	)
+	assert.Error(t, err)
+	for event := range events {
+		if failed, ok := event.(httpclientutils.RequestFailed); ok {
+			assert.NotContains(t, failed.Err.Error(), "jane@example.com")
+			break
+		}
+	}
+}
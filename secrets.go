@@ -0,0 +1,179 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider fetches a credential at request time.
+type SecretProvider interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// SecretFunc adapts a func to a SecretProvider.
+type SecretFunc func(ctx context.Context) (string, error)
+
+func (f SecretFunc) Secret(ctx context.Context) (string, error) { return f(ctx) }
+
+// EnvSecret reads the secret from the named environment variable.
+type EnvSecret string
+
+func (e EnvSecret) Secret(context.Context) (string, error) {
+	value, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return value, nil
+}
+
+// FileSecret reads the secret from the named file, trimming surrounding
+// whitespace. The file is re-read on every call, so rotated mounts are
+// picked up.
+type FileSecret string
+
+func (f FileSecret) Secret(context.Context) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// CachedSecret caches the value of Provider for TTL, so remote providers
+// are not queried on every request.
+type CachedSecret struct {
+	Provider SecretProvider
+	TTL      time.Duration
+
+	mu        sync.Mutex
+	value     string
+	fetchedAt time.Time
+}
+
+func (c *CachedSecret) Secret(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetchedAt.IsZero() && time.Since(c.fetchedAt) < c.TTL {
+		return c.value, nil
+	}
+	value, err := c.Provider.Secret(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.value, c.fetchedAt = value, time.Now()
+	return value, nil
+}
+
+// VaultSecret reads a field of a HashiCorp Vault KV secret, supporting both
+// KV version 1 and version 2 mounts.
+type VaultSecret struct {
+	Address string // e.g. https://vault.internal:8200
+	Token   SecretProvider
+	Path    string // e.g. secret/data/payments
+	Field   string
+}
+
+func (v *VaultSecret) Secret(ctx context.Context) (string, error) {
+	token, err := v.Token.Secret(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch vault token: %w", err)
+	}
+
+	var result struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	status, _, _, err := MakeHTTPRequest(
+		WithContext(ctx),
+		WithURL(strings.TrimSuffix(v.Address, "/")+"/v1/"+strings.TrimPrefix(v.Path, "/")),
+		WithHeaders(map[string]string{"X-Vault-Token": token}),
+		WithResolveResponse(&result),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to read vault secret: unexpected status %d", status)
+	}
+
+	// KV v2 nests the secret under data.data.
+	fields := result.Data
+	var nested map[string]json.RawMessage
+	if raw, ok := result.Data["data"]; ok && json.Unmarshal(raw, &nested) == nil {
+		fields = nested
+	}
+	var value string
+	if err := json.Unmarshal(fields[v.Field], &value); err != nil {
+		return "", fmt.Errorf("vault secret has no string field %q", v.Field)
+	}
+	return value, nil
+}
+
+// AWSSecret reads a secret string from AWS Secrets Manager.
+type AWSSecret struct {
+	SecretID    string
+	Region      string
+	Credentials AWSCredentials
+	// Endpoint overrides the regional endpoint, e.g. for VPC endpoints.
+	Endpoint string
+}
+
+func (a *AWSSecret) Secret(ctx context.Context) (string, error) {
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + a.Region + ".amazonaws.com/"
+	}
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	status, _, body, err := MakeHTTPRequest(
+		WithContext(ctx),
+		WithMethod(http.MethodPost),
+		WithURL(endpoint),
+		WithBody(map[string]string{"SecretId": a.SecretID}),
+		WithHeaders(map[string]string{
+			"Content-Type": "application/x-amz-json-1.1",
+			"X-Amz-Target": "secretsmanager.GetSecretValue",
+		}),
+		WithSigV4(SigV4Options{Credentials: a.Credentials, Region: a.Region, Service: "secretsmanager"}),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to read AWS secret: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to read AWS secret: unexpected status %d: %s", status, body)
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse AWS secret: %w", err)
+	}
+	if result.SecretString == "" {
+		return "", errors.New("AWS secret has no SecretString")
+	}
+	return result.SecretString, nil
+}
+
+// applySecrets fetches secret-backed credentials and sets them on req.
+func applySecrets(req *http.Request, options *RequestOptions) error {
+	if options.SecretBasicAuth != nil {
+		password, err := options.SecretBasicAuth.Password.Secret(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to fetch credentials: %w", err)
+		}
+		req.SetBasicAuth(options.SecretBasicAuth.Username, password)
+	}
+	if options.BearerSecret != nil {
+		token, err := options.BearerSecret.Secret(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to fetch credentials: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
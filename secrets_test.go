@@ -0,0 +1,106 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretProviders_EnvFileAndCache(t *testing.T) {
+	t.Setenv("HTTPCLIENTUTILS_TEST_SECRET", "from-env")
+	value, err := httpclientutils.EnvSecret("HTTPCLIENTUTILS_TEST_SECRET").Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("from-file\n"), 0o600)
+	value, err = httpclientutils.FileSecret(path).Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+
+	calls := 0
+	cached := &httpclientutils.CachedSecret{
+		Provider: httpclientutils.SecretFunc(func(context.Context) (string, error) { calls++; return "cached", nil }),
+		TTL:      time.Minute,
+	}
+	cached.Secret(context.Background())
+	cached.Secret(context.Background())
+	assert.Equal(t, 1, calls)
+}
+
+func TestSecretProviders_AppliedAtRequestTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/basic" {
+			user, pass, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "svc", user)
+			assert.Equal(t, "rotated", pass)
+			return
+		}
+		assert.Equal(t, "Bearer rotated", r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	secret := httpclientutils.SecretFunc(func(context.Context) (string, error) { return "rotated", nil })
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/basic"),
+		httpclientutils.WithBasicAuthFromSecret("svc", secret),
+	)
+	assert.NoError(t, err)
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL+"/bearer"),
+		httpclientutils.WithBearerFromSecret(secret),
+	)
+	assert.NoError(t, err)
+}
+
+func TestVaultSecret_ReadsKV2Field(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/secret/data/payments", r.URL.Path)
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"data":{"api_key":"s3cr3t"},"metadata":{"version":3}}}`))
+	}))
+	defer ts.Close()
+
+	vault := &httpclientutils.VaultSecret{
+		Address: ts.URL,
+		Token:   httpclientutils.SecretFunc(func(context.Context) (string, error) { return "vault-token", nil }),
+		Path:    "secret/data/payments",
+		Field:   "api_key",
+	}
+	value, err := vault.Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+}
+
+func TestAWSSecret_GetSecretValue(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "prod/db", req["SecretId"])
+		w.Write([]byte(`{"SecretString":"hunter2"}`))
+	}))
+	defer ts.Close()
+
+	secret := &httpclientutils.AWSSecret{
+		SecretID:    "prod/db",
+		Region:      "eu-west-1",
+		Credentials: httpclientutils.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		Endpoint:    ts.URL,
+	}
+	value, err := secret.Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+}
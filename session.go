@@ -0,0 +1,196 @@
+package httpclientutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+)
+
+// ErrLoginFailed is returned when a login response does not satisfy the
+// success predicate.
+var ErrLoginFailed = errors.New("login failed")
+
+// LoginPredicate inspects a response, e.g. to decide whether a login
+// succeeded or a session has expired.
+type LoginPredicate func(statusCode int, header http.Header, body []byte) bool
+
+// Session is a Client that keeps the cookies and token of a login. Once
+// Login succeeds, a response that Expired matches logs in again with the
+// same credentials and replays the request once; requests with streamed
+// io.Reader bodies are not replayed.
+type Session struct {
+	*Client
+
+	// Expired reports whether a response means the session has lapsed; it
+	// defaults to a 401.
+	Expired LoginPredicate
+
+	mu            sync.Mutex
+	login         func() error
+	origin        string // of the login URL, the only one the token is sent to
+	token         string
+	authenticated bool
+	logins        int
+}
+
+// NewSession returns a Session whose client applies opts and stores
+// cookies in a jar of its own.
+func NewSession(opts ...Option) *Session {
+	// cookiejar.New only fails on options it is not given.
+	jar, _ := cookiejar.New(nil)
+	return &Session{Client: NewClient(append([]Option{WithCookieJar(jar)}, opts...)...)}
+}
+
+// Login submits credentials to loginURL and marks the session
+// authenticated if success accepts the response. url.Values are sent as a
+// form and anything else as the request body, JSON-encoded unless it is a
+// string or []byte. A top-level access_token or token member of a JSON
+// response is sent as a bearer token on later requests to the login URL's
+// origin, including redirects within it; cookies are kept in the session's
+// jar. A nil success accepts any 2xx response.
+func (s *Session) Login(loginURL string, credentials interface{}, success LoginPredicate) error {
+	if success == nil {
+		success = func(statusCode int, _ http.Header, _ []byte) bool { return statusCode >= 200 && statusCode < 300 }
+	}
+	opts := []Option{WithMethod(http.MethodPost), WithURL(loginURL), WithBody(credentials)}
+	if form, ok := credentials.(url.Values); ok {
+		opts = []Option{WithMethod(http.MethodPost), WithURL(loginURL), WithBody(form.Encode()), withHeader("Content-Type", "application/x-www-form-urlencoded")}
+	}
+	login := func() error {
+		statusCode, header, body, err := s.Client.Do(opts...)
+		if err != nil {
+			return fmt.Errorf("failed to log in: %w", err)
+		}
+		if !success(statusCode, header, body) {
+			s.authenticated = false
+			return fmt.Errorf("%w: status %d", ErrLoginFailed, statusCode)
+		}
+		var tokens struct {
+			AccessToken string `json:"access_token"`
+			Token       string `json:"token"`
+		}
+		if json.Unmarshal(body, &tokens) == nil {
+			s.token = orDefault(tokens.AccessToken, tokens.Token)
+		}
+		s.authenticated = true
+		s.logins++
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.login = login
+	s.origin = ""
+	if u, err := url.Parse(loginURL); err == nil {
+		s.origin = urlOrigin(u)
+	}
+	return login()
+}
+
+// Authenticated reports whether the last login succeeded.
+func (s *Session) Authenticated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authenticated
+}
+
+// Do sends a request with the session's credentials, logging in again
+// and replaying it once if the session has expired.
+func (s *Session) Do(opts ...Option) (int, http.Header, []byte, error) {
+	logins, credentials := s.credentials()
+	statusCode, header, body, err := s.Client.Do(append(append([]Option{}, opts...), credentials)...)
+	if err != nil || !s.expired(statusCode, header, body) || !replayableBody(newRequestOptions(opts).Body) {
+		return statusCode, header, body, err
+	}
+
+	if err := s.relogin(logins); err != nil {
+		return statusCode, header, body, fmt.Errorf("failed to renew session: %w", err)
+	}
+	_, credentials = s.credentials()
+	return s.Client.Do(append(append([]Option{}, opts...), credentials)...)
+}
+
+func (s *Session) Get(url string, opts ...Option) (int, http.Header, []byte, error) {
+	return s.Do(append([]Option{WithURL(url), WithMethod(http.MethodGet)}, opts...)...)
+}
+
+func (s *Session) Post(url string, body interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	return s.Do(append([]Option{WithURL(url), WithMethod(http.MethodPost), WithBody(body)}, opts...)...)
+}
+
+func (s *Session) PutJSON(url string, body interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	return s.Do(append([]Option{WithURL(url), WithMethod(http.MethodPut), WithBody(JSONBody(body))}, opts...)...)
+}
+
+// credentials returns the login count and an option sending the current
+// token, if any, to the login origin.
+func (s *Session) credentials() (int, Option) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth := &scopedAuthorization{origin: s.origin, value: "Bearer " + s.token}
+	if s.token == "" {
+		auth = nil
+	}
+	return s.logins, func(opts *RequestOptions) { opts.sessionAuth = auth }
+}
+
+// scopedAuthorization is an Authorization value sent only to one origin.
+type scopedAuthorization struct {
+	origin string
+	value  string
+}
+
+// scopedAuthTransport sets the session's Authorization on every hop to its
+// origin, redirects included, and on no other.
+type scopedAuthTransport struct {
+	base http.RoundTripper
+	auth *scopedAuthorization
+}
+
+func (t *scopedAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case urlOrigin(req.URL) == t.auth.origin:
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", t.auth.value)
+	case req.Header.Get("Authorization") == t.auth.value:
+		req = req.Clone(req.Context())
+		req.Header.Del("Authorization")
+	}
+	return t.base.RoundTrip(req)
+}
+
+func wrapSessionAuth(options *RequestOptions, base http.RoundTripper) http.RoundTripper {
+	if options.sessionAuth == nil {
+		return base
+	}
+	return &scopedAuthTransport{base: base, auth: options.sessionAuth}
+}
+
+func (s *Session) expired(statusCode int, header http.Header, body []byte) bool {
+	s.mu.Lock()
+	loggedIn := s.login != nil
+	s.mu.Unlock()
+	if !loggedIn {
+		return false
+	}
+	if s.Expired != nil {
+		return s.Expired(statusCode, header, body)
+	}
+	return statusCode == http.StatusUnauthorized
+}
+
+// relogin logs in again unless another request already has since the
+// expired one was sent.
+func (s *Session) relogin(seen int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.logins != seen && s.authenticated {
+		return nil
+	}
+	s.authenticated = false
+	return s.login()
+}
@@ -0,0 +1,129 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_FormLoginAndRelogin(t *testing.T) {
+	var mu sync.Mutex
+	current, logins := "", 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/login":
+			if r.PostFormValue("password") != "secret" {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("bad credentials"))
+				return
+			}
+			logins++
+			current = "s" + strconv.Itoa(logins)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: current, Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		case "/expire":
+			current = ""
+			return
+		}
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("welcome"))
+	}))
+	defer ts.Close()
+
+	session := httpclientutils.NewSession()
+	defer session.Close()
+	welcome := func(statusCode int, _ http.Header, body []byte) bool {
+		return statusCode == http.StatusOK && string(body) == "welcome"
+	}
+
+	err := session.Login(ts.URL+"/login", url.Values{"user": {"ann"}, "password": {"wrong"}}, welcome)
+	assert.ErrorIs(t, err, httpclientutils.ErrLoginFailed)
+	assert.False(t, session.Authenticated())
+
+	assert.NoError(t, session.Login(ts.URL+"/login", url.Values{"user": {"ann"}, "password": {"secret"}}, welcome))
+	assert.True(t, session.Authenticated())
+
+	status, _, body, err := session.Do(httpclientutils.WithURL(ts.URL + "/api"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "welcome", string(body))
+
+	session.Do(httpclientutils.WithURL(ts.URL + "/expire"))
+	status, _, _, err = session.Do(httpclientutils.WithURL(ts.URL + "/api"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2, logins)
+}
+
+func TestSession_JSONLoginToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"tok"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	session := httpclientutils.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Login(ts.URL+"/auth", map[string]string{"user": "ann"}, nil))
+	status, _, _, err := session.Do(httpclientutils.WithURL(ts.URL + "/me"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestSession_TokenStaysOnLoginOrigin(t *testing.T) {
+	var mu sync.Mutex
+	var foreign []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		foreign = append(foreign, r.Header.Get("Authorization"))
+		mu.Unlock()
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth":
+			w.Write([]byte(`{"token":"tok"}`))
+		case "/away":
+			http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+		case "/hop":
+			http.Redirect(w, r, "/me", http.StatusFound)
+		default:
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	session := httpclientutils.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Login(ts.URL+"/auth", map[string]string{"user": "ann"}, nil))
+
+	status, _, _, err := session.Get(ts.URL + "/hop")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	_, _, _, err = session.Get(other.URL + "/direct")
+	assert.NoError(t, err)
+	_, _, _, err = session.Get(ts.URL + "/away")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", ""}, foreign)
+}
@@ -0,0 +1,51 @@
+package httpclientutils_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vector "get-vanilla" from the AWS Signature Version 4 test suite.
+func TestSignSigV4_GetVanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	err := httpclientutils.SignSigV4(req, httpclientutils.SigV4Options{
+		Credentials: httpclientutils.AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		Region:      "us-east-1",
+		Service:     "service",
+	}, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"),
+	)
+}
+
+func TestSignSigV4_StreamedBody(t *testing.T) {
+	opts := httpclientutils.SigV4Options{
+		Credentials: httpclientutils.AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"},
+		Region:      "us-east-1",
+		Service:     "s3",
+	}
+	streamed := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPut, "https://bucket.s3.amazonaws.com/key", io.NopCloser(strings.NewReader("payload")))
+		return req
+	}
+
+	req := streamed()
+	assert.NoError(t, httpclientutils.SignSigV4(req, opts, time.Now()))
+	assert.Equal(t, "UNSIGNED-PAYLOAD", req.Header.Get("X-Amz-Content-Sha256"))
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "payload", string(body))
+
+	opts.Service = "execute-api"
+	err := httpclientutils.SignSigV4(streamed(), opts, time.Now())
+	assert.ErrorIs(t, err, httpclientutils.ErrBodyNotReplayable)
+}
@@ -0,0 +1,131 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxSitemapSize is the uncompressed size limit of the sitemaps protocol.
+const maxSitemapSize = 50 << 20
+
+// maxSitemapDepth bounds how deeply sitemap index files may nest.
+const maxSitemapDepth = 3
+
+// SitemapURL is a <url> entry of a sitemap.
+type SitemapURL struct {
+	Loc        string
+	LastMod    time.Time // zero when absent or unparseable
+	ChangeFreq string
+	Priority   float64 // zero when absent
+}
+
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc        string  `xml:"loc"`
+	LastMod    string  `xml:"lastmod"`
+	ChangeFreq string  `xml:"changefreq"`
+	Priority   float64 `xml:"priority"`
+}
+
+// FetchSitemap downloads sitemapURL and returns its URL entries. Sitemap
+// index files are followed, and gzip-compressed sitemaps (.xml.gz) are
+// decompressed. opts apply to every download, so options such as
+// WithRespectRobotsTxt or WithTimeout work as for any other request.
+func FetchSitemap(sitemapURL string, opts ...Option) ([]SitemapURL, error) {
+	var urls []SitemapURL
+	visited := make(map[string]bool)
+	if err := fetchSitemap(sitemapURL, opts, 0, visited, &urls); err != nil {
+		return urls, err
+	}
+	return urls, nil
+}
+
+func fetchSitemap(sitemapURL string, opts []Option, depth int, visited map[string]bool, urls *[]SitemapURL) error {
+	if visited[sitemapURL] {
+		return nil
+	}
+	visited[sitemapURL] = true
+
+	statusCode, _, body, err := MakeHTTPRequest(append(append([]Option{}, opts...), WithURL(sitemapURL), WithMethod(http.MethodGet))...)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch sitemap %s: status %d", sitemapURL, statusCode)
+	}
+	doc, err := parseSitemap(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+	}
+
+	for _, entry := range doc.URLs {
+		*urls = append(*urls, SitemapURL{
+			Loc:        strings.TrimSpace(entry.Loc),
+			LastMod:    parseW3CDate(entry.LastMod),
+			ChangeFreq: strings.TrimSpace(entry.ChangeFreq),
+			Priority:   entry.Priority,
+		})
+	}
+	if len(doc.Sitemaps) > 0 && depth >= maxSitemapDepth {
+		return fmt.Errorf("failed to fetch sitemap %s: index nested more than %d levels", sitemapURL, maxSitemapDepth)
+	}
+	for _, child := range doc.Sitemaps {
+		if err := fetchSitemap(strings.TrimSpace(child.Loc), opts, depth+1, visited, urls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSitemap decodes a <urlset> or <sitemapindex>, gunzipping it first
+// when it starts with the gzip magic number.
+func parseSitemap(body []byte) (*sitemapDocument, error) {
+	var reader io.Reader = bytes.NewReader(body)
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxSitemapSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSitemapSize {
+		return nil, errors.New("sitemap exceeds 50 MB")
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if name := doc.XMLName.Local; name != "urlset" && name != "sitemapindex" {
+		return nil, fmt.Errorf("unexpected root element <%s>", name)
+	}
+	return &doc, nil
+}
+
+// parseW3CDate parses the W3C datetime forms allowed in <lastmod>, in UTC.
+func parseW3CDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
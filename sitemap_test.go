@@ -0,0 +1,62 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchSitemap_FollowsIndexAndGzip(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>` + ts.URL + `/pages.xml</loc></sitemap>
+  <sitemap><loc>` + ts.URL + `/posts.xml.gz</loc></sitemap>
+  <sitemap><loc>` + ts.URL + `/sitemap.xml</loc></sitemap>
+</sitemapindex>`))
+		case "/pages.xml":
+			w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc><lastmod>2024-01-15</lastmod><changefreq>daily</changefreq><priority>1.0</priority></url>
+</urlset>`))
+		case "/posts.xml.gz":
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/posts/1 </loc><lastmod>2024-02-01T10:30:00+00:00</lastmod></url>
+  <url><loc>https://example.com/posts/2</loc></url>
+</urlset>`))
+			gz.Close()
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(buf.Bytes())
+		}
+	}))
+	defer ts.Close()
+
+	urls, err := httpclientutils.FetchSitemap(ts.URL + "/sitemap.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, []httpclientutils.SitemapURL{
+		{Loc: "https://example.com/", LastMod: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), ChangeFreq: "daily", Priority: 1},
+		{Loc: "https://example.com/posts/1", LastMod: time.Date(2024, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{Loc: "https://example.com/posts/2"},
+	}, urls)
+}
+
+func TestFetchSitemap_RejectsNonSitemap(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>not a sitemap</body></html>`))
+	}))
+	defer ts.Close()
+
+	_, err := httpclientutils.FetchSitemap(ts.URL)
+	assert.ErrorContains(t, err, "unexpected root element <html>")
+}
@@ -0,0 +1,207 @@
+package httpclientutils
+
+import (
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SLOConfig sets the objectives an SLOTracker holds each host to.
+type SLOConfig struct {
+	Window      time.Duration // rolling window judged; defaults to one minute
+	Percentile  float64       // latency percentile checked, e.g. 0.99 (the default)
+	Latency     time.Duration // objective for that percentile; zero disables the check
+	ErrorRate   float64       // objective for the share of failed requests; zero disables the check
+	MinRequests int           // requests a window needs before it is judged; defaults to 20
+
+	// OnSLOBreach is called when a host starts missing an objective, and
+	// again only after it has recovered and breached once more.
+	OnSLOBreach func(status SLOStatus)
+}
+
+// SLOStatus describes one host's rolling window.
+type SLOStatus struct {
+	Host              string
+	Requests          int
+	Latency           time.Duration // latency at the configured percentile
+	ErrorRate         float64       // share of requests failing with a 5xx, timeout or transport error
+	LatencyBreached   bool
+	ErrorRateBreached bool
+}
+
+// Breached reports whether either objective is missed.
+func (s SLOStatus) Breached() bool { return s.LatencyBreached || s.ErrorRateBreached }
+
+// SLOTracker keeps a rolling window of latency and errors per host for
+// the requests it is attached to via WithSLO, and reports breaches of its
+// objectives. A nil *SLOTracker records nothing.
+//
+// Windows advance in tenths of SLOConfig.Window and keep a latency
+// histogram rather than every sample, so recording costs the same at any
+// request rate and percentiles are accurate to within about 10%.
+type SLOTracker struct {
+	config SLOConfig
+
+	mu    sync.Mutex
+	hosts map[string]*sloWindow
+}
+
+const (
+	sloSlots         = 10  // slots a window is divided into
+	sloBinsPerOctave = 8   // histogram resolution: 2^(1/8) ≈ 9% per bin
+	sloBins          = 224 // microsecond bins up to 2^28 µs, about 4.5 minutes
+)
+
+// sloWindow keeps running totals over its live slots, so judging a window
+// never revisits individual requests.
+type sloWindow struct {
+	slots     [sloSlots]sloSlot
+	requests  int
+	failed    int
+	latencies [sloBins]uint32
+	breached  bool
+}
+
+type sloSlot struct {
+	epoch     int64 // slot number since the Unix epoch
+	requests  int
+	failed    int
+	latencies [sloBins]uint32
+}
+
+// NewSLOTracker returns an SLOTracker for config.
+func NewSLOTracker(config SLOConfig) *SLOTracker {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = 0.99
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 20
+	}
+	return &SLOTracker{config: config, hosts: make(map[string]*sloWindow)}
+}
+
+// Status returns the current window of host, e.g. "api.example.com" or
+// "api.example.com:8443" as it appears in request URLs.
+func (t *SLOTracker) Status(host string) SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	window, ok := t.hosts[host]
+	if !ok {
+		return SLOStatus{Host: host}
+	}
+	window.advance(time.Now(), t.config.Window)
+	return t.status(host, window)
+}
+
+func (t *SLOTracker) record(options *RequestOptions, statusCode int, err error, latency time.Duration) {
+	if t == nil {
+		return
+	}
+	u, parseErr := url.Parse(options.URL)
+	if parseErr != nil {
+		return
+	}
+	failed := false
+	switch errorClass(statusCode, err) {
+	case "http_5xx", "timeout", "transport":
+		failed = true
+	case "shed", "deadline", "canceled":
+		// Decided on this side; they say nothing about the host.
+		return
+	}
+
+	t.mu.Lock()
+	window, ok := t.hosts[u.Host]
+	if !ok {
+		window = &sloWindow{}
+		t.hosts[u.Host] = window
+	}
+	window.add(window.advance(time.Now(), t.config.Window), latency, failed)
+	status := t.status(u.Host, window)
+	notify := status.Breached() && !window.breached
+	if status.Requests >= t.config.MinRequests {
+		window.breached = status.Breached()
+	}
+	t.mu.Unlock()
+
+	if notify && t.config.OnSLOBreach != nil {
+		t.config.OnSLOBreach(status)
+	}
+}
+
+// status judges window, which must be advanced; windows with fewer than
+// MinRequests requests never breach.
+func (t *SLOTracker) status(host string, window *sloWindow) SLOStatus {
+	status := SLOStatus{Host: host, Requests: window.requests}
+	if status.Requests == 0 {
+		return status
+	}
+	status.Latency = window.percentile(t.config.Percentile)
+	status.ErrorRate = float64(window.failed) / float64(status.Requests)
+
+	if status.Requests >= t.config.MinRequests {
+		status.LatencyBreached = t.config.Latency > 0 && status.Latency > t.config.Latency
+		status.ErrorRateBreached = t.config.ErrorRate > 0 && status.ErrorRate > t.config.ErrorRate
+	}
+	return status
+}
+
+// advance expires the slots that have left the window as of now and
+// returns the slot now falls in.
+func (w *sloWindow) advance(now time.Time, window time.Duration) *sloSlot {
+	width := max(int64(window)/sloSlots, 1)
+	epoch := now.UnixNano() / width
+	for i := range w.slots {
+		slot := &w.slots[i]
+		if slot.requests == 0 || slot.epoch > epoch-sloSlots {
+			continue
+		}
+		w.requests -= slot.requests
+		w.failed -= slot.failed
+		for bin, count := range slot.latencies {
+			w.latencies[bin] -= count
+		}
+		*slot = sloSlot{epoch: slot.epoch}
+	}
+	current := &w.slots[epoch%sloSlots]
+	current.epoch = epoch
+	return current
+}
+
+func (w *sloWindow) add(slot *sloSlot, latency time.Duration, failed bool) {
+	bin := sloBin(latency)
+	slot.requests++
+	slot.latencies[bin]++
+	w.requests++
+	w.latencies[bin]++
+	if failed {
+		slot.failed++
+		w.failed++
+	}
+}
+
+// percentile returns the upper bound of the histogram bin holding the
+// latency at percentile p.
+func (w *sloWindow) percentile(p float64) time.Duration {
+	rank := max(int(math.Ceil(p*float64(w.requests))), 1)
+	seen := 0
+	for bin, count := range w.latencies {
+		if seen += int(count); seen >= rank {
+			return sloBinLimit(bin)
+		}
+	}
+	return sloBinLimit(sloBins - 1)
+}
+
+func sloBin(latency time.Duration) int {
+	us := max(float64(latency)/float64(time.Microsecond), 1)
+	return min(int(math.Log2(us)*sloBinsPerOctave), sloBins-1)
+}
+
+func sloBinLimit(bin int) time.Duration {
+	return time.Duration(math.Exp2(float64(bin+1)/sloBinsPerOctave) * float64(time.Microsecond))
+}
@@ -0,0 +1,88 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLOTracker_ReportsBreachOnce(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(30 * time.Millisecond)
+		case "/fail":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+	host := ts.Listener.Addr().String()
+
+	var breaches []httpclientutils.SLOStatus
+	tracker := httpclientutils.NewSLOTracker(httpclientutils.SLOConfig{
+		Percentile:  0.5,
+		Latency:     20 * time.Millisecond,
+		ErrorRate:   0.4,
+		MinRequests: 4,
+		OnSLOBreach: func(status httpclientutils.SLOStatus) { breaches = append(breaches, status) },
+	})
+	get := func(path string) {
+		httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL+path), httpclientutils.WithSLO(tracker))
+	}
+
+	for range 4 {
+		get("/")
+	}
+	assert.Empty(t, breaches)
+	assert.Equal(t, 4, tracker.Status(host).Requests)
+
+	for range 6 {
+		get("/slow")
+	}
+	assert.Len(t, breaches, 1)
+	assert.Equal(t, host, breaches[0].Host)
+	assert.True(t, breaches[0].LatencyBreached)
+	assert.False(t, breaches[0].ErrorRateBreached)
+
+	// Fast failures first bring the latency back within its objective,
+	// then breach the error rate, which is reported as a new breach.
+	for range 10 {
+		get("/fail")
+	}
+	status := tracker.Status(host)
+	assert.False(t, status.LatencyBreached)
+	assert.True(t, status.ErrorRateBreached)
+	assert.InDelta(t, 0.5, status.ErrorRate, 0.001)
+	if assert.Len(t, breaches, 2) {
+		assert.True(t, breaches[1].ErrorRateBreached)
+	}
+
+	other, _ := url.Parse("http://unseen.example")
+	assert.False(t, tracker.Status(other.Host).Breached())
+}
+
+func TestSLOTracker_WindowExpires(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	host := ts.Listener.Addr().String()
+
+	tracker := httpclientutils.NewSLOTracker(httpclientutils.SLOConfig{Window: 100 * time.Millisecond, ErrorRate: 0.1, MinRequests: 1})
+	for range 3 {
+		httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithSLO(tracker))
+	}
+	status := tracker.Status(host)
+	assert.Equal(t, 3, status.Requests)
+	assert.True(t, status.ErrorRateBreached)
+
+	time.Sleep(150 * time.Millisecond)
+	status = tracker.Status(host)
+	assert.Equal(t, 0, status.Requests)
+	assert.False(t, status.Breached())
+}
@@ -0,0 +1,298 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// SOAPVersion selects the envelope namespace and content type.
+type SOAPVersion int
+
+const (
+	SOAP11 SOAPVersion = iota
+	SOAP12
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+func (v SOAPVersion) namespace() string {
+	if v == SOAP12 {
+		return soap12Namespace
+	}
+	return soap11Namespace
+}
+
+func (v SOAPVersion) contentType() string {
+	if v == SOAP12 {
+		return "application/soap+xml"
+	}
+	return "text/xml"
+}
+
+// SOAPRequest is a SOAP call. Header and Body are marshaled with
+// encoding/xml into the envelope. When Attachments are present the request
+// is sent as MTOM (multipart/related with XOP); reference an attachment
+// from Body with its Include method.
+type SOAPRequest struct {
+	Version     SOAPVersion
+	Action      string
+	Header      interface{}
+	Body        interface{}
+	Attachments []MTOMAttachment
+}
+
+// MTOMAttachment is a binary part of an MTOM message.
+type MTOMAttachment struct {
+	ContentID   string // without angle brackets
+	ContentType string // defaults to application/octet-stream
+	Data        []byte
+}
+
+// Include returns the XOP reference to put in place of the attachment's
+// data in the envelope.
+func (a MTOMAttachment) Include() *XOPInclude {
+	return &XOPInclude{Href: "cid:" + a.ContentID}
+}
+
+// XOPInclude is an xop:Include element. Use it as the type of fields that
+// carry MTOM attachments, in requests and in response targets.
+type XOPInclude struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2004/08/xop/include Include"`
+	Href    string   `xml:"href,attr"`
+}
+
+// SOAPResponse holds the MTOM attachments of a response, keyed by
+// Content-ID.
+type SOAPResponse struct {
+	StatusCode  int
+	Header      http.Header
+	Attachments map[string]MTOMAttachment
+}
+
+// Attachment returns the attachment an xop:Include in the response refers
+// to.
+func (r *SOAPResponse) Attachment(include *XOPInclude) (MTOMAttachment, bool) {
+	if include == nil {
+		return MTOMAttachment{}, false
+	}
+	attachment, ok := r.Attachments[strings.TrimPrefix(include.Href, "cid:")]
+	return attachment, ok
+}
+
+// SOAPFault is returned by CallSOAP when the response is a SOAP fault. It
+// covers both the SOAP 1.1 and 1.2 layouts.
+type SOAPFault struct {
+	Code   string `xml:"faultcode"`
+	String string `xml:"faultstring"`
+	Detail string `xml:"detail"`
+
+	Code12   string `xml:"Code>Value"`
+	Reason12 string `xml:"Reason>Text"`
+	Detail12 string `xml:"Detail"`
+}
+
+func (f *SOAPFault) Error() string {
+	code, reason := orDefault(f.Code, f.Code12), orDefault(f.String, f.Reason12)
+	return fmt.Sprintf("soap fault %s: %s", code, reason)
+}
+
+// CallSOAP posts req to url and decodes the first element of the response
+// body into resp (which may be nil). Faults are returned as *SOAPFault.
+func CallSOAP(url string, req SOAPRequest, resp interface{}, opts ...Option) (*SOAPResponse, error) {
+	body, contentType, err := req.encode()
+	if err != nil {
+		return nil, err
+	}
+	statusCode, header, responseBody, err := MakeHTTPRequest(append([]Option{
+		WithMethod(http.MethodPost),
+		WithURL(url),
+		WithBody(body),
+		withHeader("Content-Type", contentType),
+		withHeader("Accept", req.Version.contentType()+", multipart/related"),
+	}, req.actionHeaders(opts)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SOAPResponse{StatusCode: statusCode, Header: header}
+	envelope, attachments, err := splitMTOM(header.Get("Content-Type"), responseBody)
+	if err != nil {
+		return result, err
+	}
+	result.Attachments = attachments
+
+	if err := decodeSOAPBody(envelope, resp); err != nil {
+		var fault *SOAPFault
+		if statusCode >= http.StatusBadRequest && !errors.As(err, &fault) {
+			return result, fmt.Errorf("unexpected status %d: %s", statusCode, responseBody)
+		}
+		return result, err
+	}
+	if statusCode >= http.StatusBadRequest {
+		return result, fmt.Errorf("unexpected status %d: %s", statusCode, responseBody)
+	}
+	return result, nil
+}
+
+// decodeSOAPBody decodes the first element of the envelope's Body into
+// target, or returns it as a *SOAPFault. The whole envelope is run through
+// one decoder so namespaces declared on the envelope still apply.
+func decodeSOAPBody(envelope []byte, target interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(envelope))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return errors.New("failed to parse SOAP envelope: no Body")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse SOAP envelope: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		switch {
+		case !ok:
+			continue
+		case !inBody:
+			inBody = start.Name.Local == "Body"
+			continue
+		case start.Name.Local == "Fault":
+			fault := &SOAPFault{}
+			if err := decoder.DecodeElement(fault, &start); err != nil {
+				return fmt.Errorf("failed to parse SOAP fault: %w", err)
+			}
+			return fault
+		case target == nil:
+			return nil
+		}
+		if err := decoder.DecodeElement(target, &start); err != nil {
+			return fmt.Errorf("failed to resolve response: %w", err)
+		}
+		return nil
+	}
+}
+
+// actionHeaders sets SOAPAction for SOAP 1.1; SOAP 1.2 carries the action
+// in the Content-Type instead.
+func (r SOAPRequest) actionHeaders(opts []Option) []Option {
+	if r.Version == SOAP11 {
+		opts = append([]Option{withHeader("SOAPAction", `"`+r.Action+`"`)}, opts...)
+	}
+	return opts
+}
+
+func (r SOAPRequest) envelope() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<soap:Envelope xmlns:soap="%s">`, r.Version.namespace())
+	for _, part := range []struct {
+		name  string
+		value interface{}
+	}{{"Header", r.Header}, {"Body", r.Body}} {
+		if part.value == nil {
+			continue
+		}
+		data, err := xml.Marshal(part.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal SOAP %s: %w", strings.ToLower(part.name), err)
+		}
+		fmt.Fprintf(&buf, "<soap:%s>%s</soap:%[1]s>", part.name, data)
+	}
+	buf.WriteString("</soap:Envelope>")
+	return buf.Bytes(), nil
+}
+
+// encode returns the request body and its Content-Type.
+func (r SOAPRequest) encode() ([]byte, string, error) {
+	envelope, err := r.envelope()
+	if err != nil {
+		return nil, "", err
+	}
+	envelopeType := r.Version.contentType()
+	params := map[string]string{"charset": "utf-8"}
+	if r.Version == SOAP12 && r.Action != "" {
+		params["action"] = r.Action
+	}
+	if len(r.Attachments) == 0 {
+		return envelope, mime.FormatMediaType(envelopeType, params), nil
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	root := textproto.MIMEHeader{}
+	params["type"] = envelopeType
+	root.Set("Content-Type", mime.FormatMediaType("application/xop+xml", params))
+	root.Set("Content-Transfer-Encoding", "8bit")
+	root.Set("Content-ID", "<root.message>")
+	part, err := writer.CreatePart(root)
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(envelope)
+	for _, attachment := range r.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", orDefault(attachment.ContentType, "application/octet-stream"))
+		header.Set("Content-Transfer-Encoding", "binary")
+		header.Set("Content-ID", "<"+attachment.ContentID+">")
+		if part, err = writer.CreatePart(header); err != nil {
+			return nil, "", err
+		}
+		part.Write(attachment.Data)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	contentType := mime.FormatMediaType("multipart/related", map[string]string{
+		"type":       "application/xop+xml",
+		"start":      "<root.message>",
+		"start-info": envelopeType,
+		"boundary":   writer.Boundary(),
+	})
+	return buf.Bytes(), contentType, nil
+}
+
+// splitMTOM returns the envelope and attachments of an MTOM response, or
+// body unchanged when it is not multipart.
+func splitMTOM(contentType string, body []byte) ([]byte, map[string]MTOMAttachment, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/related" {
+		return body, nil, nil
+	}
+	start := strings.Trim(params["start"], "<>")
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+
+	var envelope []byte
+	attachments := make(map[string]MTOMAttachment)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse MTOM response: %w", err)
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse MTOM response: %w", err)
+		}
+		id := strings.Trim(part.Header.Get("Content-ID"), "<>")
+		if envelope == nil && (start == "" || id == start) {
+			envelope = data
+			continue
+		}
+		attachments[id] = MTOMAttachment{ContentID: id, ContentType: part.Header.Get("Content-Type"), Data: data}
+	}
+	if envelope == nil {
+		return nil, nil, errors.New("failed to parse MTOM response: no root part")
+	}
+	return envelope, attachments, nil
+}
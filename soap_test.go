@@ -0,0 +1,92 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type uploadDocument struct {
+	XMLName  xml.Name                    `xml:"urn:example UploadDocument"`
+	Name     string                      `xml:"Name"`
+	Document *httpclientutils.XOPInclude `xml:"Document>Include"`
+}
+
+func TestCallSOAP_MTOMRoundTrip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"urn:example:Upload"`, r.Header.Get("SOAPAction"))
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/related", mediaType)
+		assert.Equal(t, "application/xop+xml", params["type"])
+
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		root, _ := reader.NextPart()
+		envelope, _ := io.ReadAll(root)
+		assert.Contains(t, string(envelope), `href="cid:scan.pdf"`)
+		attachment, _ := reader.NextPart()
+		assert.Equal(t, "<scan.pdf>", attachment.Header.Get("Content-ID"))
+		data, _ := io.ReadAll(attachment)
+		assert.Equal(t, "%PDF-1.7", string(data))
+
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		part, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {`application/xop+xml; type="text/xml"`}, "Content-Id": {"<root>"}})
+		part.Write([]byte(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xop="http://www.w3.org/2004/08/xop/include">` +
+			`<s:Body><Receipt><Id>R-1</Id><Stamp><xop:Include href="cid:stamp.png"/></Stamp></Receipt></s:Body></s:Envelope>`))
+		part, _ = writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"image/png"}, "Content-Id": {"<stamp.png>"}})
+		part.Write([]byte("PNG"))
+		writer.Close()
+		w.Header().Set("Content-Type", `multipart/related; type="application/xop+xml"; start="<root>"; boundary=`+writer.Boundary())
+		w.Write(buf.Bytes())
+	}))
+	defer ts.Close()
+
+	scan := httpclientutils.MTOMAttachment{ContentID: "scan.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.7")}
+	var receipt struct {
+		ID    string                      `xml:"Id"`
+		Stamp *httpclientutils.XOPInclude `xml:"Stamp>Include"`
+	}
+	resp, err := httpclientutils.CallSOAP(ts.URL, httpclientutils.SOAPRequest{
+		Action:      "urn:example:Upload",
+		Body:        uploadDocument{Name: "scan", Document: scan.Include()},
+		Attachments: []httpclientutils.MTOMAttachment{scan},
+	}, &receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, "R-1", receipt.ID)
+
+	stamp, ok := resp.Attachment(receipt.Stamp)
+	if assert.True(t, ok) {
+		assert.Equal(t, "image/png", stamp.ContentType)
+		assert.Equal(t, "PNG", string(stamp.Data))
+	}
+}
+
+func TestCallSOAP_Fault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "application/soap+xml"))
+		assert.Contains(t, r.Header.Get("Content-Type"), `action="urn:example:Get"`)
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>` +
+			`<env:Code><env:Value>env:Sender</env:Value></env:Code><env:Reason><env:Text>unknown policy</env:Text></env:Reason>` +
+			`</env:Fault></env:Body></env:Envelope>`))
+	}))
+	defer ts.Close()
+
+	_, err := httpclientutils.CallSOAP(ts.URL, httpclientutils.SOAPRequest{Version: httpclientutils.SOAP12, Action: "urn:example:Get"}, nil)
+	var fault *httpclientutils.SOAPFault
+	if assert.ErrorAs(t, err, &fault) {
+		assert.EqualError(t, fault, "soap fault env:Sender: unknown policy")
+	}
+}
@@ -0,0 +1,152 @@
+package httpclientutils_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a leaf certificate for the given SPIFFE ID or DNS/IP names,
+// valid until notAfter.
+func (ca *testCA) issue(t *testing.T, uri string, notAfter time.Time, names ...string) tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if uri != "" {
+		u, _ := url.Parse(uri)
+		template.URIs = []*url.URL{u}
+	}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func writeSVIDFiles(t *testing.T, dir string, ca *testCA, cert tls.Certificate) *httpclientutils.FileSVIDSource {
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	source := &httpclientutils.FileSVIDSource{
+		CertFile:   filepath.Join(dir, "svid.pem"),
+		KeyFile:    filepath.Join(dir, "svid_key.pem"),
+		BundleFile: filepath.Join(dir, "bundle.pem"),
+	}
+	os.WriteFile(source.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600)
+	os.WriteFile(source.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+	os.WriteFile(source.BundleFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600)
+	return source
+}
+
+func TestSPIFFETLSConfig_MutualAuthentication(t *testing.T) {
+	ca := newTestCA(t)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].URIs[0].String()))
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, "spiffe://example.org/server", time.Now().Add(time.Hour))},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool(),
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	source := writeSVIDFiles(t, t.TempDir(), ca, ca.issue(t, "spiffe://example.org/client", time.Now().Add(time.Hour)))
+
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(httpclientutils.SPIFFETLSConfig(source, "spiffe://example.org/server")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://example.org/client", string(body))
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(httpclientutils.SPIFFETLSConfig(source, "spiffe://example.org/other")),
+	)
+	assert.ErrorContains(t, err, "peer ID spiffe://example.org/server is not allowed")
+}
+
+func TestSPIFFETLSConfig_DefaultsToOwnTrustDomain(t *testing.T) {
+	ca := newTestCA(t)
+	serve := func(id string) *httptest.Server {
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.TLS = &tls.Config{Certificates: []tls.Certificate{ca.issue(t, id, time.Now().Add(time.Hour))}}
+		ts.StartTLS()
+		return ts
+	}
+	local := serve("spiffe://example.org/server")
+	defer local.Close()
+	// The same root also signs a foreign trust domain, so the chain alone
+	// does not tell the two apart.
+	foreign := serve("spiffe://other.org/server")
+	defer foreign.Close()
+
+	source := writeSVIDFiles(t, t.TempDir(), ca, ca.issue(t, "spiffe://example.org/client", time.Now().Add(time.Hour)))
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(local.URL),
+		httpclientutils.WithTLSConfig(httpclientutils.SPIFFETLSConfig(source)),
+	)
+	assert.NoError(t, err)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(foreign.URL),
+		httpclientutils.WithTLSConfig(httpclientutils.SPIFFETLSConfig(source)),
+	)
+	assert.ErrorContains(t, err, "peer ID spiffe://other.org/server is not in trust domain example.org")
+}
@@ -0,0 +1,130 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// ErrSSRFBlocked is returned when SSRF protection refuses a destination.
+var ErrSSRFBlocked = errors.New("destination blocked by SSRF protection")
+
+// blockedPrefixes are non-public ranges not covered by the netip.Addr
+// predicates: the shared address space of RFC 6598, the benchmarking range
+// of RFC 2544, the reserved 240.0.0.0/4 (including the limited broadcast
+// address) and 6to4, which relays to the IPv4 address it embeds.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// nat64Prefix is the well-known NAT64 prefix of RFC 6052; its addresses
+// are checked as the IPv4 address they embed.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// SSRFPolicy refuses connections to private, loopback, link-local and other
+// non-public addresses unless they fall within an allowed prefix. It is
+// enforced on the resolved address at dial time, so it also covers every
+// redirect hop and DNS rebinding. Through a proxy, which dials the target
+// itself, the target host is resolved and checked before each request is
+// handed to the proxy.
+type SSRFPolicy struct {
+	Allowed []netip.Prefix
+}
+
+// Check reports whether addr may be dialed.
+func (p *SSRFPolicy) Check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.Allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if nat64Prefix.Contains(addr) {
+		embedded := addr.As16()
+		if p.Check(netip.AddrFrom4([4]byte(embedded[12:]))) != nil {
+			return fmt.Errorf("%w: %s", ErrSSRFBlocked, addr)
+		}
+		return nil
+	}
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() ||
+		slices.ContainsFunc(blockedPrefixes, func(prefix netip.Prefix) bool { return prefix.Contains(addr) }) {
+		return fmt.Errorf("%w: %s", ErrSSRFBlocked, addr)
+	}
+	return nil
+}
+
+// proxy wraps a transport's Proxy function to check the target host of
+// each proxied request, resolved with resolver, since the dialer only sees
+// the proxy's address.
+func (p *SSRFPolicy) proxy(proxy func(*http.Request) (*url.URL, error), resolver *net.Resolver) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		host := req.URL.Hostname()
+		if addr, err := netip.ParseAddr(host); err == nil {
+			if err := p.Check(addr); err != nil {
+				return nil, err
+			}
+			return proxy(req)
+		}
+		addrs, err := resolver.LookupNetIP(req.Context(), "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if err := p.Check(addr); err != nil {
+				return nil, err
+			}
+		}
+		return proxy(req)
+	}
+}
+
+// poolKey returns a canonical form of the policy, so requests applying
+// equal policies share a pooled transport; "" means no policy.
+func (p *SSRFPolicy) poolKey() string {
+	if p == nil {
+		return ""
+	}
+	prefixes := make([]string, len(p.Allowed))
+	for i, prefix := range p.Allowed {
+		prefixes[i] = prefix.Masked().String()
+	}
+	slices.Sort(prefixes)
+	return "ssrf:" + strings.Join(slices.Compact(prefixes), ",")
+}
+
+// ssrfPolicyFromKey rebuilds the policy a poolKey was made from.
+func ssrfPolicyFromKey(key string) *SSRFPolicy {
+	allowed, ok := strings.CutPrefix(key, "ssrf:")
+	if !ok {
+		return nil
+	}
+	policy := &SSRFPolicy{}
+	for _, prefix := range strings.Split(allowed, ",") {
+		if parsed, err := netip.ParsePrefix(prefix); err == nil {
+			policy.Allowed = append(policy.Allowed, parsed)
+		}
+	}
+	return policy
+}
+
+func (p *SSRFPolicy) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: unresolved address %q", ErrSSRFBlocked, host)
+	}
+	return p.Check(addr)
+}
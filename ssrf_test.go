@@ -0,0 +1,65 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSRFProtection_BlocksLoopback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithSSRFProtection(),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrSSRFBlocked)
+
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithSSRFProtection(netip.MustParsePrefix("127.0.0.0/8")),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestSSRFPolicy_Check(t *testing.T) {
+	policy := &httpclientutils.SSRFPolicy{}
+	for _, addr := range []string{"10.0.0.1", "192.168.1.1", "169.254.169.254", "::1", "fe80::1", "100.64.0.1", "::ffff:127.0.0.1",
+		"198.18.0.1", "240.0.0.1", "255.255.255.255", "64:ff9b::a9fe:a9fe", "2002:7f00:1::1", "::ffff:10.0.0.1"} {
+		assert.ErrorIs(t, policy.Check(netip.MustParseAddr(addr)), httpclientutils.ErrSSRFBlocked, addr)
+	}
+	assert.NoError(t, policy.Check(netip.MustParseAddr("93.184.216.34")))
+	assert.NoError(t, policy.Check(netip.MustParseAddr("64:ff9b::5db8:d822")))
+}
+
+func TestSSRFProtection_ChecksTargetThroughProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Host)
+	}))
+	defer proxy.Close()
+	allowProxy := httpclientutils.WithSSRFProtection(netip.MustParsePrefix("127.0.0.0/8"))
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://169.254.169.254/latest/meta-data/"),
+		httpclientutils.WithProxy(proxy.URL),
+		allowProxy,
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrSSRFBlocked)
+	assert.Empty(t, proxied)
+
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://93.184.216.34/"),
+		httpclientutils.WithProxy(proxy.URL),
+		allowProxy,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"93.184.216.34"}, proxied)
+}
@@ -0,0 +1,156 @@
+package httpclientutils
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats collects counters for the requests it is attached to via WithStats.
+// A nil *Stats records nothing.
+type Stats struct {
+	requests    atomic.Int64
+	inFlight    atomic.Int64
+	connsNew    atomic.Int64
+	connsReused atomic.Int64
+	connsActive atomic.Int64
+	connsIdle   atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+
+	mu     sync.Mutex
+	errors map[string]int64
+}
+
+// ClientStats is a point-in-time snapshot of Stats.
+type ClientStats struct {
+	Requests      int64            `json:"requests"`
+	InFlight      int64            `json:"in_flight"`
+	Errors        map[string]int64 `json:"errors"`
+	ConnsCreated  int64            `json:"conns_created"`
+	ConnsReused   int64            `json:"conns_reused"`
+	ConnsActive   int64            `json:"conns_active"`    // connections currently serving a request
+	ConnsFromIdle int64            `json:"conns_from_idle"` // reused connections taken from the idle pool
+	CacheHits     int64            `json:"cache_hits"`
+	CacheMisses   int64            `json:"cache_misses"`
+	CacheHitRatio float64          `json:"cache_hit_ratio"`
+}
+
+// NewStats returns an empty Stats.
+func NewStats() *Stats {
+	return &Stats{errors: make(map[string]int64)}
+}
+
+// Snapshot returns the current counters.
+func (s *Stats) Snapshot() ClientStats {
+	snapshot := ClientStats{
+		Requests:      s.requests.Load(),
+		InFlight:      s.inFlight.Load(),
+		Errors:        make(map[string]int64),
+		ConnsCreated:  s.connsNew.Load(),
+		ConnsReused:   s.connsReused.Load(),
+		ConnsActive:   s.connsActive.Load(),
+		ConnsFromIdle: s.connsIdle.Load(),
+		CacheHits:     s.cacheHits.Load(),
+		CacheMisses:   s.cacheMisses.Load(),
+	}
+	if lookups := snapshot.CacheHits + snapshot.CacheMisses; lookups > 0 {
+		snapshot.CacheHitRatio = float64(snapshot.CacheHits) / float64(lookups)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for class, n := range s.errors {
+		snapshot.Errors[class] = n
+	}
+	return snapshot
+}
+
+// PublishExpvar exposes the snapshot under name on the expvar endpoint. Like
+// expvar.Publish, it panics if name is already registered.
+func (s *Stats) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return s.Snapshot() }))
+}
+
+func (s *Stats) request() {
+	if s != nil {
+		s.requests.Add(1)
+	}
+}
+
+func (s *Stats) cacheLookup(hit bool) {
+	switch {
+	case s == nil:
+	case hit:
+		s.cacheHits.Add(1)
+	default:
+		s.cacheMisses.Add(1)
+	}
+}
+
+// result records the outcome of a request under an error class.
+func (s *Stats) result(statusCode int, err error) {
+	if s == nil {
+		return
+	}
+	class := errorClass(statusCode, err)
+	if class == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[class]++
+}
+
+// trace returns ctx instrumented to count in-flight requests, active
+// connections and connection reuse, and a func to call once the round trip completes.
+func (s *Stats) trace(ctx context.Context) (context.Context, func()) {
+	if s == nil {
+		return ctx, func() {}
+	}
+	s.inFlight.Add(1)
+	var active atomic.Int64
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				s.connsReused.Add(1)
+			} else {
+				s.connsNew.Add(1)
+			}
+			if info.WasIdle {
+				s.connsIdle.Add(1)
+			}
+			active.Add(1)
+			s.connsActive.Add(1)
+		},
+	})
+	return ctx, func() {
+		s.inFlight.Add(-1)
+		s.connsActive.Add(-active.Load())
+	}
+}
+
+func errorClass(statusCode int, err error) string {
+	var shed *ShedError
+	switch {
+	case err == nil && statusCode >= 500:
+		return "http_5xx"
+	case err == nil && statusCode >= 400:
+		return "http_4xx"
+	case err == nil:
+		return ""
+	case errors.As(err, &shed):
+		return "shed"
+	case errors.Is(err, ErrInsufficientDeadline):
+		return "deadline"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "transport"
+	}
+}
@@ -0,0 +1,45 @@
+package httpclientutils_test
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats_Snapshot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	defer ts.Close()
+
+	stats := httpclientutils.NewStats()
+	cache := httpclientutils.NewCache(httpclientutils.NewMemoryStore())
+	for _, path := range []string{"/ok", "/ok", "/fail"} {
+		httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL+path),
+			httpclientutils.WithCache(cache),
+			httpclientutils.WithStats(stats),
+		)
+	}
+	httpclientutils.MakeHTTPRequest(httpclientutils.WithURL("http://127.0.0.1:0"), httpclientutils.WithStats(stats))
+
+	snapshot := stats.Snapshot()
+	assert.Equal(t, int64(4), snapshot.Requests)
+	assert.Equal(t, int64(0), snapshot.InFlight)
+	assert.Equal(t, int64(1), snapshot.CacheHits)
+	assert.Equal(t, int64(2), snapshot.CacheMisses)
+	assert.InDelta(t, 1.0/3, snapshot.CacheHitRatio, 0.001)
+	assert.Equal(t, map[string]int64{"http_5xx": 1, "transport": 1}, snapshot.Errors)
+	assert.Equal(t, int64(2), snapshot.ConnsCreated)
+
+	stats.PublishExpvar("httpclientutils_test")
+	assert.Contains(t, expvar.Get("httpclientutils_test").String(), `"requests":4`)
+}
@@ -0,0 +1,79 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"text/template"
+)
+
+// RequestTemplate describes an API call whose URL, headers and body are
+// text/template strings, so call definitions can live in configuration.
+// Templates are rendered with a variables map, e.g. "/users/{{path .id}}".
+// Besides the standard functions, templates can use path and query to
+// escape URL components and json to encode a value into a body.
+type RequestTemplate struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+var templateFuncs = template.FuncMap{
+	"path":  url.PathEscape,
+	"query": url.QueryEscape,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Render substitutes vars into the template and returns the options for
+// the resulting request. Referencing a variable missing from vars is an
+// error.
+func (t RequestTemplate) Render(vars map[string]interface{}) ([]Option, error) {
+	rawURL, err := renderTemplate("url", t.URL, vars)
+	if err != nil {
+		return nil, err
+	}
+	opts := []Option{WithMethod(orDefault(t.Method, http.MethodGet)), WithURL(rawURL)}
+	for name, value := range t.Headers {
+		rendered, err := renderTemplate("header "+name, value, vars)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, withHeader(name, rendered))
+	}
+	if t.Body != "" {
+		body, err := renderTemplate("body", t.Body, vars)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithBody(body))
+	}
+	return opts, nil
+}
+
+// Do renders the template with vars and sends the request; opts are applied
+// after the rendered options.
+func (t RequestTemplate) Do(vars map[string]interface{}, opts ...Option) (int, http.Header, []byte, error) {
+	rendered, err := t.Render(vars)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return MakeHTTPRequest(append(rendered, opts...)...)
+}
+
+func renderTemplate(name, text string, vars map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
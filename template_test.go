@@ -0,0 +1,44 @@
+package httpclientutils_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTemplate_Do(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/orgs/acme%20inc/members", r.URL.EscapedPath())
+		assert.Equal(t, "a&b", r.URL.Query().Get("team"))
+		assert.Equal(t, "Bearer t0k", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	var tmpl httpclientutils.RequestTemplate
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"method": "POST",
+		"url": "{{.base}}/orgs/{{path .org}}/members?team={{query .team}}",
+		"headers": {"Authorization": "Bearer {{.token}}", "Content-Type": "application/json"},
+		"body": "{\"user\": {{json .user}}}"
+	}`), &tmpl))
+
+	_, _, body, err := tmpl.Do(map[string]interface{}{
+		"base": ts.URL, "org": "acme inc", "team": "a&b", "token": "t0k", "user": `jo "the" dev`,
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"user":"jo \"the\" dev"}`, string(body))
+}
+
+func TestRequestTemplate_MissingVariable(t *testing.T) {
+	tmpl := httpclientutils.RequestTemplate{URL: "https://example.com/{{.id}}"}
+	_, err := tmpl.Render(map[string]interface{}{})
+	assert.ErrorContains(t, err, "failed to render url template")
+}
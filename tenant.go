@@ -0,0 +1,72 @@
+package httpclientutils
+
+import (
+	"net/http"
+	"sync"
+)
+
+// TenantPartitions isolates connection pools and in-flight limits per
+// tenant, so one tenant's traffic cannot starve the others.
+type TenantPartitions struct {
+	maxInFlight int
+	maxQueue    int
+
+	mu         sync.Mutex
+	partitions map[string]*tenantPartition
+}
+
+type tenantPartition struct {
+	scheduler  *Scheduler
+	transports map[transportConfig]*http.Transport
+}
+
+// NewTenantPartitions returns partitions giving each tenant its own
+// Scheduler with the given limits. A maxInFlight of zero disables
+// per-tenant scheduling and only partitions connection pools.
+func NewTenantPartitions(maxInFlight, maxQueue int) *TenantPartitions {
+	return &TenantPartitions{maxInFlight: maxInFlight, maxQueue: maxQueue, partitions: make(map[string]*tenantPartition)}
+}
+
+func (p *TenantPartitions) partition(tenant string) *tenantPartition {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	part, ok := p.partitions[tenant]
+	if !ok {
+		part = &tenantPartition{transports: make(map[transportConfig]*http.Transport)}
+		if p.maxInFlight > 0 {
+			part.scheduler = NewScheduler(p.maxInFlight, p.maxQueue)
+		}
+		p.partitions[tenant] = part
+	}
+	return part
+}
+
+// scheduler returns the tenant's Scheduler, or nil when scheduling is not
+// partitioned.
+func (p *TenantPartitions) scheduler(tenant string) *Scheduler {
+	return p.partition(tenant).scheduler
+}
+
+// transport returns the tenant's pooled transport for config.
+func (p *TenantPartitions) transport(tenant string, config transportConfig) *http.Transport {
+	part := p.partition(tenant)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	transport, ok := part.transports[config]
+	if !ok {
+		transport = newTransport(config)
+		part.transports[config] = transport
+	}
+	return transport
+}
+
+// CloseIdleConnections closes idle connections in every tenant's pool.
+func (p *TenantPartitions) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, part := range p.partitions {
+		for _, transport := range part.transports {
+			transport.CloseIdleConnections()
+		}
+	}
+}
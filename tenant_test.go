@@ -0,0 +1,43 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantPartitions_IsolatesInFlightLimits(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tenant") == "noisy" {
+			entered <- struct{}{}
+			<-unblock
+		}
+	}))
+	defer ts.Close()
+
+	partitions := httpclientutils.NewTenantPartitions(1, 0)
+	defer partitions.CloseIdleConnections()
+	do := func(tenant string) error {
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL+"?tenant="+tenant),
+			httpclientutils.WithTenantPartitions(partitions),
+			httpclientutils.WithTenant(tenant),
+		)
+		return err
+	}
+
+	noisy := make(chan error, 1)
+	go func() { noisy <- do("noisy") }()
+	<-entered
+
+	assert.NoError(t, do("quiet"))
+	assert.NoError(t, do("quiet"))
+
+	close(unblock)
+	assert.NoError(t, <-noisy)
+}
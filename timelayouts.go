@@ -0,0 +1,196 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pseudo-layouts for WithTimeLayouts matching Unix timestamps, sent either
+// as JSON numbers or numeric strings. LayoutUnix accepts fractional
+// seconds.
+const (
+	LayoutUnix      = "unix"
+	LayoutUnixMilli = "unixmilli"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// coerceTimes rewrites the values decoding into time.Time fields of target
+// as RFC 3339, parsing them with the first of layouts that matches.
+// Values that already are RFC 3339, or match no layout, are left for
+// encoding/json to judge. For JSONPointerTargets, each pointed-to value is
+// coerced for its own target.
+func coerceTimes(data []byte, target interface{}, layouts []string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if targets, ok := target.(JSONPointerTargets); ok {
+		for pointer, target := range targets {
+			value = coercePointer(value, pointer, reflect.TypeOf(target), layouts)
+		}
+		return json.Marshal(value)
+	}
+	return json.Marshal(coerceTime(value, reflect.TypeOf(target), layouts))
+}
+
+// coercePointer applies coerceTime to the value pointer refers to within
+// value. Pointers that do not resolve are left for JSONPointerTargets to
+// report.
+func coercePointer(value interface{}, pointer string, t reflect.Type, layouts []string) interface{} {
+	if pointer == "" {
+		return coerceTime(value, t, layouts)
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return value
+	}
+	token, rest, nested := strings.Cut(pointer[1:], "/")
+	if nested {
+		rest = "/" + rest
+	}
+	token = jsonPointerToken.Replace(token)
+	switch node := value.(type) {
+	case map[string]interface{}:
+		if item, ok := node[token]; ok {
+			node[token] = coercePointer(item, rest, t, layouts)
+		}
+	case []interface{}:
+		if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(node) {
+			node[index] = coercePointer(node[index], rest, t, layouts)
+		}
+	}
+	return value
+}
+
+func coerceTime(value interface{}, t reflect.Type, layouts []string) interface{} {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return value
+	}
+	if t == timeType {
+		if parsed, ok := parseTime(value, layouts); ok {
+			return parsed.Format(time.RFC3339Nano)
+		}
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, item := range object {
+				if field, ok := jsonFieldType(t, key); ok {
+					object[key] = coerceTime(item, field, layouts)
+				}
+			}
+		}
+	case reflect.Map:
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, item := range object {
+				object[key] = coerceTime(item, t.Elem(), layouts)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if array, ok := value.([]interface{}); ok {
+			for i, item := range array {
+				array[i] = coerceTime(item, t.Elem(), layouts)
+			}
+		}
+	}
+	return value
+}
+
+func parseTime(value interface{}, layouts []string) (time.Time, bool) {
+	var text string
+	switch value := value.(type) {
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return time.Time{}, false
+		}
+		text = value
+	case json.Number:
+		text = value.String()
+	default:
+		return time.Time{}, false
+	}
+	for _, layout := range layouts {
+		switch layout {
+		case LayoutUnix:
+			whole, fraction, _ := strings.Cut(text, ".")
+			seconds, err := strconv.ParseInt(whole, 10, 64)
+			if err != nil {
+				continue
+			}
+			var nanos int64
+			if fraction != "" {
+				fraction = (fraction + "000000000")[:9]
+				if nanos, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+					continue
+				}
+			}
+			return time.Unix(seconds, nanos), true
+		case LayoutUnixMilli:
+			if millis, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return time.UnixMilli(millis), true
+			}
+		default:
+			if parsed, err := time.Parse(layout, text); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonFieldType returns the type of the struct field encoding/json would
+// decode key into: an exact tag or field name match, else a
+// case-insensitive one, looking into untagged embedded structs.
+func jsonFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	var folded reflect.Type
+	var search func(t reflect.Type) (reflect.Type, bool)
+	search = func(t reflect.Type) (reflect.Type, bool) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					if found, ok := search(embedded); ok {
+						return found, true
+					}
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if name == key {
+				return field.Type, true
+			}
+			if folded == nil && strings.EqualFold(name, key) {
+				folded = field.Type
+			}
+		}
+		return nil, false
+	}
+	if found, ok := search(t); ok {
+		return found, true
+	}
+	return folded, folded != nil
+}
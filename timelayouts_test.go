@@ -0,0 +1,71 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTimeLayouts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"created": 1700000000,
+			"updated": "Tue, 14 Nov 2023 22:13:20 GMT",
+			"expires": "2023-11-14T22:13:20Z",
+			"events": [{"at": "1700000000.25"}],
+			"label": "1700000000"
+		}`))
+	}))
+	defer ts.Close()
+
+	var resource struct {
+		Created time.Time
+		Updated *time.Time `json:"updated"`
+		Expires time.Time
+		Events  []struct{ At time.Time }
+		Label   string
+	}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&resource),
+		httpclientutils.WithTimeLayouts(httpclientutils.LayoutUnix, http.TimeFormat),
+	)
+	assert.NoError(t, err)
+	want := time.Unix(1700000000, 0)
+	assert.True(t, want.Equal(resource.Created))
+	if assert.NotNil(t, resource.Updated) {
+		assert.True(t, want.Equal(*resource.Updated))
+	}
+	assert.True(t, want.Equal(resource.Expires))
+	if assert.Len(t, resource.Events, 1) {
+		assert.True(t, want.Add(250*time.Millisecond).Equal(resource.Events[0].At))
+	}
+	assert.Equal(t, "1700000000", resource.Label)
+}
+
+func TestWithTimeLayouts_JSONPointerTargets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": [{"at": 1700000000}], "meta": {"generated": "Tue, 14 Nov 2023 22:13:20 GMT"}}`))
+	}))
+	defer ts.Close()
+
+	var items []struct{ At time.Time }
+	var generated time.Time
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(httpclientutils.JSONPointerTargets{"/data": &items, "/meta/generated": &generated}),
+		httpclientutils.WithTimeLayouts(httpclientutils.LayoutUnix, http.TimeFormat),
+	)
+	assert.NoError(t, err)
+	want := time.Unix(1700000000, 0)
+	if assert.Len(t, items, 1) {
+		assert.True(t, want.Equal(items[0].At))
+	}
+	assert.True(t, want.Equal(generated))
+}
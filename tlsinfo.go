@@ -0,0 +1,48 @@
+package httpclientutils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+// TLSInfo describes the TLS session a response arrived over.
+type TLSInfo struct {
+	Version     uint16 // e.g. tls.VersionTLS13
+	CipherSuite uint16
+	// NegotiatedProtocol is the ALPN protocol, e.g. "h2"; empty when none
+	// was negotiated.
+	NegotiatedProtocol string
+	ServerName         string
+	// PeerCertificates is the chain the server presented, leaf first.
+	PeerCertificates []*x509.Certificate
+}
+
+func newTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	return &TLSInfo{
+		Version:            state.Version,
+		CipherSuite:        state.CipherSuite,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		ServerName:         state.ServerName,
+		PeerCertificates:   state.PeerCertificates,
+	}
+}
+
+// VersionName returns the protocol version as a string such as "TLS 1.3".
+func (t *TLSInfo) VersionName() string { return tls.VersionName(t.Version) }
+
+// CipherSuiteName returns the cipher suite name, e.g.
+// "TLS_AES_128_GCM_SHA256".
+func (t *TLSInfo) CipherSuiteName() string { return tls.CipherSuiteName(t.CipherSuite) }
+
+// Expiry returns the earliest NotAfter in the peer chain, the moment the
+// chain stops verifying; it is zero when no certificate was presented.
+func (t *TLSInfo) Expiry() time.Time {
+	var earliest time.Time
+	for _, cert := range t.PeerCertificates {
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+	return earliest
+}
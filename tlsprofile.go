@@ -0,0 +1,74 @@
+package httpclientutils
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLSProfile is a vetted set of TLS versions, cipher suites and curves
+// applied on top of any WithTLSConfig settings.
+type TLSProfile int
+
+const (
+	// TLSProfileDefault leaves versions, suites and curves to Go.
+	TLSProfileDefault TLSProfile = iota
+	// TLSProfileModern allows TLS 1.3 only. Curves are left to Go, so its
+	// default post-quantum hybrid key exchange stays enabled.
+	TLSProfileModern
+	// TLSProfileIntermediate allows TLS 1.2 and 1.3 with forward-secret
+	// AEAD suites, following Mozilla's intermediate configuration, and
+	// leaves curves to Go like TLSProfileModern.
+	TLSProfileIntermediate
+	// TLSProfileFIPS allows only FIPS 140 approved suites and curves. Go
+	// does not let callers restrict TLS 1.3 suites, so the profile caps
+	// the version at TLS 1.2, where every negotiable suite is approved.
+	TLSProfileFIPS
+)
+
+func (p TLSProfile) String() string {
+	switch p {
+	case TLSProfileDefault:
+		return "default"
+	case TLSProfileModern:
+		return "modern"
+	case TLSProfileIntermediate:
+		return "intermediate"
+	case TLSProfileFIPS:
+		return "fips"
+	default:
+		return fmt.Sprintf("tlsprofile(%d)", int(p))
+	}
+}
+
+// apply overwrites the version, suite and curve settings of config.
+func (p TLSProfile) apply(config *tls.Config) {
+	switch p {
+	case TLSProfileModern:
+		config.MinVersion = tls.VersionTLS13
+		config.MaxVersion = 0
+		config.CipherSuites = nil
+		config.CurvePreferences = nil
+	case TLSProfileIntermediate:
+		config.MinVersion = tls.VersionTLS12
+		config.MaxVersion = 0
+		config.CipherSuites = []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		}
+		config.CurvePreferences = nil
+	case TLSProfileFIPS:
+		config.MinVersion = tls.VersionTLS12
+		config.MaxVersion = tls.VersionTLS12
+		config.CipherSuites = []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		}
+		config.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+	}
+}
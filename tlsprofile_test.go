@@ -0,0 +1,70 @@
+package httpclientutils_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTLSProfile(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+
+	request := func(profile httpclientutils.TLSProfile) (*httpclientutils.Response, error) {
+		var resp httpclientutils.Response
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithTLSConfig(tlsConfig),
+			httpclientutils.WithTLSProfile(profile),
+			httpclientutils.WithResponse(&resp),
+		)
+		return &resp, err
+	}
+
+	_, err := request(httpclientutils.TLSProfileModern)
+	assert.Error(t, err)
+
+	resp, err := request(httpclientutils.TLSProfileFIPS)
+	if assert.NoError(t, err) && assert.NotNil(t, resp.TLS) {
+		assert.Equal(t, uint16(tls.VersionTLS12), resp.TLS.Version)
+		assert.Contains(t, resp.TLS.CipherSuiteName(), "_GCM_")
+	}
+	assert.Nil(t, tlsConfig.CipherSuites, "the caller's tls.Config must not be modified")
+
+	assert.Equal(t, "intermediate", httpclientutils.TLSProfileIntermediate.String())
+}
+
+func TestWithTLSProfile_KeepsDefaultCurves(t *testing.T) {
+	var curves []tls.CurveID
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		curves = hello.SupportedCurves
+		return nil, nil
+	}}
+	ts.StartTLS()
+	defer ts.Close()
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+
+	offered := func(profile httpclientutils.TLSProfile) []tls.CurveID {
+		curves = nil
+		_, _, _, err := httpclientutils.MakeHTTPRequest(
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithTLSConfig(tlsConfig),
+			httpclientutils.WithTLSProfile(profile),
+		)
+		assert.NoError(t, err)
+		return curves
+	}
+
+	defaults := offered(httpclientutils.TLSProfileDefault)
+	assert.NotEmpty(t, defaults)
+	assert.Equal(t, defaults, offered(httpclientutils.TLSProfileModern))
+	assert.Equal(t, defaults, offered(httpclientutils.TLSProfileIntermediate))
+}
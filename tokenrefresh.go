@@ -0,0 +1,197 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// TokenRefresher replays requests rejected with an expired credential: on a
+// 401, or a 403 carrying one of ErrorCodes, it calls Refresh once and
+// resends the request with the new token. If the replay is rejected again
+// its response is returned as is. The refreshed token is sent on later
+// requests sharing the TokenRefresher, and concurrent rejections share a
+// single refresh. The token is only sent to the origin of the request URL.
+type TokenRefresher struct {
+	// Scheme prefixes the token in the Authorization header; it defaults
+	// to Bearer.
+	Scheme string
+	// ErrorCodes limits refreshing to responses whose error code, taken
+	// from the WWW-Authenticate error parameter or an error, code or
+	// error_code member of a JSON body, is listed. With none, any 401 is
+	// refreshed and 403s never are.
+	ErrorCodes []string
+
+	refresh func(ctx context.Context) (string, error)
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewTokenRefresher returns a TokenRefresher obtaining new tokens from
+// refresh.
+func NewTokenRefresher(refresh func(ctx context.Context) (string, error)) *TokenRefresher {
+	return &TokenRefresher{refresh: refresh}
+}
+
+func (r *TokenRefresher) authorization(token string) string {
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	return scheme + " " + token
+}
+
+// current returns the Authorization value of the last refreshed token, or
+// "" before the first refresh.
+func (r *TokenRefresher) current() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.token == "" {
+		return ""
+	}
+	return r.authorization(r.token)
+}
+
+// renew returns a fresh Authorization value for a request rejected with
+// sent, reusing a token refreshed concurrently since.
+func (r *TokenRefresher) renew(ctx context.Context, sent string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.token != "" && r.authorization(r.token) != sent {
+		return r.authorization(r.token), nil
+	}
+	token, err := r.refresh(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+	r.token = token
+	return r.authorization(token), nil
+}
+
+// rejects reports whether resp asks for a new credential, restoring its
+// body after inspecting it.
+func (r *TokenRefresher) rejects(resp *http.Response) bool {
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized && len(r.ErrorCodes) == 0:
+		return true
+	case resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden:
+		return false
+	case len(r.ErrorCodes) == 0:
+		return false
+	}
+	for _, code := range responseErrorCodes(resp) {
+		for _, want := range r.ErrorCodes {
+			if strings.EqualFold(code, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// responseErrorCodes collects the error codes a rejection may carry.
+func responseErrorCodes(resp *http.Response) []string {
+	var codes []string
+	for _, value := range resp.Header.Values("WWW-Authenticate") {
+		for _, param := range strings.Split(value, ",") {
+			name, arg, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.HasSuffix(strings.ToLower(name), "error") {
+				codes = append(codes, strings.Trim(arg, `"`))
+			}
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return codes
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return codes
+	}
+	for _, name := range []string{"error", "code", "error_code"} {
+		var code string
+		if json.Unmarshal(fields[name], &code) == nil {
+			codes = append(codes, code)
+			continue
+		}
+		var nested struct {
+			Code string `json:"code"`
+		}
+		if json.Unmarshal(fields[name], &nested) == nil && nested.Code != "" {
+			codes = append(codes, nested.Code)
+		}
+	}
+	return codes
+}
+
+type tokenRefreshTransport struct {
+	base      http.RoundTripper
+	refresher *TokenRefresher
+	origin    string // of the request's URL; redirects elsewhere get no token
+}
+
+func (t *tokenRefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if urlOrigin(req.URL) != t.origin {
+		// A redirect to another origin must not carry the credential, as
+		// net/http drops sensitive headers on such hops.
+		if req.Header.Get("Authorization") != "" {
+			req = req.Clone(req.Context())
+			req.Header.Del("Authorization")
+		}
+		return t.base.RoundTrip(req)
+	}
+	if current := t.refresher.current(); current != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", current)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !t.refresher.rejects(resp) {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// A streamed body has been consumed and cannot be replayed.
+		return resp, nil
+	}
+
+	authorization, err := t.refresher.renew(req.Context(), req.Header.Get("Authorization"))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	attempt, err := replayableRequest(req)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	attempt.Header.Set("Authorization", authorization)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return t.base.RoundTrip(attempt)
+}
+
+func wrapTokenRefresh(options *RequestOptions, base http.RoundTripper) http.RoundTripper {
+	if options.TokenRefresher == nil {
+		return base
+	}
+	origin := ""
+	if u, err := url.Parse(options.URL); err == nil {
+		origin = urlOrigin(u)
+	}
+	return &tokenRefreshTransport{base: base, refresher: options.TokenRefresher, origin: origin}
+}
+
+// urlOrigin returns the scheme and host of u, which credentials scoped to
+// a request's URL are confined to.
+func urlOrigin(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+}
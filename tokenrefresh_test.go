@@ -0,0 +1,95 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRefresher(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	var refreshes int32
+	refresher := httpclientutils.NewTokenRefresher(func(context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		return "fresh", nil
+	})
+	refresher.ErrorCodes = []string{"invalid_token"}
+	post := func(opts ...httpclientutils.Option) (int, []byte, error) {
+		status, _, body, err := httpclientutils.MakeHTTPRequest(append([]httpclientutils.Option{
+			httpclientutils.WithMethod(http.MethodPost),
+			httpclientutils.WithURL(ts.URL),
+			httpclientutils.WithHeaders(map[string]string{"Authorization": "Bearer stale"}),
+			httpclientutils.WithBody("payload"),
+		}, opts...)...)
+		return status, body, err
+	}
+
+	status, body, err := post(httpclientutils.WithTokenRefresh(refresher))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "payload", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// The refreshed token is reused without another round trip.
+	status, _, err = post(httpclientutils.WithTokenRefresh(refresher))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+
+	// A replay that is rejected again is returned without looping.
+	stillStale := httpclientutils.NewTokenRefresher(func(context.Context) (string, error) { return "stale", nil })
+	status, _, err = post(httpclientutils.WithTokenRefresh(stillStale))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&hits))
+
+	broken := httpclientutils.NewTokenRefresher(func(context.Context) (string, error) { return "", errors.New("refresh token revoked") })
+	_, _, err = post(httpclientutils.WithTokenRefresh(broken))
+	assert.ErrorContains(t, err, "refresh token revoked")
+}
+
+func TestTokenRefresher_KeepsTokenOnOrigin(t *testing.T) {
+	var leaked atomic.Value
+	leaked.Store("")
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, other.URL+"/elsewhere", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	refresher := httpclientutils.NewTokenRefresher(func(context.Context) (string, error) { return "fresh", nil })
+	status, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(origin.URL),
+		httpclientutils.WithTokenRefresh(refresher),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "", leaked.Load())
+}
@@ -0,0 +1,47 @@
+package httpclientutils
+
+import (
+	"context"
+	"net/http"
+)
+
+// traceHeaders lists the W3C Trace Context and B3 propagation headers.
+var traceHeaders = []string{
+	"traceparent", "tracestate",
+	"b3", "X-B3-TraceId", "X-B3-SpanId", "X-B3-ParentSpanId", "X-B3-Sampled", "X-B3-Flags",
+}
+
+type traceContextKey struct{}
+
+// ContextWithTraceHeaders captures the trace propagation headers of an
+// incoming request (typically r.Header in a server handler) so requests made
+// with WithTracePropagation and this context forward them.
+func ContextWithTraceHeaders(ctx context.Context, header http.Header) context.Context {
+	captured := make(http.Header)
+	for _, name := range traceHeaders {
+		if value := header.Get(name); value != "" {
+			captured.Set(name, value)
+		}
+	}
+	return context.WithValue(ctx, traceContextKey{}, captured)
+}
+
+// applyTraceHeaders copies propagation headers from the request context,
+// then applies explicit values, leaving headers set by WithHeaders intact.
+func applyTraceHeaders(req *http.Request, options *RequestOptions) {
+	set := func(name, value string) {
+		if value != "" && req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	set("traceparent", options.TraceParent)
+	set("tracestate", options.TraceState)
+	set("b3", options.B3)
+	if options.PropagateTrace {
+		captured, _ := req.Context().Value(traceContextKey{}).(http.Header)
+		for name := range captured {
+			set(name, captured.Get(name))
+		}
+	}
+}
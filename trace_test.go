@@ -0,0 +1,50 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracePropagation_CopiesIncomingHeaders(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, traceparent, r.Header.Get("traceparent"))
+		assert.Equal(t, "vendor=1", r.Header.Get("tracestate"))
+		assert.Equal(t, "1", r.Header.Get("X-B3-Sampled"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", traceparent)
+	incoming.Set("tracestate", "vendor=1")
+	incoming.Set("X-B3-Sampled", "1")
+	incoming.Set("Authorization", "Bearer secret")
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithContext(httpclientutils.ContextWithTraceHeaders(context.Background(), incoming)),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTracePropagation(),
+	)
+	assert.NoError(t, err)
+}
+
+func TestTracePropagation_ExplicitValues(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", r.Header.Get("traceparent"))
+		assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1", r.Header.Get("b3"))
+	}))
+	defer ts.Close()
+
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", ""),
+		httpclientutils.WithB3("80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"),
+	)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,57 @@
+package httpclientutils
+
+import (
+	"io"
+	"net/http"
+)
+
+// RequestTrailer declares a request trailer whose value is computed once
+// the body has been sent, e.g. a checksum accumulated while streaming.
+type RequestTrailer struct {
+	Name  string
+	Value func() string
+}
+
+// trailerBody fills the request trailers when the body reaches EOF, which
+// is the last moment net/http allows them to change.
+type trailerBody struct {
+	io.ReadCloser
+	trailer  http.Header
+	trailers []RequestTrailer
+	done     bool
+}
+
+func (b *trailerBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF && !b.done {
+		b.done = true
+		for _, trailer := range b.trailers {
+			b.trailer.Set(trailer.Name, trailer.Value())
+		}
+	}
+	return n, err
+}
+
+// declareTrailers announces the trailers on req and switches it to chunked
+// encoding, which trailers require.
+func declareTrailers(req *http.Request, trailers []RequestTrailer) {
+	req.Trailer = make(http.Header, len(trailers))
+	for _, trailer := range trailers {
+		req.Trailer[http.CanonicalHeaderKey(trailer.Name)] = nil
+	}
+	req.ContentLength = -1
+	body := req.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	req.Body = &trailerBody{ReadCloser: body, trailer: req.Trailer, trailers: trailers}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &trailerBody{ReadCloser: body, trailer: req.Trailer, trailers: trailers}, nil
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package httpclientutils_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestAndResponseTrailers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		assert.Equal(t, hex.EncodeToString(sum[:]), r.Trailer.Get("X-Content-Sha256"))
+
+		w.Header().Set("Trailer", "X-Rows")
+		w.Write([]byte("stored"))
+		w.Header().Set("X-Rows", "3")
+	}))
+	defer ts.Close()
+
+	hash := sha256.New()
+	var resp httpclientutils.Response
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithMethod(http.MethodPut),
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithBody(io.TeeReader(strings.NewReader("a,b,c"), hash)),
+		httpclientutils.WithRequestTrailer("X-Content-Sha256", func() string { return hex.EncodeToString(hash.Sum(nil)) }),
+		httpclientutils.WithResponse(&resp),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "stored", string(body))
+	assert.Equal(t, "3", resp.Trailer.Get("X-Rows"))
+}
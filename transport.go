@@ -0,0 +1,193 @@
+package httpclientutils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"time"
+)
+
+// transportConfig holds the options that shape an http.Transport. It is
+// comparable so pooled transports can be keyed by it.
+type transportConfig struct {
+	tls           *tls.Config
+	ssrf          string // SSRFPolicy.poolKey
+	unixSocket    string
+	family        AddressFamily
+	fallbackDelay time.Duration
+	dohResolver   string
+	dotResolver   string
+	localAddr     string
+	netInterface  string
+	proxy         string
+	proxyAuth     string
+
+	checkRevocation bool
+	revocation      RevocationCheck
+
+	tlsProfile TLSProfile
+	ech        string
+	serverName string
+}
+
+func transportConfigFor(options *RequestOptions) transportConfig {
+	config := transportConfig{
+		tls:           options.TLSConfig,
+		ssrf:          options.SSRFPolicy.poolKey(),
+		unixSocket:    options.UnixSocket,
+		family:        options.AddressFamily,
+		fallbackDelay: options.FallbackDelay,
+		dohResolver:   options.DoHResolver,
+		dotResolver:   options.DoTResolver,
+		localAddr:     options.LocalAddr.String(),
+		netInterface:  options.NetworkInterface,
+		proxy:         options.Proxy,
+		proxyAuth:     options.ProxyAuthorization,
+		tlsProfile:    options.TLSProfile,
+		ech:           string(options.ECHConfigList),
+		serverName:    options.TLSServerName,
+	}
+	if options.RevocationCheck != nil {
+		config.checkRevocation = true
+		config.revocation = *options.RevocationCheck
+	}
+	return config
+}
+
+func newTransport(config transportConfig) *http.Transport {
+	transport := &http.Transport{TLSClientConfig: config.tlsConfig(), IdleConnTimeout: 90 * time.Second}
+	var resolver *net.Resolver
+	if config.dohResolver != "" || config.dotResolver != "" {
+		resolver = encryptedResolver(config.dohResolver, config.dotResolver)
+	}
+	if config.proxy != "" {
+		transport.Proxy = proxyFunc(config.proxy)
+		if policy := ssrfPolicyFromKey(config.ssrf); policy != nil {
+			transport.Proxy = policy.proxy(transport.Proxy, resolver)
+		}
+		if config.proxyAuth != "" {
+			transport.ProxyConnectHeader = http.Header{"Proxy-Authorization": {config.proxyAuth}}
+		}
+	}
+	switch {
+	case config.unixSocket != "":
+		// Every connection goes to the socket; the URL host only names it.
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", config.unixSocket)
+		}
+	case config.customDialer():
+		dialer := &net.Dialer{FallbackDelay: config.fallbackDelay}
+		if policy := ssrfPolicyFromKey(config.ssrf); policy != nil {
+			dialer.Control = policy.control
+		}
+		dialer.Resolver = resolver
+		if ip := net.ParseIP(config.localAddr); ip != nil {
+			dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+		transport.DialContext = dialContext(dialer, config.family)
+		if config.netInterface != "" {
+			transport.DialContext = dialInterface(dialer, config.netInterface, config.family)
+		}
+	}
+	return transport
+}
+
+// tlsConfig returns the configured tls.Config, cloned to apply the TLS
+// profile, ECH configuration and server name and to run the revocation
+// check after any VerifyConnection callback of its own.
+func (config transportConfig) tlsConfig() *tls.Config {
+	if !config.checkRevocation && config.tlsProfile == TLSProfileDefault && config.ech == "" && config.serverName == "" {
+		return config.tls
+	}
+	tlsConfig := &tls.Config{}
+	if config.tls != nil {
+		tlsConfig = config.tls.Clone()
+	}
+	config.tlsProfile.apply(tlsConfig)
+	if config.serverName != "" {
+		tlsConfig.ServerName = config.serverName
+	}
+	if config.ech != "" {
+		// ECH is only defined for TLS 1.3.
+		tlsConfig.EncryptedClientHelloConfigList = []byte(config.ech)
+		tlsConfig.MinVersion = max(tlsConfig.MinVersion, tls.VersionTLS13)
+	}
+	if !config.checkRevocation {
+		return tlsConfig
+	}
+	verify := tlsConfig.VerifyConnection
+	client := revocationClient(config)
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(cs); err != nil {
+				return err
+			}
+		}
+		return config.revocation.verifyConnection(cs, client)
+	}
+	return tlsConfig
+}
+
+// customDialer reports whether config needs anything beyond the default
+// dialer.
+func (config transportConfig) customDialer() bool {
+	return config.ssrf != "" || config.family != FamilyAuto || config.fallbackDelay != 0 ||
+		config.dohResolver != "" || config.dotResolver != "" ||
+		net.ParseIP(config.localAddr) != nil || config.netInterface != ""
+}
+
+func transportFor(options *RequestOptions) http.RoundTripper {
+	config := transportConfigFor(options)
+	if pooledTransport(options) {
+		return options.TenantPartitions.transport(options.Tenant, config)
+	}
+	transport := newTransport(config)
+	if options.VerifyPeerCertificate != nil || options.VerifyConnection != nil {
+		transport.TLSClientConfig = withVerifyCallbacks(transport.TLSClientConfig, options)
+	}
+	return transport
+}
+
+// pooledTransport reports whether the request shares a pooled transport.
+// Verification callbacks cannot key a pool, so requests using them get a
+// transport of their own; a tls.Config carrying the callbacks, passed with
+// WithTLSConfig, pools as usual.
+func pooledTransport(options *RequestOptions) bool {
+	return options.TenantPartitions != nil && options.VerifyPeerCertificate == nil && options.VerifyConnection == nil
+}
+
+// withVerifyCallbacks returns a clone of config running the request's
+// verification callbacks after those config already has.
+func withVerifyCallbacks(config *tls.Config, options *RequestOptions) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if verify := options.VerifyPeerCertificate; verify != nil {
+		previous := config.VerifyPeerCertificate
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if previous != nil {
+				if err := previous(rawCerts, verifiedChains); err != nil {
+					return err
+				}
+			}
+			return verify(rawCerts, verifiedChains)
+		}
+	}
+	if verify := options.VerifyConnection; verify != nil {
+		previous := config.VerifyConnection
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if previous != nil {
+				if err := previous(cs); err != nil {
+					return err
+				}
+			}
+			return verify(cs)
+		}
+	}
+	return config
+}
@@ -0,0 +1,81 @@
+package httpclientutils_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("over unix " + r.URL.Path))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	_, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL("http://localhost/ping"),
+		httpclientutils.WithUnixSocket(socket),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "over unix /ping", string(body))
+}
+
+func TestWithTLSServerName(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+
+	var serverName string
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(tlsConfig),
+		httpclientutils.WithTLSServerName("example.com"),
+		httpclientutils.WithVerifyConnection(func(cs tls.ConnectionState) error {
+			serverName = cs.ServerName
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", serverName)
+
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(tlsConfig),
+		httpclientutils.WithTLSServerName("api.internal"),
+	)
+	var invalid x509.HostnameError
+	assert.ErrorAs(t, err, &invalid)
+}
+
+func TestWithVerifyPeerCertificate(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	pinned := errors.New("certificate not pinned")
+	var chains int
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithTLSConfig(ts.Client().Transport.(*http.Transport).TLSClientConfig),
+		httpclientutils.WithTenantPartitions(httpclientutils.NewTenantPartitions(0, 0)),
+		httpclientutils.WithVerifyPeerCertificate(func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			chains = len(verifiedChains)
+			return pinned
+		}),
+	)
+	assert.ErrorIs(t, err, pinned)
+	assert.Equal(t, 1, chains)
+}
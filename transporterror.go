@@ -0,0 +1,134 @@
+package httpclientutils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// ErrorKind classifies why a request failed to get a response.
+type ErrorKind int
+
+const (
+	KindTimeout ErrorKind = iota + 1
+	KindCanceled
+	KindDNS
+	KindConnReset
+	KindTLS
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindTimeout:
+		return "timeout"
+	case KindCanceled:
+		return "canceled"
+	case KindDNS:
+		return "dns"
+	case KindConnReset:
+		return "connection reset"
+	case KindTLS:
+		return "tls"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// TransportError is a classified failure to get a response. Match it with
+// errors.Is against the Err* kinds, or errors.As and Kind.
+type TransportError struct {
+	kind ErrorKind
+	Err  error
+}
+
+var (
+	// ErrTimeout reports that the request exceeded WithTimeout.
+	ErrTimeout = &TransportError{kind: KindTimeout}
+	// ErrCanceled reports that the caller's context was canceled or hit
+	// its own deadline.
+	ErrCanceled = &TransportError{kind: KindCanceled}
+	// ErrDNS reports that the host name could not be resolved.
+	ErrDNS = &TransportError{kind: KindDNS}
+	// ErrConnReset reports that the peer reset or closed the connection
+	// mid-request.
+	ErrConnReset = &TransportError{kind: KindConnReset}
+	// ErrTLS reports a failed TLS handshake or certificate verification.
+	ErrTLS = &TransportError{kind: KindTLS}
+)
+
+func (e *TransportError) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.kind.String()
+	case e.kind == KindTimeout:
+		return fmt.Sprintf("request timed out: %v", e.Err)
+	case e.kind == KindCanceled:
+		return fmt.Sprintf("request canceled: %v", e.Err)
+	default:
+		return fmt.Sprintf("failed to send request: %s: %v", e.kind, e.Err)
+	}
+}
+
+func (e *TransportError) Unwrap() error { return e.Err }
+
+func (e *TransportError) Kind() ErrorKind { return e.kind }
+
+// Is matches the sentinel of the same kind.
+func (e *TransportError) Is(target error) bool {
+	t, ok := target.(*TransportError)
+	return ok && t.Err == nil && t.kind == e.kind
+}
+
+// sendError classifies a client.Do failure. Timeouts keep reporting a 408
+// status for callers that branch on it; cancellation by the caller does not.
+func sendError(options *RequestOptions, err error) (int, http.Header, []byte, error) {
+	kind := errorKind(options, err)
+	switch kind {
+	case 0:
+		return 0, nil, nil, fmt.Errorf("failed to send request: %w", err)
+	case KindTimeout:
+		return http.StatusRequestTimeout, nil, nil, &TransportError{kind: kind, Err: err}
+	case KindCanceled:
+		// Keep a cancellation cause such as ErrClientClosed matchable.
+		if options.Context != nil {
+			if cause := context.Cause(options.Context); cause != nil && !errors.Is(err, cause) {
+				err = fmt.Errorf("%w: %w", err, cause)
+			}
+		}
+	}
+	return 0, nil, nil, &TransportError{kind: kind, Err: err}
+}
+
+func errorKind(options *RequestOptions, err error) ErrorKind {
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		verifyErr  *tls.CertificateVerificationError
+		unknownCA  x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+	switch {
+	case options.Context != nil && options.Context.Err() != nil, errors.Is(err, context.Canceled):
+		return KindCanceled
+	case errors.As(err, &dnsErr):
+		return KindDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return KindTimeout
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &verifyErr),
+		errors.As(err, &unknownCA), errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return KindTLS
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return KindConnReset
+	}
+	return 0
+}
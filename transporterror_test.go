@@ -0,0 +1,64 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransportError_Kinds(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+	hangup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer hangup.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer secure.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		opts   []httpclientutils.Option
+		want   error
+		kind   httpclientutils.ErrorKind
+		status int
+	}{
+		{"timeout", []httpclientutils.Option{httpclientutils.WithURL(slow.URL), httpclientutils.WithTimeout(50 * time.Millisecond)},
+			httpclientutils.ErrTimeout, httpclientutils.KindTimeout, http.StatusRequestTimeout},
+		{"canceled", []httpclientutils.Option{httpclientutils.WithURL(slow.URL), httpclientutils.WithContext(canceled)},
+			httpclientutils.ErrCanceled, httpclientutils.KindCanceled, 0},
+		{"dns", []httpclientutils.Option{httpclientutils.WithURL("http://no-such-host.invalid/")},
+			httpclientutils.ErrDNS, httpclientutils.KindDNS, 0},
+		{"reset", []httpclientutils.Option{httpclientutils.WithURL(hangup.URL)},
+			httpclientutils.ErrConnReset, httpclientutils.KindConnReset, 0},
+		{"tls", []httpclientutils.Option{httpclientutils.WithURL(secure.URL)},
+			httpclientutils.ErrTLS, httpclientutils.KindTLS, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, _, err := httpclientutils.MakeHTTPRequest(tt.opts...)
+			assert.ErrorIs(t, err, tt.want)
+			var transportErr *httpclientutils.TransportError
+			if assert.True(t, errors.As(err, &transportErr)) {
+				assert.Equal(t, tt.kind, transportErr.Kind())
+				assert.NotNil(t, errors.Unwrap(transportErr))
+			}
+			assert.Equal(t, tt.status, status)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const tusVersion = "1.0.0"
+
+// TusUpload uploads with the tus.io resumable upload protocol. An upload
+// is created at Endpoint unless UploadURL is already set, in which case it
+// resumes from the server's Upload-Offset; the reader must then start at
+// the beginning of the content.
+type TusUpload struct {
+	Endpoint    string            // creation endpoint
+	Size        int64             // total size of the content, required by creation
+	Metadata    map[string]string // sent as Upload-Metadata
+	ChunkSize   int64             // defaults to 8 MiB
+	MaxAttempts int               // per chunk; defaults to 3
+	Options     []Option          // applied to every request, e.g. auth
+
+	// UploadURL is the upload's URL, set once it has been created.
+	// Persist it to resume after a restart.
+	UploadURL string
+}
+
+// Upload sends everything read from r and returns the final offset.
+func (u *TusUpload) Upload(ctx context.Context, r io.Reader) (int64, error) {
+	var base int64
+	if u.UploadURL == "" {
+		if err := u.create(ctx); err != nil {
+			return 0, err
+		}
+	} else {
+		offset, err := u.offset(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+			return 0, fmt.Errorf("failed to skip uploaded bytes: %w", err)
+		}
+		base = offset
+	}
+	if base >= u.Size {
+		return base, nil
+	}
+
+	failed := false
+	upload := &ChunkedUpload{
+		ChunkSize:   u.ChunkSize,
+		MaxAttempts: u.MaxAttempts,
+		Upload: func(ctx context.Context, chunk Chunk) error {
+			err := u.patch(ctx, base, chunk, failed)
+			failed = err != nil
+			return err
+		},
+	}
+	size, err := upload.Run(ctx, r)
+	return base + size, err
+}
+
+func (u *TusUpload) create(ctx context.Context) error {
+	opts := append([]Option{}, u.Options...)
+	opts = append(opts,
+		WithContext(ctx),
+		WithMethod(http.MethodPost),
+		WithURL(u.Endpoint),
+		withHeader("Tus-Resumable", tusVersion),
+		withHeader("Upload-Length", strconv.FormatInt(u.Size, 10)),
+	)
+	if len(u.Metadata) > 0 {
+		opts = append(opts, withHeader("Upload-Metadata", tusMetadata(u.Metadata)))
+	}
+	statusCode, header, body, err := MakeHTTPRequest(opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create tus upload: %w", err)
+	}
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("failed to create tus upload: unexpected status %d: %s", statusCode, body)
+	}
+	location := header.Get("Location")
+	if location == "" {
+		return errors.New("failed to create tus upload: no Location in response")
+	}
+	// Servers commonly answer with a path relative to the endpoint.
+	base, err := url.Parse(u.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to create tus upload: %w", err)
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return fmt.Errorf("failed to create tus upload: %w", err)
+	}
+	u.UploadURL = base.ResolveReference(ref).String()
+	return nil
+}
+
+// patch sends chunk, first asking the server for its offset when the
+// previous attempt failed.
+func (u *TusUpload) patch(ctx context.Context, base int64, chunk Chunk, retry bool) error {
+	offset, data := base+chunk.Offset, chunk.Data
+	if retry {
+		current, err := u.offset(ctx)
+		if err != nil {
+			return err
+		}
+		if skip := current - offset; skip > 0 {
+			offset, data = current, data[min(skip, int64(len(data))):]
+		}
+		if len(data) == 0 {
+			return nil
+		}
+	}
+
+	opts := append([]Option{}, u.Options...)
+	statusCode, header, body, err := MakeHTTPRequest(append(opts,
+		WithContext(ctx),
+		WithMethod(http.MethodPatch),
+		WithURL(u.UploadURL),
+		WithBody(data),
+		withHeader("Tus-Resumable", tusVersion),
+		withHeader("Content-Type", "application/offset+octet-stream"),
+		withHeader("Upload-Offset", strconv.FormatInt(offset, 10)),
+	)...)
+	switch {
+	case err != nil:
+		return err
+	case statusCode == http.StatusNoContent:
+		if got := header.Get("Upload-Offset"); got != strconv.FormatInt(offset+int64(len(data)), 10) {
+			return fmt.Errorf("tus server reported offset %s after chunk at %d", got, offset)
+		}
+		return nil
+	case retryableStatus(statusCode) || statusCode == http.StatusConflict:
+		// 409 means the offsets disagree; the retry re-reads the offset.
+		return fmt.Errorf("unexpected status %d: %s", statusCode, body)
+	default:
+		return &PermanentError{Err: fmt.Errorf("unexpected status %d: %s", statusCode, body)}
+	}
+}
+
+func (u *TusUpload) offset(ctx context.Context) (int64, error) {
+	opts := append([]Option{}, u.Options...)
+	statusCode, header, _, err := MakeHTTPRequest(append(opts,
+		WithContext(ctx),
+		WithMethod(http.MethodHead),
+		WithURL(u.UploadURL),
+		withHeader("Tus-Resumable", tusVersion),
+	)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query tus offset: %w", err)
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusNoContent {
+		return 0, fmt.Errorf("failed to query tus offset: unexpected status %d", statusCode)
+	}
+	offset, err := strconv.ParseInt(header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query tus offset: bad Upload-Offset %q", header.Get("Upload-Offset"))
+	}
+	return offset, nil
+}
+
+// tusMetadata encodes metadata as comma-separated "key base64(value)"
+// pairs, sorted for reproducible requests.
+func tusMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,95 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTusServer struct {
+	mu       sync.Mutex
+	length   int64
+	metadata string
+	data     []byte
+	failOnce bool
+}
+
+func (s *fakeTusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Tus-Resumable") != "1.0.0" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		s.length, _ = strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		s.metadata = r.Header.Get("Upload-Metadata")
+		w.Header().Set("Location", "abc")
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.Header().Set("Upload-Length", strconv.FormatInt(s.length, 10))
+	case http.MethodPatch:
+		if r.URL.Path != "/files/abc" || r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Upload-Offset") != strconv.Itoa(len(s.data)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if s.failOnce && len(s.data) > 0 {
+			// Part of the chunk arrived before the connection broke.
+			s.failOnce = false
+			s.data = append(s.data, body[:3]...)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		s.data = append(s.data, body...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestTusUpload_CreatesAndRecovers(t *testing.T) {
+	server := &fakeTusServer{failOnce: true}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	content := []byte("the quick brown fox jumps over the lazy dog")
+	upload := &httpclientutils.TusUpload{
+		Endpoint:  ts.URL + "/files/",
+		Size:      int64(len(content)),
+		Metadata:  map[string]string{"filename": "fox.txt"},
+		ChunkSize: 10,
+	}
+	size, err := upload.Upload(context.Background(), bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, ts.URL+"/files/abc", upload.UploadURL)
+	assert.Equal(t, "filename Zm94LnR4dA==", server.metadata)
+	assert.Equal(t, content, server.data)
+}
+
+func TestTusUpload_ResumesFromServerOffset(t *testing.T) {
+	content := []byte("resumable content")
+	server := &fakeTusServer{length: int64(len(content)), data: append([]byte{}, content[:9]...)}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	upload := &httpclientutils.TusUpload{UploadURL: ts.URL + "/files/abc", Size: int64(len(content))}
+	size, err := upload.Upload(context.Background(), bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.Equal(t, content, server.data)
+}
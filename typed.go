@@ -0,0 +1,51 @@
+package httpclientutils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Do sends body as the request payload, POST unless opts set another
+// method, and decodes a successful response into a Resp with the same
+// resolver as WithResolveResponse, honoring WithUnmarshalFunc and
+// WithXMLOptions. A status of 400 or above is returned as a *RequestError
+// without decoding; the Response is returned either way once one arrived.
+func Do[Req, Resp any](ctx context.Context, body Req, opts ...Option) (Resp, *Response, error) {
+	opts = append(append([]Option{WithMethod(http.MethodPost)}, opts...), WithBody(body))
+	return typedRequest[Resp](ctx, opts)
+}
+
+// Fetch sends a GET request built from opts and decodes a successful
+// response into a Resp, as Do does.
+func Fetch[Resp any](ctx context.Context, opts ...Option) (Resp, *Response, error) {
+	return typedRequest[Resp](ctx, append(append([]Option{}, opts...), WithMethod(http.MethodGet)))
+}
+
+func typedRequest[Resp any](ctx context.Context, opts []Option) (Resp, *Response, error) {
+	var out Resp
+	resp := &Response{}
+	opts = append(opts, WithContext(ctx), WithResponse(resp))
+	statusCode, header, body, err := MakeHTTPRequest(opts...)
+	if err != nil {
+		return out, resp, err
+	}
+	options := newRequestOptions(opts)
+	if statusCode >= http.StatusBadRequest {
+		return out, resp, &RequestError{Err: fmt.Errorf("request failed with status %d", statusCode), statusCode: statusCode, attempt: options.Attempt}
+	}
+	if len(body) == 0 {
+		return out, resp, nil
+	}
+
+	contentType := header.Get("Content-Type")
+	if options.UnmarshalFunc != nil {
+		err = options.UnmarshalFunc(contentType, body, &out)
+	} else {
+		err = resolveResponse(contentType, body, &out, nil, options)
+	}
+	if err != nil {
+		return out, resp, fmt.Errorf("failed to resolve response: %w", err)
+	}
+	return out, resp, nil
+}
@@ -0,0 +1,64 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type createUser struct {
+	Name string `json:"name"`
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestDo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<html>not found</html>"))
+			return
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		var req createUser
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user{ID: 7, Name: req.Name})
+	}))
+	defer ts.Close()
+
+	created, resp, err := httpclientutils.Do[createUser, user](context.Background(), createUser{Name: "ada"}, httpclientutils.WithURL(ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 7, Name: "ada"}, created)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	_, resp, err = httpclientutils.Do[createUser, user](context.Background(), createUser{}, httpclientutils.WithURL(ts.URL+"/missing"))
+	var requestErr *httpclientutils.RequestError
+	if assert.True(t, errors.As(err, &requestErr)) {
+		assert.Equal(t, http.StatusNotFound, requestErr.StatusCode())
+	}
+	assert.Equal(t, "<html>not found</html>", string(resp.Body))
+}
+
+func TestFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":1,"name":"ada"},{"id":2,"name":"grace"}]`))
+	}))
+	defer ts.Close()
+
+	users, _, err := httpclientutils.Fetch[[]user](context.Background(), httpclientutils.WithURL(ts.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, []user{{1, "ada"}, {2, "grace"}}, users)
+}
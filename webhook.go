@@ -0,0 +1,155 @@
+package httpclientutils
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WebhookConfig configures SendWebhook.
+type WebhookConfig struct {
+	// Secret signs each delivery. The signature header carries
+	// "t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">".
+	Secret []byte
+
+	SignatureHeader   string // defaults to "Webhook-Signature"
+	TimestampHeader   string // defaults to "Webhook-Timestamp"
+	IdempotencyHeader string // defaults to "Idempotency-Key"
+
+	MaxAttempts    int           // defaults to 5
+	InitialBackoff time.Duration // defaults to one second, doubling per attempt
+	MaxBackoff     time.Duration // defaults to one minute
+
+	// DeadLetter is called when every attempt has failed.
+	DeadLetter func(delivery WebhookDelivery, err error)
+
+	// Options are applied to every attempt, e.g. WithTimeout or WithStats.
+	Options []Option
+}
+
+// WebhookDelivery describes a webhook delivery.
+type WebhookDelivery struct {
+	URL            string
+	IdempotencyKey string
+	Payload        []byte
+	Attempts       int
+	StatusCode     int // status of the last attempt; zero on transport errors
+}
+
+// SendWebhook POSTs payload as JSON to url, retrying with exponential
+// backoff on retryable transport errors (see RequestError.Retryable), 408,
+// 429 and 5xx responses. Every attempt is
+// signed with a fresh timestamp and carries the same idempotency key, so
+// receivers can drop duplicates. A Retry-After header on a retryable
+// response overrides the backoff for that attempt.
+func SendWebhook(ctx context.Context, url string, payload interface{}, config WebhookConfig) (*WebhookDelivery, error) {
+	reader, err := prepareBody(payload, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare request body: %w", err)
+	}
+	var body []byte
+	if reader != nil {
+		if body, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to prepare request body: %w", err)
+		}
+	}
+	key, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &WebhookDelivery{URL: url, IdempotencyKey: key, Payload: body}
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	for {
+		delivery.Attempts++
+		statusCode, header, err := deliverWebhook(ctx, delivery, config)
+		delivery.StatusCode = statusCode
+		if err == nil && statusCode < 300 {
+			return delivery, nil
+		}
+		retry := retryableStatus(statusCode)
+		var requestErr *RequestError
+		if errors.As(err, &requestErr) {
+			// Failures without a usable response are classified by cause, so
+			// policy refusals, encoding errors and panics are not retried.
+			retry = requestErr.Retryable()
+		}
+		if err == nil {
+			err = fmt.Errorf("webhook rejected with status %d", statusCode)
+		}
+		if !retry || delivery.Attempts >= maxAttempts || ctx.Err() != nil {
+			err = fmt.Errorf("failed to deliver webhook after %d attempts: %w", delivery.Attempts, err)
+			if config.DeadLetter != nil {
+				config.DeadLetter(*delivery, err)
+			}
+			return delivery, err
+		}
+
+		wait := exponentialBackoff(config.InitialBackoff, config.MaxBackoff, delivery.Attempts)
+		if seconds, convErr := strconv.Atoi(header.Get("Retry-After")); convErr == nil && seconds >= 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func deliverWebhook(ctx context.Context, delivery *WebhookDelivery, config WebhookConfig) (int, http.Header, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := hex.EncodeToString(hmacSHA256(config.Secret, timestamp+"."+string(delivery.Payload)))
+
+	opts := append([]Option{}, config.Options...)
+	opts = append(opts,
+		WithContext(ctx),
+		WithMethod(http.MethodPost),
+		WithURL(delivery.URL),
+		WithBody(delivery.Payload),
+		WithAttempt(delivery.Attempts),
+		withHeader("Content-Type", "application/json"),
+		withHeader(orDefault(config.TimestampHeader, "Webhook-Timestamp"), timestamp),
+		withHeader(orDefault(config.SignatureHeader, "Webhook-Signature"), "t="+timestamp+",v1="+signature),
+		withHeader(orDefault(config.IdempotencyHeader, "Idempotency-Key"), delivery.IdempotencyKey),
+	)
+	statusCode, header, _, err := MakeHTTPRequest(opts...)
+	return statusCode, header, err
+}
+
+// exponentialBackoff returns the wait after the given number of failed
+// attempts: initial (default one second) doubling up to limit (default one
+// minute).
+func exponentialBackoff(initial, limit time.Duration, attempts int) time.Duration {
+	return NextDelay(attempts, BackoffPolicy{Initial: initial, Max: limit})
+}
+
+// retryableStatus reports whether a request that got statusCode may succeed
+// on a later attempt; zero means the request never got a response.
+func retryableStatus(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// withHeader sets a single header, keeping any set by earlier options
+// without modifying a map passed to WithHeaders.
+func withHeader(key, value string) Option {
+	return func(opts *RequestOptions) {
+		headers := make(map[string]string, len(opts.Headers)+1)
+		maps.Copy(headers, opts.Headers)
+		headers[key] = value
+		opts.Headers = headers
+	}
+}
@@ -0,0 +1,106 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWebhook_RetriesWithSameIdempotencyKey(t *testing.T) {
+	secret := []byte("whsec")
+	var attempts int32
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+
+		timestamp := r.Header.Get("Webhook-Timestamp")
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(timestamp + "." + string(body)))
+		assert.Equal(t, "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("Webhook-Signature"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	delivery, err := httpclientutils.SendWebhook(context.Background(), ts.URL, map[string]string{"event": "paid"}, httpclientutils.WebhookConfig{
+		Secret:         secret,
+		InitialBackoff: time.Millisecond,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.StatusCode)
+	assert.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, []string{keys[0], keys[0], keys[0]}, keys)
+}
+
+func TestSendWebhook_DeadLettersAfterMaxAttempts(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	var dead *httpclientutils.WebhookDelivery
+	_, err := httpclientutils.SendWebhook(context.Background(), ts.URL, `{"event":"paid"}`, httpclientutils.WebhookConfig{
+		Secret:         []byte("whsec"),
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		DeadLetter: func(delivery httpclientutils.WebhookDelivery, err error) {
+			dead = &delivery
+		},
+	})
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "after 3 attempts"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	if assert.NotNil(t, dead) {
+		assert.Equal(t, 3, dead.Attempts)
+		assert.Equal(t, `{"event":"paid"}`, string(dead.Payload))
+	}
+}
+
+func TestSendWebhook_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	_, err := httpclientutils.SendWebhook(context.Background(), ts.URL, "{}", httpclientutils.WebhookConfig{InitialBackoff: time.Millisecond})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestSendWebhook_DoesNotRetryPermanentFailures(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	config := httpclientutils.WebhookConfig{InitialBackoff: time.Millisecond}
+	config.Options = []httpclientutils.Option{httpclientutils.WithSSRFProtection()}
+	delivery, err := httpclientutils.SendWebhook(context.Background(), ts.URL, "{}", config)
+	assert.ErrorIs(t, err, httpclientutils.ErrSSRFBlocked)
+	assert.Equal(t, 1, delivery.Attempts)
+
+	httpclientutils.SetOffline(true)
+	defer httpclientutils.SetOffline(false)
+	delivery, err = httpclientutils.SendWebhook(context.Background(), ts.URL, "{}", httpclientutils.WebhookConfig{InitialBackoff: time.Millisecond})
+	assert.ErrorIs(t, err, httpclientutils.ErrOffline)
+	assert.Equal(t, 1, delivery.Attempts)
+}
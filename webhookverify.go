@@ -0,0 +1,148 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultWebhookTolerance is how far a signed timestamp may be from now
+// when a verifier is given no tolerance.
+const defaultWebhookTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidWebhookSignature is returned when an inbound webhook's
+	// signature is missing or does not match its body.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrWebhookTimestamp is returned when a signed webhook timestamp is
+	// outside the verifier's tolerance, e.g. for a replayed delivery.
+	ErrWebhookTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// WebhookVerifier checks the signature of an inbound webhook.
+type WebhookVerifier func(header http.Header, body []byte) error
+
+// VerifyWebhook reads the body of r and checks it with verify, returning
+// the body on success. r.Body is replaced so handlers can read it again.
+func VerifyWebhook(r *http.Request, verify WebhookVerifier) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := verify(r.Header, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// HMACVerifier verifies webhooks signed by SendWebhook: signatureHeader
+// (default Webhook-Signature) carries "t=<unix timestamp>,v1=<hex
+// HMAC-SHA256 of "<timestamp>.<body>">". Timestamps further than tolerance
+// (default five minutes) from now are rejected.
+func HMACVerifier(secret []byte, signatureHeader string, tolerance time.Duration) WebhookVerifier {
+	signatureHeader = orDefault(signatureHeader, "Webhook-Signature")
+	return func(header http.Header, body []byte) error {
+		return verifyTimestampedHMAC(secret, header.Get(signatureHeader), body, tolerance)
+	}
+}
+
+// StripeVerifier verifies the Stripe-Signature header with an endpoint's
+// signing secret (whsec_...). Stripe uses the same scheme as SendWebhook
+// and lists one v1 signature per active secret during rotation.
+func StripeVerifier(secret string, tolerance time.Duration) WebhookVerifier {
+	return func(header http.Header, body []byte) error {
+		return verifyTimestampedHMAC([]byte(secret), header.Get("Stripe-Signature"), body, tolerance)
+	}
+}
+
+// GitHubVerifier verifies the X-Hub-Signature-256 header, a hex
+// HMAC-SHA256 of the body prefixed with "sha256=". GitHub signs no
+// timestamp, so replays are only detectable by X-GitHub-Delivery.
+func GitHubVerifier(secret string) WebhookVerifier {
+	return func(header http.Header, body []byte) error {
+		signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		if !ok {
+			return fmt.Errorf("%w: missing X-Hub-Signature-256", ErrInvalidWebhookSignature)
+		}
+		return checkHMAC([]byte(secret), string(body), signature)
+	}
+}
+
+// SlackVerifier verifies the X-Slack-Signature header, "v0=" and a hex
+// HMAC-SHA256 of "v0:<X-Slack-Request-Timestamp>:<body>", with an app's
+// signing secret, rejecting timestamps further than tolerance (default
+// five minutes) from now.
+func SlackVerifier(signingSecret string, tolerance time.Duration) WebhookVerifier {
+	return func(header http.Header, body []byte) error {
+		timestamp := header.Get("X-Slack-Request-Timestamp")
+		if err := checkWebhookTimestamp(timestamp, tolerance); err != nil {
+			return err
+		}
+		signature, ok := strings.CutPrefix(header.Get("X-Slack-Signature"), "v0=")
+		if !ok {
+			return fmt.Errorf("%w: missing X-Slack-Signature", ErrInvalidWebhookSignature)
+		}
+		return checkHMAC([]byte(signingSecret), "v0:"+timestamp+":"+string(body), signature)
+	}
+}
+
+// verifyTimestampedHMAC checks a "t=<timestamp>,v1=<signature>,..." value,
+// accepting any of its v1 signatures.
+func verifyTimestampedHMAC(secret []byte, value string, body []byte, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "t":
+			timestamp = arg
+		case "v1":
+			signatures = append(signatures, arg)
+		}
+	}
+	if len(signatures) == 0 {
+		return fmt.Errorf("%w: no v1 signature", ErrInvalidWebhookSignature)
+	}
+	if err := checkWebhookTimestamp(timestamp, tolerance); err != nil {
+		return err
+	}
+	for _, signature := range signatures {
+		if checkHMAC(secret, timestamp+"."+string(body), signature) == nil {
+			return nil
+		}
+	}
+	return ErrInvalidWebhookSignature
+}
+
+func checkWebhookTimestamp(timestamp string, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = defaultWebhookTolerance
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp %q", ErrInvalidWebhookSignature, timestamp)
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrWebhookTimestamp, age.Round(time.Second))
+	}
+	return nil
+}
+
+// checkHMAC compares a hex signature with the HMAC-SHA256 of message in
+// constant time.
+func checkHMAC(secret []byte, message, signature string) error {
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, hmacSHA256(secret, message)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package httpclientutils_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhook_RoundTripsSendWebhook(t *testing.T) {
+	secret := []byte("shared")
+	verified := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := httpclientutils.VerifyWebhook(r, httpclientutils.HMACVerifier(secret, "", 0))
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		verified <- string(body)
+	}))
+	defer ts.Close()
+
+	_, err := httpclientutils.SendWebhook(context.Background(), ts.URL, map[string]string{"event": "paid"}, httpclientutils.WebhookConfig{Secret: secret})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"event":"paid"}`, <-verified)
+
+	_, err = httpclientutils.SendWebhook(context.Background(), ts.URL, "{}", httpclientutils.WebhookConfig{Secret: []byte("other"), MaxAttempts: 1})
+	assert.Error(t, err)
+}
+
+func TestWebhookVerifiers(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	stripe := httpclientutils.StripeVerifier("whsec_test", 0)
+	header := http.Header{"Stripe-Signature": {"t=" + now + ",v1=" + sign("old", now+"."+string(body)) + ",v1=" + sign("whsec_test", now+"."+string(body))}}
+	assert.NoError(t, stripe(header, body))
+	assert.ErrorIs(t, stripe(header, []byte(`{"id":"evt_2"}`)), httpclientutils.ErrInvalidWebhookSignature)
+	header = http.Header{"Stripe-Signature": {"t=" + stale + ",v1=" + sign("whsec_test", stale+"."+string(body))}}
+	assert.ErrorIs(t, stripe(header, body), httpclientutils.ErrWebhookTimestamp)
+
+	github := httpclientutils.GitHubVerifier("gh")
+	assert.NoError(t, github(http.Header{"X-Hub-Signature-256": {"sha256=" + sign("gh", string(body))}}, body))
+	assert.ErrorIs(t, github(http.Header{}, body), httpclientutils.ErrInvalidWebhookSignature)
+
+	slack := httpclientutils.SlackVerifier("sl", time.Minute)
+	header = http.Header{"X-Slack-Request-Timestamp": {now}, "X-Slack-Signature": {"v0=" + sign("sl", "v0:"+now+":"+string(body))}}
+	assert.NoError(t, slack(header, body))
+	header.Set("X-Slack-Request-Timestamp", stale)
+	assert.ErrorIs(t, slack(header, body), httpclientutils.ErrWebhookTimestamp)
+}
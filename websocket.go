@@ -0,0 +1,284 @@
+package httpclientutils
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WebSocket message types (RFC 6455 opcodes).
+const (
+	WebSocketText   = 1
+	WebSocketBinary = 2
+
+	wsContinuation = 0
+	wsClose        = 8
+	wsPing         = 9
+	wsPong         = 10
+)
+
+const (
+	webSocketGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxWebSocketMessage   = 32 << 20
+	webSocketNormalClose  = 1000
+	webSocketNoStatusCode = 1005
+)
+
+// WebSocketCloseError is returned by ReadMessage once the server has closed
+// the connection.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", e.Code, e.Reason)
+}
+
+// WebSocketConn is a client WebSocket connection. ReadMessage must be called
+// from one goroutine at a time; writes are safe for concurrent use.
+type WebSocketConn struct {
+	// Header holds the handshake response headers, e.g. the negotiated
+	// Sec-WebSocket-Protocol.
+	Header http.Header
+
+	rw      io.ReadWriteCloser
+	br      *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// DialWebSocket opens a WebSocket connection to a ws:// or wss:// URL. It
+// uses the same options as MakeHTTPRequest for the handshake, so TLS
+// settings and policies, SSRF and host policies, headers, credentials,
+// stats and trace propagation apply as they do to ordinary requests; body, cache and
+// response options are ignored.
+func DialWebSocket(rawURL string, opts ...Option) (*WebSocketConn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	switch target.Scheme {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	}
+	options := newRequestOptions(append(opts, WithURL(target.String()), WithMethod(http.MethodGet)))
+
+	// WithTimeout bounds only the handshake; an http.Client timeout would
+	// also cut off the upgraded connection. The transport hands the
+	// upgraded connection over to the caller, so the handshake context is
+	// released when DialWebSocket returns without closing it.
+	ctx, cancel := context.WithCancel(options.Context)
+	defer cancel()
+	if options.Timeout > 0 {
+		timer := time.AfterFunc(options.Timeout, cancel)
+		defer timer.Stop()
+	}
+
+	if Offline() {
+		return nil, ErrOffline
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, options.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := checkHost(options, req.URL); err != nil {
+		return nil, err
+	}
+	if err := applyRequestHeaders(req, options); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	traced, done := options.Stats.trace(req.Context())
+	defer done()
+	var redirects []RedirectHop
+	client := &http.Client{Transport: wrapTransport(options, transportFor(options)), CheckRedirect: checkRedirect(options, &redirects)}
+	resp, err := client.Do(req.WithContext(traced))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return nil, fmt.Errorf("websocket handshake failed with status %d", resp.StatusCode)
+	}
+	rw, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, errors.New("websocket handshake failed: connection is not writable")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+		rw.Close()
+		return nil, errors.New("websocket handshake failed: invalid Sec-WebSocket-Accept")
+	}
+	return &WebSocketConn{Header: resp.Header, rw: rw, br: bufio.NewReader(rw)}, nil
+}
+
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ReadMessage returns the next text or binary message, answering pings and
+// reassembling fragmented messages along the way.
+func (c *WebSocketConn) ReadMessage() (int, []byte, error) {
+	var (
+		messageType int
+		message     []byte
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			closeErr := &WebSocketCloseError{Code: webSocketNoStatusCode}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.writeFrame(wsClose, payload[:min(len(payload), 2)])
+			c.rw.Close()
+			return 0, nil, closeErr
+		case wsContinuation:
+			if messageType == 0 {
+				return 0, nil, errors.New("websocket protocol error: unexpected continuation frame")
+			}
+		default:
+			if messageType != 0 {
+				return 0, nil, errors.New("websocket protocol error: interleaved data frame")
+			}
+			messageType = opcode
+		}
+
+		if len(message)+len(payload) > maxWebSocketMessage {
+			return 0, nil, errors.New("websocket message too large")
+		}
+		message = append(message, payload...)
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+func (c *WebSocketConn) readFrame() (bool, int, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	opcode := int(head[0] & 0x0f)
+	masked := head[1]&0x80 != 0
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebSocketMessage {
+		return false, 0, nil, errors.New("websocket message too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends data as a single text or binary frame.
+func (c *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != WebSocketText && messageType != WebSocketBinary {
+		return fmt.Errorf("invalid websocket message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// writeFrame sends one final frame, masked as RFC 6455 requires of clients.
+func (c *WebSocketConn) writeFrame(opcode int, payload []byte) error {
+	frame := []byte{0x80 | byte(opcode)}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	start := len(frame)
+	frame = append(frame, payload...)
+	for i := range frame[start:] {
+		frame[start+i] ^= mask[i%4]
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.rw.Write(frame)
+	return err
+}
+
+// Close sends a normal closure frame and closes the connection.
+func (c *WebSocketConn) Close() error {
+	payload := binary.BigEndian.AppendUint16(nil, webSocketNormalClose)
+	writeErr := c.writeFrame(wsClose, payload)
+	if err := c.rw.Close(); err != nil {
+		return err
+	}
+	return writeErr
+}
@@ -0,0 +1,164 @@
+package httpclientutils_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// readClientFrame reads one small masked client frame.
+func readClientFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	head := make([]byte, 2)
+	_, err := io.ReadFull(r, head)
+	assert.NoError(t, err)
+	assert.NotZero(t, head[1]&0x80, "client frames must be masked")
+	mask := make([]byte, 4)
+	io.ReadFull(r, mask)
+	payload := make([]byte, head[1]&0x7f)
+	io.ReadFull(r, payload)
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0] & 0x0f, payload
+}
+
+func writeServerFrame(w *bufio.Writer, opcode byte, payload []byte) {
+	w.Write([]byte{0x80 | opcode, byte(len(payload))})
+	w.Write(payload)
+	w.Flush()
+}
+
+func TestDialWebSocket_EchoWithSharedOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "chat", r.Header.Get("Sec-WebSocket-Protocol"))
+		assert.True(t, strings.EqualFold(r.Header.Get("Upgrade"), "websocket"))
+
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Protocol: chat\r\nSec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		rw.Flush()
+
+		opcode, payload := readClientFrame(t, rw.Reader)
+		assert.Equal(t, byte(0x1), opcode)
+
+		// A ping, then the echo in two fragments, then a close.
+		writeServerFrame(rw.Writer, 0x9, []byte("hb"))
+		rw.Writer.Write([]byte{0x01, byte(len(payload[:2]))})
+		rw.Writer.Write(payload[:2])
+		writeServerFrame(rw.Writer, 0x0, payload[2:])
+		writeServerFrame(rw.Writer, 0x8, binary.BigEndian.AppendUint16(nil, 1001))
+
+		opcode, payload = readClientFrame(t, rw.Reader)
+		assert.Equal(t, byte(0xA), opcode)
+		assert.Equal(t, "hb", string(payload))
+		opcode, _ = readClientFrame(t, rw.Reader)
+		assert.Equal(t, byte(0x8), opcode)
+	}))
+	defer ts.Close()
+
+	conn, err := httpclientutils.DialWebSocket("ws"+strings.TrimPrefix(ts.URL, "http"),
+		httpclientutils.WithHeaders(map[string]string{"Authorization": "Bearer token", "Sec-WebSocket-Protocol": "chat"}),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, "chat", conn.Header.Get("Sec-WebSocket-Protocol"))
+
+	assert.NoError(t, conn.WriteMessage(httpclientutils.WebSocketText, []byte("hello")))
+	messageType, message, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, httpclientutils.WebSocketText, messageType)
+	assert.Equal(t, "hello", string(message))
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *httpclientutils.WebSocketCloseError
+	assert.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, 1001, closeErr.Code)
+}
+
+func TestDialWebSocket_OutlivesHandshakeContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		rw.Flush()
+
+		_, payload := readClientFrame(t, rw.Reader)
+		writeServerFrame(rw.Writer, 0x1, payload)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := httpclientutils.DialWebSocket("ws"+strings.TrimPrefix(ts.URL, "http"),
+		httpclientutils.WithContext(ctx),
+		httpclientutils.WithTimeout(50*time.Millisecond),
+	)
+	if !assert.NoError(t, err) {
+		cancel()
+		return
+	}
+	defer conn.Close()
+
+	// Neither the caller's context nor the handshake timeout ends the
+	// established connection.
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, conn.WriteMessage(httpclientutils.WebSocketText, []byte("still open")))
+	_, message, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "still open", string(message))
+}
+
+func TestDialWebSocket_RejectedHandshake(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	_, err := httpclientutils.DialWebSocket("ws" + strings.TrimPrefix(ts.URL, "http"))
+	assert.ErrorContains(t, err, "status 403")
+}
+
+func TestDialWebSocket_AppliesTransportPolicies(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	_, err := httpclientutils.DialWebSocket(wsURL, httpclientutils.WithRequireTLS())
+	assert.ErrorIs(t, err, httpclientutils.ErrPlaintextRefused)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	// The handshake is replayed with the refreshed token.
+	refresher := httpclientutils.NewTokenRefresher(func(context.Context) (string, error) { return "fresh", nil })
+	_, err = httpclientutils.DialWebSocket(wsURL, httpclientutils.WithTokenRefresh(refresher))
+	assert.ErrorContains(t, err, "status 403")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
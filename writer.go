@@ -0,0 +1,54 @@
+package httpclientutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrContentTypeNotAllowed is returned when WithResolveToWriter rejects a
+// response by its Content-Type.
+var ErrContentTypeNotAllowed = errors.New("content type not allowed")
+
+// streamResponse copies the body of resp into options.ResolveWriter if its
+// Content-Type matches the allowlist, without reading it otherwise.
+func streamResponse(resp *http.Response, options *RequestOptions) error {
+	if status, ok := options.ResolveWriter.(interface{ writeStatus(int) error }); ok {
+		if err := status.writeStatus(resp.StatusCode); err != nil {
+			return err
+		}
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !contentTypeAllowed(contentType, options.ResolveWriterTypes) {
+		return fmt.Errorf("failed to resolve response: %w: %q", ErrContentTypeNotAllowed, contentType)
+	}
+	if _, err := io.Copy(options.ResolveWriter, resp.Body); err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	return nil
+}
+
+// contentTypeAllowed matches a Content-Type against patterns such as
+// "application/pdf", "image/*" or "*/*". An empty allowlist allows any type.
+func contentTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*/*" || pattern == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package httpclientutils_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithResolveToWriter_StreamsAllowedTypes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	status, header, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveToWriter(&buf, "image/*", "application/pdf"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "image/png", header.Get("Content-Type"))
+	assert.Nil(t, body)
+	assert.Equal(t, "\x89PNG", buf.String())
+}
+
+func TestWithResolveToWriter_RejectsOtherTypes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html>error page</html>"))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveToWriter(&buf, "application/pdf"),
+	)
+	assert.ErrorIs(t, err, httpclientutils.ErrContentTypeNotAllowed)
+	assert.Zero(t, buf.Len())
+}
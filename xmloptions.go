@@ -0,0 +1,112 @@
+package httpclientutils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/clbanning/mxj/v2"
+)
+
+// mxjAttrPrefix is the prefix mxj gives attribute keys by default.
+const mxjAttrPrefix = "-"
+
+// XMLOptions shapes the map an XML response is converted to before it is
+// decoded as JSON. The zero value matches the default conversion. Options
+// are applied per request rather than through mxj's package-level setters,
+// so concurrent requests with different options do not interfere.
+type XMLOptions struct {
+	// AttrPrefix replaces the "-" prefix of attribute keys; use e.g. "@",
+	// or "" to merge attributes with child elements.
+	AttrPrefix *string
+	// Cast converts numeric and boolean text to JSON numbers and booleans.
+	Cast bool
+	// ForceArray names elements that are always decoded as arrays, even
+	// when they occur once.
+	ForceArray []string
+	// StripNamespaces drops xmlns declarations. Element and attribute
+	// prefixes are always dropped.
+	StripNamespaces bool
+}
+
+// convert parses body into a map according to the options, which may be
+// nil.
+func (o *XMLOptions) convert(body []byte) (mxj.Map, error) {
+	if o == nil {
+		return mxj.NewMapXml(body)
+	}
+	m, err := mxj.NewMapXml(body, o.Cast)
+	if err != nil {
+		return nil, err
+	}
+
+	var declarations map[string]string
+	if o.StripNamespaces {
+		if declarations, err = namespaceDeclarations(body); err != nil {
+			return nil, err
+		}
+	}
+	forceArray := make(map[string]bool, len(o.ForceArray))
+	for _, name := range o.ForceArray {
+		forceArray[name] = true
+	}
+	return o.reshape(map[string]interface{}(m), forceArray, declarations).(map[string]interface{}), nil
+}
+
+func (o *XMLOptions) reshape(value interface{}, forceArray map[string]bool, declarations map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if name, ok := strings.CutPrefix(key, mxjAttrPrefix); ok {
+				if uri, declared := declarations[name]; declared && child == uri {
+					continue
+				}
+				if o.AttrPrefix != nil {
+					key = *o.AttrPrefix + name
+				}
+			}
+			child = o.reshape(child, forceArray, declarations)
+			if _, isArray := child.([]interface{}); forceArray[key] && !isArray {
+				child = []interface{}{child}
+			}
+			out[key] = child
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = o.reshape(child, forceArray, declarations)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// namespaceDeclarations returns the xmlns attributes declared in body,
+// keyed by the local attribute name mxj converts them to.
+func namespaceDeclarations(body []byte) (map[string]string, error) {
+	declarations := make(map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			return declarations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+				declarations[attr.Name.Local] = attr.Value
+			}
+		}
+	}
+}
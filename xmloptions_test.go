@@ -0,0 +1,56 @@
+package httpclientutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+const ordersXML = `<?xml version="1.0"?>
+<orders xmlns="urn:example:orders" xmlns:x="urn:example:ext">
+  <order id="7" x:priority="high"><total>12.50</total><paid>true</paid></order>
+</orders>`
+
+func serveXML(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(ordersXML))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestWithXMLOptions_Defaults(t *testing.T) {
+	ts := serveXML(t)
+	var result map[string]interface{}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(httpclientutils.WithURL(ts.URL), httpclientutils.WithResolveResponse(&result))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"orders": map[string]interface{}{
+		"-xmlns": "urn:example:orders",
+		"-x":     "urn:example:ext",
+		"order":  map[string]interface{}{"-id": "7", "-priority": "high", "total": "12.50", "paid": "true"},
+	}}, result)
+}
+
+func TestWithXMLOptions_Reshapes(t *testing.T) {
+	ts := serveXML(t)
+	prefix := "@"
+	var result map[string]interface{}
+	_, _, _, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithResolveResponse(&result),
+		httpclientutils.WithXMLOptions(httpclientutils.XMLOptions{
+			AttrPrefix:      &prefix,
+			Cast:            true,
+			ForceArray:      []string{"order"},
+			StripNamespaces: true,
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"orders": map[string]interface{}{
+		"order": []interface{}{map[string]interface{}{"@id": float64(7), "@priority": "high", "total": 12.5, "paid": true}},
+	}}, result)
+}
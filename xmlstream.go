@@ -0,0 +1,56 @@
+package httpclientutils
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// XMLElementFunc handles one element streamed by WithXMLStream. decode
+// unmarshals the element into v following encoding/xml rules; it must be
+// called at most once. Returning an error stops the stream.
+type XMLElementFunc func(decode func(v interface{}) error) error
+
+// XMLStream passes every element named Element to Handle.
+type XMLStream struct {
+	Element string
+	Handle  XMLElementFunc
+}
+
+// run decodes body token by token, passing every matching element to the
+// handler, so documents of any size are processed in constant
+// memory.
+func (s *XMLStream) run(body io.Reader) error {
+	decoder := xml.NewDecoder(body)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse XML response: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != s.Element {
+			continue
+		}
+
+		decoded := false
+		err = s.Handle(func(v interface{}) error {
+			if decoded {
+				return errors.New("element already decoded")
+			}
+			decoded = true
+			return decoder.DecodeElement(v, &start)
+		})
+		if err != nil {
+			return err
+		}
+		if !decoded {
+			if err := decoder.Skip(); err != nil {
+				return fmt.Errorf("failed to parse XML response: %w", err)
+			}
+		}
+	}
+}
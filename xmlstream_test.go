@@ -0,0 +1,55 @@
+package httpclientutils_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/InheritxSolution/httpclientutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithXMLStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, "<feed><meta><item>not an entry</item></meta><entries>")
+		for i := 1; i <= 1000; i++ {
+			fmt.Fprintf(w, `<entry id="%d"><title>Entry %d</title></entry>`, i, i)
+		}
+		fmt.Fprint(w, "</entries></feed>")
+	}))
+	defer ts.Close()
+
+	type entry struct {
+		ID    int    `xml:"id,attr"`
+		Title string `xml:"title"`
+	}
+	var count, skipped int
+	var last entry
+	status, _, body, err := httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithXMLStream("entry", func(decode func(v interface{}) error) error {
+			count++
+			if count%2 == 0 {
+				skipped++
+				return nil
+			}
+			return decode(&last)
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, body)
+	assert.Equal(t, 1000, count)
+	assert.Equal(t, 500, skipped)
+	assert.Equal(t, entry{ID: 999, Title: "Entry 999"}, last)
+
+	stop := errors.New("stop")
+	_, _, _, err = httpclientutils.MakeHTTPRequest(
+		httpclientutils.WithURL(ts.URL),
+		httpclientutils.WithXMLStream("entry", func(func(v interface{}) error) error { return stop }),
+	)
+	assert.ErrorIs(t, err, stop)
+}